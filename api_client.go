@@ -41,10 +41,17 @@ const delayMultiplier = 2
 
 type apiClient struct {
 	clientConfig *ClientConfig
+	// pool, if set, sends the requests with the clients of a [Pool] instead.
+	pool *clientPool
 }
 
 // sendStreamRequest issues an server streaming API request and returns a map of the response contents.
 func sendStreamRequest[T responseStream[R], R any](ctx context.Context, ac *apiClient, path string, method string, body map[string]any, httpOptions *HTTPOptions, output *responseStream[R]) error {
+	if ac.pool != nil {
+		return ac.pool.do(ctx, func(member *apiClient) error {
+			return sendStreamRequest[T](ctx, member, path, method, body, httpOptions, output)
+		})
+	}
 	req, httpOptions, err := buildRequest(ctx, ac, path, body, method, httpOptions)
 	if err != nil {
 		return err
@@ -75,6 +82,14 @@ func sendStreamRequest[T responseStream[R], R any](ctx context.Context, ac *apiC
 
 // sendRequest issues an API request and returns a map of the response contents.
 func sendRequest(ctx context.Context, ac *apiClient, path string, method string, body map[string]any, httpOptions *HTTPOptions) (map[string]any, error) {
+	if ac.pool != nil {
+		var output map[string]any
+		err := ac.pool.do(ctx, func(member *apiClient) (err error) {
+			output, err = sendRequest(ctx, member, path, method, body, httpOptions)
+			return err
+		})
+		return output, err
+	}
 
 	req, httpOptions, err := buildRequest(ctx, ac, path, body, method, httpOptions)
 	if err != nil {
@@ -0,0 +1,358 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+)
+
+// EmbeddingInput is a single input of an embeddings batch, identified by a
+// caller-chosen ID.
+type EmbeddingInput struct {
+	// Required. Caller-defined identifier, echoed back in the matching
+	// [EmbeddingResult].
+	ID string `json:"id,omitempty"`
+	// Required. The content to embed. Only text parts are supported.
+	Content *Content `json:"content,omitempty"`
+}
+
+// EmbeddingResult is the embedding computed for an [EmbeddingInput].
+type EmbeddingResult struct {
+	// The ID of the input.
+	ID string `json:"id,omitempty"`
+	// The embedding vector. Nil if the input failed.
+	Values []float32 `json:"values,omitempty"`
+	// The error encountered while embedding the input, if any.
+	Error *JobError `json:"error,omitempty"`
+}
+
+// CreateEmbeddingsBatchConfig holds optional parameters for
+// [Batches.CreateEmbeddingsBatch].
+type CreateEmbeddingsBatchConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. The user-defined name of the batch job.
+	DisplayName string `json:"displayName,omitempty"`
+	// Optional. Parameters applied to every input, such as TaskType and
+	// OutputDimensionality. OutputDimensionality is not supported in Vertex AI.
+	EmbedConfig *EmbedContentConfig `json:"embedConfig,omitempty"`
+	// Vertex AI only. Required. Cloud Storage URI the JSONL input file is
+	// written to, for example "gs://bucket/embeddings/input.jsonl".
+	GCSInputURI string `json:"gcsInputUri,omitempty"`
+	// Vertex AI only. Required. Cloud Storage URI prefix the batch prediction
+	// job writes its output to, for example "gs://bucket/embeddings/output".
+	GCSOutputURIPrefix string `json:"gcsOutputUriPrefix,omitempty"`
+}
+
+// EmbeddingsBatch is a handle to an embeddings batch job created by
+// [Batches.CreateEmbeddingsBatch]. It is JSON-serializable so that results can be
+// read by a different process than the one that created the job.
+type EmbeddingsBatch struct {
+	// Job is the underlying batch job.
+	Job *BatchJob `json:"job,omitempty"`
+	// IDs are the input IDs, in input order.
+	IDs []string `json:"ids,omitempty"`
+	// Texts are the embedded texts, in input order. Vertex AI does not preserve
+	// the input order in its output, so results are matched by text.
+	Texts []string `json:"texts,omitempty"`
+}
+
+// CreateEmbeddingsBatch starts a batch job that embeds all inputs.
+//
+// On the Gemini Developer API the inputs are sent inline to
+// [Batches.CreateEmbeddings]. On Vertex AI they are written as JSONL to
+// config.GCSInputURI and a batch prediction job is created for the model. Use
+// [Batches.EmbeddingsBatchResults] once the job has succeeded.
+func (b Batches) CreateEmbeddingsBatch(ctx context.Context, model string, inputs []*EmbeddingInput, config *CreateEmbeddingsBatchConfig) (*EmbeddingsBatch, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("at least one embedding input is required")
+	}
+	if config == nil {
+		config = &CreateEmbeddingsBatchConfig{}
+	}
+	batch := &EmbeddingsBatch{IDs: make([]string, len(inputs)), Texts: make([]string, len(inputs))}
+	seen := make(map[string]bool, len(inputs))
+	for i, in := range inputs {
+		if in == nil || in.Content == nil {
+			return nil, fmt.Errorf("embedding input %d is nil", i)
+		}
+		if in.ID == "" {
+			return nil, fmt.Errorf("embedding input %d has an empty ID", i)
+		}
+		if seen[in.ID] {
+			return nil, fmt.Errorf("duplicate embedding input ID %q", in.ID)
+		}
+		seen[in.ID] = true
+		text, err := embeddingInputText(in.Content)
+		if err != nil {
+			return nil, fmt.Errorf("embedding input %q: %w", in.ID, err)
+		}
+		batch.IDs[i] = in.ID
+		batch.Texts[i] = text
+	}
+
+	var err error
+	if b.apiClient.clientConfig.Backend == BackendVertexAI {
+		batch.Job, err = b.createVertexEmbeddingsBatch(ctx, model, batch.Texts, config)
+	} else {
+		contents := make([]*Content, len(inputs))
+		for i, in := range inputs {
+			contents[i] = in.Content
+		}
+		batch.Job, err = b.CreateEmbeddings(ctx, &model, &EmbeddingsBatchJobSource{
+			InlinedRequests: &EmbedContentBatch{Contents: contents, Config: config.EmbedConfig},
+		}, &CreateEmbeddingsBatchJobConfig{HTTPOptions: config.HTTPOptions, DisplayName: config.DisplayName})
+		// Only the Vertex AI results are matched by text.
+		batch.Texts = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func embeddingInputText(content *Content) (string, error) {
+	var texts []string
+	for _, p := range content.Parts {
+		if p == nil {
+			continue
+		}
+		if p.Text == "" {
+			return "", fmt.Errorf("only text parts are supported in embeddings batches")
+		}
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+func (b Batches) createVertexEmbeddingsBatch(ctx context.Context, model string, texts []string, config *CreateEmbeddingsBatchConfig) (*BatchJob, error) {
+	if config.GCSInputURI == "" || config.GCSOutputURIPrefix == "" {
+		return nil, fmt.Errorf("GCSInputURI and GCSOutputURIPrefix are required for Vertex AI embeddings batches")
+	}
+	instance := map[string]any{}
+	if c := config.EmbedConfig; c != nil {
+		if c.OutputDimensionality != nil {
+			return nil, fmt.Errorf("OutputDimensionality is not supported for Vertex AI embeddings batches")
+		}
+		if c.TaskType != "" {
+			instance["task_type"] = c.TaskType
+		}
+		if c.Title != "" {
+			instance["title"] = c.Title
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, text := range texts {
+		instance["content"] = text
+		if err := enc.Encode(instance); err != nil {
+			return nil, err
+		}
+	}
+	if err := uploadGCSObject(ctx, b.apiClient, config.GCSInputURI, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("writing embeddings batch input to %s: %w", config.GCSInputURI, err)
+	}
+	return b.Create(ctx, model, &BatchJobSource{Format: "jsonl", GCSURI: []string{config.GCSInputURI}}, &CreateBatchJobConfig{
+		HTTPOptions: config.HTTPOptions,
+		DisplayName: config.DisplayName,
+		Dest:        &BatchJobDestination{Format: "jsonl", GCSURI: config.GCSOutputURIPrefix},
+	})
+}
+
+// EmbeddingsBatchResults fetches the latest state of the batch job and, if it
+// has succeeded, returns an iterator over the embedding of every input. A
+// non-nil error ends the iteration.
+func (b Batches) EmbeddingsBatchResults(ctx context.Context, batch *EmbeddingsBatch, config *BatchResultsConfig) iter.Seq2[*EmbeddingResult, error] {
+	return func(yield func(*EmbeddingResult, error) bool) {
+		if batch == nil || batch.Job == nil {
+			yield(nil, fmt.Errorf("embeddings batch has no job"))
+			return
+		}
+		var httpOptions *HTTPOptions
+		if config != nil {
+			httpOptions = config.HTTPOptions
+		}
+		var (
+			job       *BatchJob
+			outputDir string
+			err       error
+		)
+		if b.apiClient.clientConfig.Backend == BackendVertexAI {
+			job, outputDir, err = b.getVertexBatchJob(ctx, batch.Job.Name, httpOptions)
+		} else {
+			job, err = b.Get(ctx, batch.Job.Name, &GetBatchJobConfig{HTTPOptions: cloneHTTPOptions(httpOptions)})
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		batch.Job = job
+		if job.State != JobStateSucceeded {
+			yield(nil, fmt.Errorf("embeddings batch job %s has not succeeded (state %s)", job.Name, job.State))
+			return
+		}
+
+		var results iter.Seq2[*EmbeddingResult, error]
+		if b.apiClient.clientConfig.Backend == BackendVertexAI {
+			results = b.vertexEmbeddingsBatchResults(ctx, batch, outputDir)
+		} else {
+			results = mldevEmbeddingsBatchResults(batch)
+		}
+		for r, err := range results {
+			if !yield(r, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func mldevEmbeddingsBatchResults(batch *EmbeddingsBatch) iter.Seq2[*EmbeddingResult, error] {
+	return func(yield func(*EmbeddingResult, error) bool) {
+		var responses []*InlinedEmbedContentResponse
+		if batch.Job.Dest != nil {
+			responses = batch.Job.Dest.InlinedEmbedContentResponses
+		}
+		if len(responses) != len(batch.IDs) {
+			yield(nil, fmt.Errorf("embeddings batch job %s returned %d responses for %d inputs", batch.Job.Name, len(responses), len(batch.IDs)))
+			return
+		}
+		for i, r := range responses {
+			result := &EmbeddingResult{ID: batch.IDs[i], Error: r.Error}
+			if r.Response != nil && r.Response.Embedding != nil {
+				result.Values = r.Response.Embedding.Values
+			}
+			if !yield(result, nil) {
+				return
+			}
+		}
+	}
+}
+
+// getVertexBatchJob gets a Vertex AI batch prediction job, along with the
+// Cloud Storage directory its output was written to. [BatchJob] doesn't hold
+// the output info of the job, so the response is read here.
+func (b Batches) getVertexBatchJob(ctx context.Context, name string, httpOptions *HTTPOptions) (*BatchJob, string, error) {
+	id, err := tBatchJobName(b.apiClient, name)
+	if err != nil {
+		return nil, "", err
+	}
+	httpOptions = cloneHTTPOptions(httpOptions)
+	if httpOptions == nil {
+		httpOptions = &HTTPOptions{}
+	}
+	if httpOptions.Headers == nil {
+		httpOptions.Headers = http.Header{}
+	}
+	responseMap, err := sendRequest(ctx, b.apiClient, "batchPredictionJobs/"+id.(string), http.MethodGet, nil, httpOptions)
+	if err != nil {
+		return nil, "", err
+	}
+	outputDir, _ := getValueByPath(responseMap, []string{"outputInfo", "gcsOutputDirectory"}).(string)
+	responseMap, err = batchJobFromVertex(responseMap, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	job := new(BatchJob)
+	if err := mapToStruct(responseMap, job); err != nil {
+		return nil, "", err
+	}
+	return job, outputDir, nil
+}
+
+// vertexEmbeddingsBatchResults reads the prediction files that a Vertex AI
+// batch prediction job wrote to outputDir. Each line echoes its input
+// instance, which is used to find the ID the prediction belongs to. Inputs
+// without a prediction are reported in a final error.
+func (b Batches) vertexEmbeddingsBatchResults(ctx context.Context, batch *EmbeddingsBatch, outputDir string) iter.Seq2[*EmbeddingResult, error] {
+	return func(yield func(*EmbeddingResult, error) bool) {
+		if outputDir == "" {
+			yield(nil, fmt.Errorf("embeddings batch job %s has no output directory", batch.Job.Name))
+			return
+		}
+		idsByText := make(map[string][]string, len(batch.Texts))
+		for i, text := range batch.Texts {
+			idsByText[text] = append(idsByText[text], batch.IDs[i])
+		}
+		uris, err := listGCSObjects(ctx, b.apiClient, outputDir)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, uri := range uris {
+			if !strings.HasSuffix(uri, ".jsonl") {
+				continue
+			}
+			data, err := downloadGCSObject(ctx, b.apiClient, uri)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for n, line := range bytes.Split(data, []byte("\n")) {
+				if len(bytes.TrimSpace(line)) == 0 {
+					continue
+				}
+				var prediction struct {
+					Instance struct {
+						Content string `json:"content"`
+					} `json:"instance"`
+					Predictions []struct {
+						Embeddings *ContentEmbedding `json:"embeddings"`
+					} `json:"predictions"`
+					Status string `json:"status"`
+				}
+				if err := json.Unmarshal(line, &prediction); err != nil {
+					yield(nil, fmt.Errorf("parsing %s line %d: %w", uri, n+1, err))
+					return
+				}
+				var values []float32
+				var jobErr *JobError
+				if len(prediction.Predictions) > 0 && prediction.Predictions[0].Embeddings != nil {
+					values = prediction.Predictions[0].Embeddings.Values
+				} else {
+					jobErr = &JobError{Message: prediction.Status}
+				}
+				// Inputs with identical text each get one of the matching lines.
+				ids := idsByText[prediction.Instance.Content]
+				if len(ids) == 0 {
+					continue
+				}
+				idsByText[prediction.Instance.Content] = ids[1:]
+				if !yield(&EmbeddingResult{ID: ids[0], Values: values, Error: jobErr}, nil) {
+					return
+				}
+			}
+		}
+
+		// Report the inputs left without a prediction, in input order.
+		var missing []string
+		for _, text := range batch.Texts {
+			ids := idsByText[text]
+			if len(ids) == 0 {
+				continue
+			}
+			missing = append(missing, ids[0])
+			idsByText[text] = ids[1:]
+		}
+		if len(missing) > 0 {
+			yield(nil, fmt.Errorf("embeddings batch job %s has no result for inputs %s", batch.Job.Name, strings.Join(missing, ", ")))
+		}
+	}
+}
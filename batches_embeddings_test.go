@@ -0,0 +1,239 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBatchesEmbeddingsBatchGeminiAPI(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1beta/models/gemini-embedding-001:asyncBatchEmbedContent":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Failed to decode request: %v", err)
+			}
+			requests := getValueByPath(body, []string{"batch", "inputConfig", "requests", "requests"}).([]any)
+			if len(requests) != 2 {
+				t.Errorf("got %d inlined requests, want 2", len(requests))
+			}
+			_, _ = w.Write([]byte(`{"name":"batches/emb","metadata":{"state":"BATCH_STATE_PENDING"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta/batches/emb":
+			_, _ = w.Write([]byte(`{"name":"batches/emb","metadata":{"state":"BATCH_STATE_SUCCEEDED","output":{"inlinedEmbedContentResponses":{"inlinedResponses":[
+				{"response":{"embedding":{"values":[0.1,0.2]}}},
+				{"error":{"code":3,"message":"too long"}}]}}}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	batch, err := client.Batches.CreateEmbeddingsBatch(ctx, "gemini-embedding-001", []*EmbeddingInput{
+		{ID: "doc-1", Content: NewContentFromText("hello", RoleUser)},
+		{ID: "doc-2", Content: NewContentFromText("world", RoleUser)},
+	}, &CreateEmbeddingsBatchConfig{EmbedConfig: &EmbedContentConfig{TaskType: "RETRIEVAL_DOCUMENT"}})
+	if err != nil {
+		t.Fatalf("CreateEmbeddingsBatch() unexpected error: %v", err)
+	}
+
+	var got []*EmbeddingResult
+	for r, err := range client.Batches.EmbeddingsBatchResults(ctx, batch, nil) {
+		if err != nil {
+			t.Fatalf("EmbeddingsBatchResults() unexpected error: %v", err)
+		}
+		got = append(got, r)
+	}
+	want := []*EmbeddingResult{
+		{ID: "doc-1", Values: []float32{0.1, 0.2}},
+		{ID: "doc-2", Error: &JobError{Code: Ptr[int32](3), Message: "too long"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("EmbeddingsBatchResults() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchesEmbeddingsBatchVertexAI(t *testing.T) {
+	ctx := context.Background()
+	var input string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const jobsPath = "/v1beta1/projects/test-project/locations/us-central1/batchPredictionJobs"
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/bkt/o":
+			if got := r.URL.Query().Get("name"); got != "in/input.jsonl" {
+				t.Errorf("uploaded object name = %q, want %q", got, "in/input.jsonl")
+			}
+			b, _ := io.ReadAll(r.Body)
+			input = string(b)
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && r.URL.Path == jobsPath:
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/locations/us-central1/batchPredictionJobs/1","state":"JOB_STATE_PENDING"}`))
+		case r.Method == http.MethodGet && r.URL.Path == jobsPath+"/1":
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/locations/us-central1/batchPredictionJobs/1","state":"JOB_STATE_SUCCEEDED",
+				"outputConfig":{"gcsDestination":{"outputUriPrefix":"gs://bkt/out"}},
+				"outputInfo":{"gcsOutputDirectory":"gs://bkt/out/prediction-model-1"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/bkt/o":
+			if got := r.URL.Query().Get("prefix"); got != "out/prediction-model-1/" {
+				t.Errorf("list prefix = %q, want %q", got, "out/prediction-model-1/")
+			}
+			_, _ = w.Write([]byte(`{"items":[{"name":"out/prediction-model-1/000000000000.jsonl"},{"name":"out/prediction-model-1/stats"}]}`))
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/storage/v1/b/bkt/o/out%2Fprediction-model-1%2F000000000000.jsonl":
+			_, _ = w.Write([]byte(`{"instance":{"content":"world"},"predictions":[{"embeddings":{"values":[2]}}]}
+{"instance":{"content":"hello"},"predictions":[{"embeddings":{"values":[1]}}]}
+{"instance":{"content":"hello"},"predictions":[{"embeddings":{"values":[1]}}]}
+`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	oldStorageBaseURL := storageBaseURL
+	storageBaseURL = ts.URL + "/"
+	defer func() { storageBaseURL = oldStorageBaseURL }()
+
+	client, err := NewClient(ctx, &ClientConfig{
+		Backend:     BackendVertexAI,
+		Project:     "test-project",
+		Location:    "us-central1",
+		HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+		HTTPClient:  ts.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	batch, err := client.Batches.CreateEmbeddingsBatch(ctx, "text-embedding-005", []*EmbeddingInput{
+		{ID: "a", Content: NewContentFromText("hello", RoleUser)},
+		{ID: "b", Content: NewContentFromText("world", RoleUser)},
+		{ID: "c", Content: NewContentFromText("hello", RoleUser)},
+	}, &CreateEmbeddingsBatchConfig{
+		EmbedConfig:        &EmbedContentConfig{TaskType: "RETRIEVAL_QUERY"},
+		GCSInputURI:        "gs://bkt/in/input.jsonl",
+		GCSOutputURIPrefix: "gs://bkt/out",
+	})
+	if err != nil {
+		t.Fatalf("CreateEmbeddingsBatch() unexpected error: %v", err)
+	}
+	wantInput := `{"content":"hello","task_type":"RETRIEVAL_QUERY"}
+{"content":"world","task_type":"RETRIEVAL_QUERY"}
+{"content":"hello","task_type":"RETRIEVAL_QUERY"}
+`
+	if diff := cmp.Diff(wantInput, input); diff != "" {
+		t.Errorf("uploaded input mismatch (-want +got):\n%s", diff)
+	}
+
+	var got []*EmbeddingResult
+	for r, err := range client.Batches.EmbeddingsBatchResults(ctx, batch, nil) {
+		if err != nil {
+			t.Fatalf("EmbeddingsBatchResults() unexpected error: %v", err)
+		}
+		got = append(got, r)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].ID < got[j].ID })
+	want := []*EmbeddingResult{
+		{ID: "a", Values: []float32{1}},
+		{ID: "b", Values: []float32{2}},
+		{ID: "c", Values: []float32{1}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("EmbeddingsBatchResults() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchesEmbeddingsBatchVertexAIErrors(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta1/projects/test-project/locations/us-central1/batchPredictionJobs/1":
+			_, _ = w.Write([]byte(`{"name":"projects/test-project/locations/us-central1/batchPredictionJobs/1","state":"JOB_STATE_SUCCEEDED",
+				"outputInfo":{"gcsOutputDirectory":"gs://bkt/out/prediction-model-1"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/storage/v1/b/bkt/o":
+			_, _ = w.Write([]byte(`{"items":[{"name":"out/prediction-model-1/000000000000.jsonl"}]}`))
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/storage/v1/b/bkt/o/out%2Fprediction-model-1%2F000000000000.jsonl":
+			_, _ = w.Write([]byte(`{"instance":{"content":"hello"},"predictions":[{"embeddings":{"values":[1]}}]}` + "\n"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	oldStorageBaseURL := storageBaseURL
+	storageBaseURL = ts.URL + "/"
+	defer func() { storageBaseURL = oldStorageBaseURL }()
+
+	t.Run("missing results", func(t *testing.T) {
+		client, err := NewClient(ctx, &ClientConfig{
+			Backend:     BackendVertexAI,
+			Project:     "test-project",
+			Location:    "us-central1",
+			HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+			HTTPClient:  ts.Client(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		batch := &EmbeddingsBatch{
+			Job:   &BatchJob{Name: "projects/test-project/locations/us-central1/batchPredictionJobs/1"},
+			IDs:   []string{"a", "b", "c"},
+			Texts: []string{"world", "hello", "hello"},
+		}
+		var ids []string
+		var gotErr error
+		for r, err := range client.Batches.EmbeddingsBatchResults(ctx, batch, nil) {
+			if err != nil {
+				gotErr = err
+				break
+			}
+			ids = append(ids, r.ID)
+		}
+		if diff := cmp.Diff([]string{"b"}, ids); diff != "" {
+			t.Errorf("EmbeddingsBatchResults() IDs mismatch (-want +got):\n%s", diff)
+		}
+		if gotErr == nil || !strings.HasSuffix(gotErr.Error(), "has no result for inputs a, c") {
+			t.Errorf("EmbeddingsBatchResults() error = %v, want an error listing a and c", gotErr)
+		}
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		client, err := NewClient(ctx, &ClientConfig{
+			Backend:     BackendVertexAI,
+			APIKey:      "test-api-key",
+			HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+			HTTPClient:  ts.Client(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		_, err = client.Batches.CreateEmbeddingsBatch(ctx, "text-embedding-005", []*EmbeddingInput{
+			{ID: "a", Content: NewContentFromText("hello", RoleUser)},
+		}, &CreateEmbeddingsBatchConfig{GCSInputURI: "gs://bkt/in/input.jsonl", GCSOutputURIPrefix: "gs://bkt/out"})
+		if err == nil || !strings.Contains(err.Error(), "API key") {
+			t.Errorf("CreateEmbeddingsBatch() error = %v, want an API key error", err)
+		}
+	})
+}
@@ -0,0 +1,392 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+)
+
+// defaultMaxInlinedBatchBytes is the largest serialized size of the inlined
+// requests of a single batch job accepted by the Gemini Developer API.
+const defaultMaxInlinedBatchBytes = 20 * 1024 * 1024
+
+// BatchOverflowStrategy selects what [Batches.CreateGroup] does when the
+// inlined requests are too large for a single batch job.
+type BatchOverflowStrategy string
+
+const (
+	// BatchOverflowUploadFile writes the requests to a JSONL file, uploads it
+	// and creates a single file-based batch job. This is the default.
+	BatchOverflowUploadFile BatchOverflowStrategy = "UPLOAD_FILE"
+	// BatchOverflowSplit splits the requests into several inlined batch jobs
+	// that each fit within the size limit.
+	BatchOverflowSplit BatchOverflowStrategy = "SPLIT"
+)
+
+// CreateBatchJobGroupConfig holds optional parameters for [Batches.CreateGroup].
+type CreateBatchJobGroupConfig struct {
+	// Optional. Configuration applied to every batch job created.
+	BatchJob *CreateBatchJobConfig `json:"batchJob,omitempty"`
+	// Optional. Maximum serialized size in bytes of the body of the creation
+	// request of a single inlined batch job. Defaults to 20 MB.
+	MaxInlinedRequestBytes int64 `json:"maxInlinedRequestBytes,omitempty"`
+	// Optional. What to do when the requests exceed MaxInlinedRequestBytes.
+	// Defaults to BatchOverflowUploadFile.
+	Overflow BatchOverflowStrategy `json:"overflow,omitempty"`
+	// Optional. Configuration for uploading the input file when Overflow is
+	// BatchOverflowUploadFile.
+	Upload *UploadFileConfig `json:"upload,omitempty"`
+}
+
+// BatchJobGroup is a set of batch jobs that together process the requests
+// passed to [Batches.CreateGroup]. Results of all jobs are keyed by the index of
+// the request in the original [BatchJobSource.InlinedRequests].
+type BatchJobGroup struct {
+	// Jobs are the batch jobs of the group, as of the last call to
+	// [BatchJobGroup.Refresh].
+	Jobs []*BatchJob
+
+	batches Batches
+	// offsets holds, for every job, the index of its first request in the
+	// original request list.
+	offsets []int
+}
+
+// CreateGroup creates one or more batch jobs for the inlined requests of src.
+//
+// The serialized size of the creation request is estimated before anything is
+// sent. If it fits within config.MaxInlinedRequestBytes, a single inlined batch
+// job is created. Otherwise the requests are either moved to an uploaded JSONL
+// file or split across several inlined batch jobs, depending on
+// config.Overflow. The Metadata of the requests isn't sent when they are moved
+// to a file, since file-based batches don't support it; the results are keyed
+// by request index either way. If a job of a split group can't be created, the
+// jobs already created are canceled and the error is returned.
+//
+// This method is only supported in the Gemini Developer client.
+func (b Batches) CreateGroup(ctx context.Context, model string, src *BatchJobSource, config *CreateBatchJobGroupConfig) (*BatchJobGroup, error) {
+	if b.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, fmt.Errorf("method CreateGroup is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
+	}
+	if src == nil || len(src.InlinedRequests) == 0 {
+		return nil, fmt.Errorf("InlinedRequests must be set.")
+	}
+	if config == nil {
+		config = &CreateBatchJobGroupConfig{}
+	}
+	limit := config.MaxInlinedRequestBytes
+	if limit <= 0 {
+		limit = defaultMaxInlinedBatchBytes
+	}
+
+	envelope, err := batchEnvelopeSize(b.apiClient, model, config.BatchJob)
+	if err != nil {
+		return nil, err
+	}
+	if envelope >= limit {
+		return nil, fmt.Errorf("the batch job creation request without its inlined requests is %d bytes, which exceeds the limit of %d bytes", envelope, limit)
+	}
+	// The remaining bytes are left for the inlined requests of each job.
+	limit -= envelope
+
+	sizes := make([]int64, len(src.InlinedRequests))
+	var total int64
+	for i, r := range src.InlinedRequests {
+		size, err := inlinedRequestSize(b.apiClient, r)
+		if err != nil {
+			return nil, fmt.Errorf("inlined request %d: %w", i, err)
+		}
+		sizes[i] = size
+		total += size
+	}
+
+	group := &BatchJobGroup{batches: b}
+	if total <= limit {
+		job, err := b.Create(ctx, model, src, config.BatchJob)
+		if err != nil {
+			return nil, err
+		}
+		group.add(job, 0)
+		return group, nil
+	}
+
+	switch config.Overflow {
+	case "", BatchOverflowUploadFile:
+		requests := make([]*BatchRequest, len(src.InlinedRequests))
+		for i, r := range src.InlinedRequests {
+			// File-based batches don't support Metadata: the results are
+			// keyed by the index of the request instead.
+			if r != nil && len(r.Metadata) > 0 {
+				rc := *r
+				rc.Metadata = nil
+				r = &rc
+			}
+			requests[i] = &BatchRequest{Key: strconv.Itoa(i), Request: r}
+		}
+		job, err := b.CreateFromRequests(ctx, model, requests, &CreateBatchJobFromRequestsConfig{
+			Upload:   config.Upload,
+			BatchJob: config.BatchJob,
+		})
+		if err != nil {
+			return nil, err
+		}
+		group.add(job, 0)
+	case BatchOverflowSplit:
+		chunks, err := splitBySize(sizes, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range chunks {
+			job, err := b.Create(ctx, model, &BatchJobSource{InlinedRequests: src.InlinedRequests[c[0]:c[1]]}, config.BatchJob)
+			if err != nil {
+				err = fmt.Errorf("creating batch job for requests [%d, %d): %w", c[0], c[1], err)
+				return nil, errors.Join(err, group.cancel(ctx, config.BatchJob))
+			}
+			group.add(job, c[0])
+		}
+	default:
+		return nil, fmt.Errorf("unknown batch overflow strategy %q", config.Overflow)
+	}
+	return group, nil
+}
+
+func (g *BatchJobGroup) add(job *BatchJob, offset int) {
+	g.Jobs = append(g.Jobs, job)
+	g.offsets = append(g.offsets, offset)
+}
+
+// cancel cancels the jobs of a group that couldn't be created in full, even
+// if ctx is done, so that no job runs a part of the requests only.
+func (g *BatchJobGroup) cancel(ctx context.Context, config *CreateBatchJobConfig) error {
+	ctx = context.WithoutCancel(ctx)
+	var errs []error
+	for _, job := range g.Jobs {
+		var cancelConfig *CancelBatchJobConfig
+		if config != nil && config.HTTPOptions != nil {
+			cancelConfig = &CancelBatchJobConfig{HTTPOptions: cloneHTTPOptions(config.HTTPOptions)}
+		}
+		if err := g.batches.Cancel(ctx, job.Name, cancelConfig); err != nil {
+			errs = append(errs, fmt.Errorf("canceling batch job %s: %w", job.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// batchEnvelopeSize estimates the number of bytes of the body of a batch
+// creation request around its inlined requests.
+func batchEnvelopeSize(ac *apiClient, model string, config *CreateBatchJobConfig) (int64, error) {
+	fromObject := make(map[string]any)
+	if err := deepMarshal(map[string]any{"model": model, "src": &BatchJobSource{}, "config": config}, &fromObject); err != nil {
+		return 0, err
+	}
+	if c, ok := fromObject["config"].(map[string]any); ok {
+		delete(c, "httpOptions")
+	}
+	toObject, err := createBatchJobParametersToMldev(ac, fromObject, nil)
+	if err != nil {
+		return 0, err
+	}
+	delete(toObject, "_url")
+	delete(toObject, "_query")
+	setValueByPath(toObject, []string{"batch", "inputConfig", "requests", "requests"}, []any{})
+	data, err := json.Marshal(toObject)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+// inlinedRequestSize estimates the number of bytes the request occupies in the
+// body of a batch creation request.
+func inlinedRequestSize(ac *apiClient, r *InlinedRequest) (int64, error) {
+	fromObject := make(map[string]any)
+	if err := deepMarshal(r, &fromObject); err != nil {
+		return 0, err
+	}
+	toObject, err := inlinedRequestToMldev(ac, fromObject, nil)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(toObject)
+	if err != nil {
+		return 0, err
+	}
+	// Account for the separating comma.
+	return int64(len(data)) + 1, nil
+}
+
+// splitBySize packs consecutive items into half-open index ranges whose summed
+// sizes do not exceed limit.
+func splitBySize(sizes []int64, limit int64) ([][2]int, error) {
+	var chunks [][2]int
+	start := 0
+	var current int64
+	for i, size := range sizes {
+		if size > limit {
+			return nil, fmt.Errorf("inlined request %d is %d bytes, which exceeds the limit of %d bytes", i, size, limit)
+		}
+		if current+size > limit {
+			chunks = append(chunks, [2]int{start, i})
+			start, current = i, 0
+		}
+		current += size
+	}
+	return append(chunks, [2]int{start, len(sizes)}), nil
+}
+
+// Refresh fetches the latest state of every job in the group.
+func (g *BatchJobGroup) Refresh(ctx context.Context) error {
+	for i, job := range g.Jobs {
+		latest, err := g.batches.Get(ctx, job.Name, nil)
+		if err != nil {
+			return err
+		}
+		g.Jobs[i] = latest
+	}
+	return nil
+}
+
+// State returns the aggregated state of the group. While any job is still
+// active the group is active; once every job is done the group has succeeded
+// if all jobs succeeded, partially succeeded if some did, and otherwise takes
+// the state of its first unsuccessful job.
+func (g *BatchJobGroup) State() JobState {
+	if len(g.Jobs) == 0 {
+		return JobStateUnspecified
+	}
+	active, succeeded := 0, 0
+	var unsuccessful JobState
+	for _, job := range g.Jobs {
+		switch {
+		case !jobStateDone(job.State):
+			active++
+		case job.State == JobStateSucceeded:
+			succeeded++
+		case unsuccessful == "":
+			unsuccessful = job.State
+		}
+	}
+	switch {
+	case active == len(g.Jobs):
+		for _, job := range g.Jobs {
+			if job.State == JobStateRunning {
+				return JobStateRunning
+			}
+		}
+		return g.Jobs[0].State
+	case active > 0:
+		return JobStateRunning
+	case succeeded == len(g.Jobs):
+		return JobStateSucceeded
+	case succeeded > 0:
+		return JobStatePartiallySucceeded
+	default:
+		return unsuccessful
+	}
+}
+
+// Done reports whether every job in the group has reached a terminal state.
+func (g *BatchJobGroup) Done() bool {
+	for _, job := range g.Jobs {
+		if !jobStateDone(job.State) {
+			return false
+		}
+	}
+	return true
+}
+
+// CompletionStats returns the sum of the completion statistics of all jobs.
+// For jobs that do not report statistics, successes and failures are counted
+// from their inlined responses. IncompleteCount is -1 if it is unknown for any
+// job. It returns nil if no job has any statistics.
+func (g *BatchJobGroup) CompletionStats() *CompletionStats {
+	var total *CompletionStats
+	for _, job := range g.Jobs {
+		stats := job.CompletionStats
+		if stats == nil {
+			stats = inlinedResponsesStats(job)
+		}
+		if stats == nil {
+			continue
+		}
+		if total == nil {
+			total = &CompletionStats{}
+		}
+		total.FailedCount += stats.FailedCount
+		// An unknown count, -1, makes the total unknown too.
+		if stats.IncompleteCount < 0 || total.IncompleteCount < 0 {
+			total.IncompleteCount = -1
+		} else {
+			total.IncompleteCount += stats.IncompleteCount
+		}
+		total.SuccessfulCount += stats.SuccessfulCount
+		total.SuccessfulForecastPointCount += stats.SuccessfulForecastPointCount
+	}
+	return total
+}
+
+func inlinedResponsesStats(job *BatchJob) *CompletionStats {
+	if job.Dest == nil || len(job.Dest.InlinedResponses) == 0 {
+		return nil
+	}
+	stats := &CompletionStats{}
+	for _, r := range job.Dest.InlinedResponses {
+		if r.Error != nil {
+			stats.FailedCount++
+		} else {
+			stats.SuccessfulCount++
+		}
+	}
+	return stats
+}
+
+// Results returns an iterator over the results of every job in the group, in
+// job order. Keys are the indices of the requests in the original
+// [BatchJobSource.InlinedRequests]. Call it once [BatchJobGroup.Done] reports
+// true.
+func (g *BatchJobGroup) Results(ctx context.Context, config *BatchResultsConfig) iter.Seq2[*BatchResult, error] {
+	return func(yield func(*BatchResult, error) bool) {
+		for i, job := range g.Jobs {
+			for r, err := range g.batches.Results(ctx, job, config) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if idx, err := strconv.Atoi(r.Key); err == nil {
+					r.Key = strconv.Itoa(g.offsets[i] + idx)
+				}
+				if !yield(r, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// jobStateDone reports whether a batch job in the given state will not change
+// state anymore.
+func jobStateDone(s JobState) bool {
+	switch s {
+	case JobStateSucceeded, JobStateFailed, JobStateCancelled, JobStateExpired, JobStatePartiallySucceeded:
+		return true
+	default:
+		return false
+	}
+}
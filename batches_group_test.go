@@ -0,0 +1,243 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitBySize(t *testing.T) {
+	tests := []struct {
+		name    string
+		sizes   []int64
+		limit   int64
+		want    [][2]int
+		wantErr bool
+	}{
+		{name: "SingleChunk", sizes: []int64{1, 2, 3}, limit: 10, want: [][2]int{{0, 3}}},
+		{name: "ExactFit", sizes: []int64{5, 5, 5}, limit: 10, want: [][2]int{{0, 2}, {2, 3}}},
+		{name: "OnePerChunk", sizes: []int64{6, 6, 6}, limit: 10, want: [][2]int{{0, 1}, {1, 2}, {2, 3}}},
+		{name: "TooLarge", sizes: []int64{1, 11}, limit: 10, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitBySize(tt.sizes, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitBySize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("splitBySize() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBatchJobGroupState(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []JobState
+		want   JobState
+	}{
+		{name: "Empty", want: JobStateUnspecified},
+		{name: "AllPending", states: []JobState{JobStatePending, JobStatePending}, want: JobStatePending},
+		{name: "OneRunning", states: []JobState{JobStatePending, JobStateRunning}, want: JobStateRunning},
+		{name: "SomeDone", states: []JobState{JobStateSucceeded, JobStatePending}, want: JobStateRunning},
+		{name: "AllSucceeded", states: []JobState{JobStateSucceeded, JobStateSucceeded}, want: JobStateSucceeded},
+		{name: "Partial", states: []JobState{JobStateSucceeded, JobStateFailed}, want: JobStatePartiallySucceeded},
+		{name: "AllFailed", states: []JobState{JobStateCancelled, JobStateFailed}, want: JobStateCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &BatchJobGroup{}
+			for _, s := range tt.states {
+				g.add(&BatchJob{State: s}, 0)
+			}
+			if got := g.State(); got != tt.want {
+				t.Errorf("State() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchesCreateGroup(t *testing.T) {
+	ctx := context.Background()
+	requests := []*InlinedRequest{
+		{Contents: Text(strings.Repeat("a", 100)), Metadata: map[string]string{"id": "a"}},
+		{Contents: Text(strings.Repeat("b", 100))},
+		{Contents: Text(strings.Repeat("c", 100))},
+	}
+
+	tests := []struct {
+		name        string
+		config      *CreateBatchJobGroupConfig
+		wantCreates []int // Number of inlined requests in each created job.
+		wantFile    bool
+	}{
+		{
+			name:        "FitsInline",
+			config:      nil,
+			wantCreates: []int{3},
+		},
+		{
+			name:        "Split",
+			config:      &CreateBatchJobGroupConfig{MaxInlinedRequestBytes: 420, Overflow: BatchOverflowSplit},
+			wantCreates: []int{2, 1},
+		},
+		{
+			name:        "UploadFile",
+			config:      &CreateBatchJobGroupConfig{MaxInlinedRequestBytes: 420},
+			wantCreates: []int{0},
+			wantFile:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := NewMockUploadServer(t)
+			var creates []int
+			usedFile := false
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1beta/models/gemini-2.5-flash:batchGenerateContent" {
+					mockServer.ServeHTTP(w, r)
+					return
+				}
+				var body struct {
+					Batch struct {
+						InputConfig struct {
+							FileName string `json:"fileName"`
+							Requests struct {
+								Requests []any `json:"requests"`
+							} `json:"requests"`
+						} `json:"inputConfig"`
+					} `json:"batch"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("Failed to decode create request: %v", err)
+				}
+				usedFile = usedFile || body.Batch.InputConfig.FileName != ""
+				creates = append(creates, len(body.Batch.InputConfig.Requests.Requests))
+				fmt.Fprintf(w, `{"name":"batches/%d","metadata":{"state":"BATCH_STATE_PENDING"}}`, len(creates))
+			}))
+			defer ts.Close()
+			mockServer.baseURL = ts.URL
+			client := newTestBatchesClient(t, ts)
+
+			group, err := client.Batches.CreateGroup(ctx, "gemini-2.5-flash", &BatchJobSource{InlinedRequests: requests}, tt.config)
+			if err != nil {
+				t.Fatalf("CreateGroup() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCreates, creates); diff != "" {
+				t.Errorf("CreateGroup() created jobs mismatch (-want +got):\n%s", diff)
+			}
+			if usedFile != tt.wantFile {
+				t.Errorf("CreateGroup() used file source = %v, want %v", usedFile, tt.wantFile)
+			}
+			if len(group.Jobs) != len(tt.wantCreates) {
+				t.Errorf("CreateGroup() returned %d jobs, want %d", len(group.Jobs), len(tt.wantCreates))
+			}
+			if got := group.State(); got != JobStatePending {
+				t.Errorf("State() = %s, want %s", got, JobStatePending)
+			}
+		})
+	}
+}
+
+func TestBatchesCreateGroupSplitFailure(t *testing.T) {
+	ctx := context.Background()
+	var creates int
+	var canceled []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1beta/models/gemini-2.5-flash:batchGenerateContent":
+			creates++
+			if creates > 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"error":{"code":500,"message":"failed"}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"name":"batches/%d","metadata":{"state":"BATCH_STATE_PENDING"}}`, creates)
+		case strings.HasSuffix(r.URL.Path, ":cancel"):
+			canceled = append(canceled, strings.TrimPrefix(r.URL.Path, "/v1beta/"))
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	requests := []*InlinedRequest{
+		{Contents: Text(strings.Repeat("a", 100))},
+		{Contents: Text(strings.Repeat("b", 100))},
+	}
+	group, err := client.Batches.CreateGroup(ctx, "gemini-2.5-flash", &BatchJobSource{InlinedRequests: requests},
+		&CreateBatchJobGroupConfig{MaxInlinedRequestBytes: 300, Overflow: BatchOverflowSplit})
+	if err == nil || group != nil {
+		t.Fatalf("CreateGroup() = %v, %v, want an error and no group", group, err)
+	}
+	if diff := cmp.Diff([]string{"batches/1:cancel"}, canceled); diff != "" {
+		t.Errorf("CreateGroup() canceled jobs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchJobGroupCompletionStatsUnknown(t *testing.T) {
+	g := &BatchJobGroup{}
+	g.add(&BatchJob{CompletionStats: &CompletionStats{SuccessfulCount: 1, IncompleteCount: 2}}, 0)
+	g.add(&BatchJob{CompletionStats: &CompletionStats{SuccessfulCount: 3, IncompleteCount: -1}}, 1)
+	g.add(&BatchJob{CompletionStats: &CompletionStats{IncompleteCount: 4}}, 4)
+	if diff := cmp.Diff(&CompletionStats{SuccessfulCount: 4, IncompleteCount: -1}, g.CompletionStats()); diff != "" {
+		t.Errorf("CompletionStats() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchJobGroupResults(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+	g := &BatchJobGroup{batches: *client.Batches}
+	g.add(&BatchJob{State: JobStateSucceeded, Dest: &BatchJobDestination{InlinedResponses: []*InlinedResponse{
+		{Response: &GenerateContentResponse{ModelVersion: "0"}},
+		{Response: &GenerateContentResponse{ModelVersion: "1"}},
+	}}}, 0)
+	g.add(&BatchJob{State: JobStateSucceeded, Dest: &BatchJobDestination{InlinedResponses: []*InlinedResponse{
+		{Error: &JobError{Message: "2"}},
+	}}}, 2)
+
+	var keys []string
+	for r, err := range g.Results(context.Background(), nil) {
+		if err != nil {
+			t.Fatalf("Results() unexpected error: %v", err)
+		}
+		keys = append(keys, r.Key)
+	}
+	if diff := cmp.Diff([]string{"0", "1", "2"}, keys); diff != "" {
+		t.Errorf("Results() keys mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&CompletionStats{SuccessfulCount: 2, FailedCount: 1}, g.CompletionStats()); diff != "" {
+		t.Errorf("CompletionStats() mismatch (-want +got):\n%s", diff)
+	}
+	if !g.Done() {
+		t.Errorf("Done() = false, want true")
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// BatchRequest is a single request of a file-based batch job. The Key is chosen
+// by the caller and is echoed back in the matching [BatchResult].
+type BatchRequest struct {
+	// Required. Caller-defined identifier of the request. Keys must be unique
+	// within a batch.
+	Key string `json:"key,omitempty"`
+	// Required. The request to run. Metadata is not supported in file-based
+	// batches; use Key to correlate results instead.
+	Request *InlinedRequest `json:"request,omitempty"`
+}
+
+// BatchResult is the outcome of a single request of a batch job.
+type BatchResult struct {
+	// The key of the request. For batch jobs created from inlined requests,
+	// this is the index of the request in [BatchJobSource.InlinedRequests].
+	Key string `json:"key,omitempty"`
+	// The response to the request. Nil if the request failed.
+	Response *GenerateContentResponse `json:"response,omitempty"`
+	// The error encountered while processing the request, if any.
+	Error *JobError `json:"error,omitempty"`
+}
+
+// CreateBatchJobFromRequestsConfig holds optional parameters for
+// [Batches.CreateFromRequests].
+type CreateBatchJobFromRequestsConfig struct {
+	// Optional. Configuration for uploading the generated JSONL input file.
+	// MIMEType defaults to "jsonl".
+	Upload *UploadFileConfig `json:"upload,omitempty"`
+	// Optional. Configuration for the batch job itself.
+	BatchJob *CreateBatchJobConfig `json:"batchJob,omitempty"`
+}
+
+// BatchResultsConfig holds optional parameters for [Batches.Results].
+type BatchResultsConfig struct {
+	// Optional. Used to override HTTP request options when downloading the
+	// output file.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+}
+
+// WriteRequestsJSONL serializes the requests into the JSONL format expected by
+// file-based batch jobs in the Gemini Developer API, one request per line.
+func (b Batches) WriteRequestsJSONL(w io.Writer, requests []*BatchRequest) error {
+	if b.apiClient.clientConfig.Backend == BackendVertexAI {
+		return fmt.Errorf("method WriteRequestsJSONL is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
+	}
+	seen := make(map[string]bool, len(requests))
+	bw := bufio.NewWriter(w)
+	for i, r := range requests {
+		if r == nil || r.Request == nil {
+			return fmt.Errorf("batch request %d is nil", i)
+		}
+		if r.Key == "" {
+			return fmt.Errorf("batch request %d has an empty key", i)
+		}
+		if seen[r.Key] {
+			return fmt.Errorf("duplicate batch request key %q", r.Key)
+		}
+		seen[r.Key] = true
+		if len(r.Request.Metadata) > 0 {
+			return fmt.Errorf("batch request %q: Metadata is not supported in file-based batches, use Key instead", r.Key)
+		}
+
+		line, err := batchRequestToMldev(b.apiClient, r)
+		if err != nil {
+			return fmt.Errorf("batch request %q: %w", r.Key, err)
+		}
+		data, err := json.Marshal(line)
+		if err != nil {
+			return fmt.Errorf("batch request %q: %w", r.Key, err)
+		}
+		if _, err := bw.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// batchRequestToMldev converts a request into a single line of a batch input
+// file: {"key": ..., "request": <GenerateContentRequest>}.
+func batchRequestToMldev(ac *apiClient, r *BatchRequest) (map[string]any, error) {
+	fromObject := make(map[string]any)
+	if err := deepMarshal(r.Request, &fromObject); err != nil {
+		return nil, err
+	}
+	toObject, err := inlinedRequestToMldev(ac, fromObject, nil)
+	if err != nil {
+		return nil, err
+	}
+	request := getValueByPathOrDefault(toObject, []string{"request"}, map[string]any{})
+	return map[string]any{"key": r.Key, "request": request}, nil
+}
+
+// CreateFromRequests serializes the requests to JSONL, uploads the result with
+// [Files.Upload] and creates a batch job that reads its input from the
+// uploaded file. Use [Batches.Results] to read the responses once the job
+// has succeeded.
+//
+// This method is only supported in the Gemini Developer client.
+func (b Batches) CreateFromRequests(ctx context.Context, model string, requests []*BatchRequest, config *CreateBatchJobFromRequestsConfig) (*BatchJob, error) {
+	if b.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, fmt.Errorf("method CreateFromRequests is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("at least one batch request is required")
+	}
+	if config == nil {
+		config = &CreateBatchJobFromRequestsConfig{}
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteRequestsJSONL(&buf, requests); err != nil {
+		return nil, err
+	}
+
+	var uploadConfig UploadFileConfig
+	if config.Upload != nil {
+		deepCopy(*config.Upload, &uploadConfig)
+	}
+	if uploadConfig.MIMEType == "" {
+		uploadConfig.MIMEType = "jsonl"
+	}
+	files := Files{apiClient: b.apiClient}
+	file, err := files.Upload(ctx, &buf, &uploadConfig)
+	if err != nil {
+		return nil, fmt.Errorf("uploading batch input file: %w", err)
+	}
+	return b.Create(ctx, model, &BatchJobSource{FileName: file.Name}, config.BatchJob)
+}
+
+// Results returns an iterator over the results of a completed batch job.
+//
+// For jobs whose output was written to a file, the file is downloaded with
+// [Files.Download] and every line is yielded with the key of the request it
+// belongs to. For jobs created from inlined requests, the inlined responses are
+// yielded with their index as key. A non-nil error ends the iteration.
+func (b Batches) Results(ctx context.Context, job *BatchJob, config *BatchResultsConfig) iter.Seq2[*BatchResult, error] {
+	if job == nil || job.Dest == nil {
+		name, state := "", JobState("")
+		if job != nil {
+			name, state = job.Name, job.State
+		}
+		return yieldErrorAndEndIterator[BatchResult](fmt.Errorf("batch job %q has no output (state %s)", name, state))
+	}
+	if len(job.Dest.InlinedResponses) > 0 {
+		return func(yield func(*BatchResult, error) bool) {
+			for i, r := range job.Dest.InlinedResponses {
+				if !yield(&BatchResult{Key: strconv.Itoa(i), Response: r.Response, Error: r.Error}, nil) {
+					return
+				}
+			}
+		}
+	}
+	if job.Dest.FileName == "" {
+		return yieldErrorAndEndIterator[BatchResult](fmt.Errorf("batch job %q has no output file", job.Name))
+	}
+
+	return func(yield func(*BatchResult, error) bool) {
+		var downloadConfig *DownloadFileConfig
+		if config != nil && config.HTTPOptions != nil {
+			downloadConfig = &DownloadFileConfig{HTTPOptions: config.HTTPOptions}
+		}
+		files := Files{apiClient: b.apiClient}
+		data, err := files.Download(ctx, &File{DownloadURI: job.Dest.FileName}, downloadConfig)
+		if err != nil {
+			yield(nil, fmt.Errorf("downloading batch output file %s: %w", job.Dest.FileName, err))
+			return
+		}
+		for result, err := range parseBatchResultsJSONL(data) {
+			if !yield(result, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseBatchResultsJSONL parses the lines of a batch output file.
+func parseBatchResultsJSONL(data []byte) iter.Seq2[*BatchResult, error] {
+	return func(yield func(*BatchResult, error) bool) {
+		for n, line := range bytes.Split(data, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			result, err := batchResultFromMldev(line)
+			if err != nil {
+				yield(nil, fmt.Errorf("parsing batch output line %d: %w", n+1, err))
+				return
+			}
+			if !yield(result, nil) {
+				return
+			}
+		}
+	}
+}
+
+func batchResultFromMldev(line []byte) (*BatchResult, error) {
+	var fromObject map[string]any
+	if err := json.Unmarshal(line, &fromObject); err != nil {
+		return nil, err
+	}
+	// The output file carries google.rpc.Status errors whose details are
+	// objects; JobError exposes them as strings.
+	if details, ok := getValueByPath(fromObject, []string{"error", "details"}).([]any); ok {
+		for i, d := range details {
+			if _, ok := d.(string); !ok {
+				b, err := json.Marshal(d)
+				if err != nil {
+					return nil, err
+				}
+				details[i] = string(b)
+			}
+		}
+	}
+	toObject, err := inlinedResponseFromMldev(fromObject, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := new(BatchResult)
+	if err := mapToStruct(toObject, result); err != nil {
+		return nil, err
+	}
+	if key, ok := fromObject["key"].(string); ok {
+		result.Key = key
+	}
+	return result, nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestBatchesClient(t *testing.T, ts *httptest.Server) *Client {
+	t.Helper()
+	client, err := NewClient(context.Background(), &ClientConfig{
+		Backend:     BackendGeminiAPI,
+		APIKey:      "test-api-key",
+		HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+		HTTPClient:  ts.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestBatchesWriteRequestsJSONL(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	tests := []struct {
+		name       string
+		requests   []*BatchRequest
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name: "Success",
+			requests: []*BatchRequest{
+				{Key: "a", Request: &InlinedRequest{Contents: Text("hello")}},
+				{Key: "b", Request: &InlinedRequest{
+					Contents: Text("bye"),
+					Config: &GenerateContentConfig{
+						Temperature:       Ptr[float32](0.5),
+						SystemInstruction: &Content{Parts: []*Part{{Text: "be brief"}}},
+					},
+				}},
+			},
+			want: `{"key":"a","request":{"contents":[{"parts":[{"text":"hello"}],"role":"user"}]}}
+{"key":"b","request":{"contents":[{"parts":[{"text":"bye"}],"role":"user"}],"generationConfig":{"temperature":0.5},"systemInstruction":{"parts":[{"text":"be brief"}]}}}
+`,
+		},
+		{
+			name: "DuplicateKey",
+			requests: []*BatchRequest{
+				{Key: "a", Request: &InlinedRequest{Contents: Text("1")}},
+				{Key: "a", Request: &InlinedRequest{Contents: Text("2")}},
+			},
+			wantErrMsg: `duplicate batch request key "a"`,
+		},
+		{
+			name:       "EmptyKey",
+			requests:   []*BatchRequest{{Request: &InlinedRequest{Contents: Text("1")}}},
+			wantErrMsg: "batch request 0 has an empty key",
+		},
+		{
+			name: "Metadata",
+			requests: []*BatchRequest{
+				{Key: "a", Request: &InlinedRequest{Contents: Text("1"), Metadata: map[string]string{"k": "v"}}},
+			},
+			wantErrMsg: `batch request "a": Metadata is not supported in file-based batches, use Key instead`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := client.Batches.WriteRequestsJSONL(&buf, tt.requests)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Fatalf("WriteRequestsJSONL() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WriteRequestsJSONL() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Errorf("WriteRequestsJSONL() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBatchesCreateFromRequestsAndResults(t *testing.T) {
+	ctx := context.Background()
+	mockServer := NewMockUploadServer(t)
+	var uploaded []byte
+	mockServer.uploadHandler = func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		uploaded = append(uploaded, body...)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		mockServer.handleUpload(w, r)
+	}
+	output := `{"key":"b","response":{"candidates":[{"content":{"parts":[{"text":"two"}],"role":"model"}}]}}
+{"key":"a","error":{"code":3,"message":"bad request","details":[{"@type":"type.googleapis.com/google.rpc.BadRequest"}]}}
+`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1beta/models/gemini-2.5-flash:batchGenerateContent":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Failed to decode create request: %v", err)
+			}
+			want := map[string]any{"batch": map[string]any{
+				"displayName": "nightly",
+				"inputConfig": map[string]any{"fileName": "files/generated-0"},
+			}}
+			if diff := cmp.Diff(want, body); diff != "" {
+				t.Errorf("create request mismatch (-want +got):\n%s", diff)
+			}
+			_, _ = w.Write([]byte(`{"name":"batches/123","metadata":{"state":"BATCH_STATE_PENDING"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta/files/out:download":
+			_, _ = w.Write([]byte(output))
+		default:
+			mockServer.ServeHTTP(w, r)
+		}
+	}))
+	defer ts.Close()
+	mockServer.baseURL = ts.URL
+	client := newTestBatchesClient(t, ts)
+
+	job, err := client.Batches.CreateFromRequests(ctx, "gemini-2.5-flash", []*BatchRequest{
+		{Key: "a", Request: &InlinedRequest{Contents: Text("one")}},
+		{Key: "b", Request: &InlinedRequest{Contents: Text("two")}},
+	}, &CreateBatchJobFromRequestsConfig{BatchJob: &CreateBatchJobConfig{DisplayName: "nightly"}})
+	if err != nil {
+		t.Fatalf("CreateFromRequests() unexpected error: %v", err)
+	}
+	if job.Name != "batches/123" {
+		t.Errorf("CreateFromRequests() job name = %q, want %q", job.Name, "batches/123")
+	}
+	if got := strings.Count(string(uploaded), "\n"); got != 2 {
+		t.Errorf("uploaded %d JSONL lines, want 2", got)
+	}
+
+	job.State = JobStateSucceeded
+	job.Dest = &BatchJobDestination{FileName: "files/out"}
+	var got []*BatchResult
+	for r, err := range client.Batches.Results(ctx, job, nil) {
+		if err != nil {
+			t.Fatalf("Results() unexpected error: %v", err)
+		}
+		got = append(got, r)
+	}
+	want := []*BatchResult{
+		{Key: "b", Response: &GenerateContentResponse{Candidates: []*Candidate{{Content: &Content{Parts: []*Part{{Text: "two"}}, Role: RoleModel}}}}},
+		{Key: "a", Error: &JobError{Code: Ptr[int32](3), Message: "bad request", Details: []string{`{"@type":"type.googleapis.com/google.rpc.BadRequest"}`}}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Results() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchesResultsInlined(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+	job := &BatchJob{Name: "batches/1", Dest: &BatchJobDestination{InlinedResponses: []*InlinedResponse{
+		{Response: &GenerateContentResponse{ModelVersion: "v1"}},
+		{Error: &JobError{Message: "oops"}},
+	}}}
+	var got []*BatchResult
+	for r, err := range client.Batches.Results(context.Background(), job, nil) {
+		if err != nil {
+			t.Fatalf("Results() unexpected error: %v", err)
+		}
+		got = append(got, r)
+	}
+	want := []*BatchResult{
+		{Key: "0", Response: &GenerateContentResponse{ModelVersion: "v1"}},
+		{Key: "1", Error: &JobError{Message: "oops"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Results() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, err := range client.Batches.Results(context.Background(), &BatchJob{Name: "batches/2", State: JobStateRunning}, nil) {
+		if err == nil {
+			t.Errorf("Results() on a running job returned no error")
+		}
+	}
+}
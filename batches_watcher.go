@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+const defaultBatchWatchInterval = 30 * time.Second
+
+// WatchBatchJobsConfig holds optional parameters for [Batches.Watch].
+type WatchBatchJobsConfig struct {
+	// Optional. Used to override HTTP request options of each Batches.Get call.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. Delay between two polls of the watched jobs. Defaults to 30
+	// seconds.
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+}
+
+// BatchJobEvent reports a change of a watched batch job.
+type BatchJobEvent struct {
+	// Job is the latest snapshot of the batch job.
+	Job *BatchJob
+	// PreviousState is the state of the job at the previous poll. It is empty
+	// for the first event of a job.
+	PreviousState JobState
+	// State is the current state of the job.
+	State JobState
+	// StatsDelta is the change in Job.CompletionStats since the previous event
+	// of the job. It is nil if the job reports no completion statistics.
+	StatsDelta *CompletionStats
+}
+
+// StateChanged reports whether the event is a state transition, as opposed to
+// a completion statistics update within the same state.
+func (e *BatchJobEvent) StateChanged() bool {
+	return e.PreviousState != e.State
+}
+
+// Watch polls the named batch jobs with a single loop and returns an iterator
+// over their changes.
+//
+// An event is yielded the first time each job is observed, on every
+// transition between [JobState] values, and whenever its completion statistics
+// change. A job is no longer polled once it reaches a terminal state, and the
+// iteration ends when all jobs have. Errors from [Batches.Get] are yielded and
+// polling continues for as long as the caller keeps iterating; cancelling ctx
+// ends the iteration with the context error. A name listed more than once is
+// watched once.
+func (b Batches) Watch(ctx context.Context, names []string, config *WatchBatchJobsConfig) iter.Seq2[*BatchJobEvent, error] {
+	if config == nil {
+		config = &WatchBatchJobsConfig{}
+	}
+	interval := config.PollInterval
+	if interval <= 0 {
+		interval = defaultBatchWatchInterval
+	}
+
+	return func(yield func(*BatchJobEvent, error) bool) {
+		last := make(map[string]*BatchJob, len(names))
+		var pending []string
+		seen := make(map[string]bool, len(names))
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				pending = append(pending, name)
+			}
+		}
+		for len(pending) > 0 {
+			var next []string
+			for _, name := range pending {
+				job, err := b.Get(ctx, name, &GetBatchJobConfig{HTTPOptions: config.HTTPOptions})
+				if err != nil {
+					if ctx.Err() != nil {
+						yield(nil, ctx.Err())
+						return
+					}
+					if !yield(nil, fmt.Errorf("watching batch job %s: %w", name, err)) {
+						return
+					}
+					next = append(next, name)
+					continue
+				}
+				if event := newBatchJobEvent(last[name], job); event != nil {
+					if !yield(event, nil) {
+						return
+					}
+				}
+				last[name] = job
+				if !jobStateDone(job.State) {
+					next = append(next, name)
+				}
+			}
+			pending = next
+			if len(pending) == 0 {
+				return
+			}
+			if err := sleepContext(ctx, interval); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
+
+// newBatchJobEvent returns the event describing the change from prev to cur,
+// or nil if nothing observable changed.
+func newBatchJobEvent(prev, cur *BatchJob) *BatchJobEvent {
+	event := &BatchJobEvent{Job: cur, State: cur.State}
+	var prevStats *CompletionStats
+	if prev != nil {
+		event.PreviousState = prev.State
+		prevStats = prev.CompletionStats
+	}
+	if cur.CompletionStats != nil {
+		event.StatsDelta = completionStatsDelta(prevStats, cur.CompletionStats)
+	}
+	if prev != nil && !event.StateChanged() && (event.StatsDelta == nil || *event.StatsDelta == CompletionStats{}) {
+		return nil
+	}
+	return event
+}
+
+func completionStatsDelta(prev, cur *CompletionStats) *CompletionStats {
+	if prev == nil {
+		prev = &CompletionStats{}
+	}
+	return &CompletionStats{
+		FailedCount:                  cur.FailedCount - prev.FailedCount,
+		IncompleteCount:              cur.IncompleteCount - prev.IncompleteCount,
+		SuccessfulCount:              cur.SuccessfulCount - prev.SuccessfulCount,
+		SuccessfulForecastPointCount: cur.SuccessfulForecastPointCount - prev.SuccessfulForecastPointCount,
+	}
+}
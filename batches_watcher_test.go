@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBatchesWatch(t *testing.T) {
+	states := map[string][]string{
+		"a": {"BATCH_STATE_PENDING", "BATCH_STATE_RUNNING", "BATCH_STATE_RUNNING", "BATCH_STATE_SUCCEEDED"},
+		"b": {"BATCH_STATE_RUNNING", "BATCH_STATE_FAILED"},
+	}
+	var mu sync.Mutex
+	polls := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/v1beta/batches/")
+		mu.Lock()
+		seq := states[id]
+		if polls[id] >= len(seq) {
+			t.Errorf("job %s polled after reaching a terminal state", id)
+			mu.Unlock()
+			http.NotFound(w, r)
+			return
+		}
+		state := seq[polls[id]]
+		polls[id]++
+		mu.Unlock()
+		fmt.Fprintf(w, `{"name":"batches/%s","metadata":{"state":%q}}`, id, state)
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	type transition struct {
+		Name     string
+		From, To JobState
+	}
+	var got []transition
+	// The repeated name must be watched once.
+	names := []string{"batches/a", "batches/b", "batches/a"}
+	for event, err := range client.Batches.Watch(context.Background(), names, &WatchBatchJobsConfig{PollInterval: time.Millisecond}) {
+		if err != nil {
+			t.Fatalf("Watch() unexpected error: %v", err)
+		}
+		got = append(got, transition{event.Job.Name, event.PreviousState, event.State})
+	}
+	want := []transition{
+		{"batches/a", "", JobStatePending},
+		{"batches/b", "", JobStateRunning},
+		{"batches/a", JobStatePending, JobStateRunning},
+		{"batches/b", JobStateRunning, JobStateFailed},
+		{"batches/a", JobStateRunning, JobStateSucceeded},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Watch() events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBatchesWatchContextCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"batches/a","metadata":{"state":"BATCH_STATE_RUNNING"}}`)
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var gotErr error
+	for event, err := range client.Batches.Watch(ctx, []string{"batches/a"}, &WatchBatchJobsConfig{PollInterval: time.Hour}) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		if event.State != JobStateRunning {
+			t.Errorf("Watch() state = %s, want %s", event.State, JobStateRunning)
+		}
+		cancel()
+	}
+	if gotErr != context.Canceled {
+		t.Errorf("Watch() error = %v, want %v", gotErr, context.Canceled)
+	}
+}
+
+func TestNewBatchJobEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur *BatchJob
+		want      *BatchJobEvent
+	}{
+		{
+			name: "FirstObservation",
+			cur:  &BatchJob{State: JobStateQueued},
+			want: &BatchJobEvent{State: JobStateQueued},
+		},
+		{
+			name: "Unchanged",
+			prev: &BatchJob{State: JobStateRunning, CompletionStats: &CompletionStats{SuccessfulCount: 1}},
+			cur:  &BatchJob{State: JobStateRunning, CompletionStats: &CompletionStats{SuccessfulCount: 1}},
+		},
+		{
+			name: "StatsOnly",
+			prev: &BatchJob{State: JobStateRunning, CompletionStats: &CompletionStats{SuccessfulCount: 1}},
+			cur:  &BatchJob{State: JobStateRunning, CompletionStats: &CompletionStats{SuccessfulCount: 4, FailedCount: 1}},
+			want: &BatchJobEvent{PreviousState: JobStateRunning, State: JobStateRunning, StatsDelta: &CompletionStats{SuccessfulCount: 3, FailedCount: 1}},
+		},
+		{
+			name: "Transition",
+			prev: &BatchJob{State: JobStateRunning},
+			cur:  &BatchJob{State: JobStateCancelled},
+			want: &BatchJobEvent{PreviousState: JobStateRunning, State: JobStateCancelled},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newBatchJobEvent(tt.prev, tt.cur)
+			if tt.want != nil {
+				tt.want.Job = tt.cur
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("newBatchJobEvent() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
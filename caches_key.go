@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// minReusableCacheLifetime is how long a cache found by content key must still
+// live to be reused rather than replaced.
+const minReusableCacheLifetime = time.Minute
+
+// ContentKey returns a deterministic key identifying the cached content that
+// Create would make for model and config. Two configs have the same key if they
+// have the same model, contents, system instruction, tools, tool config and
+// KMS key, regardless of their TTL or display name.
+func (m Caches) ContentKey(model string, config *CreateCachedContentConfig) (string, error) {
+	fullModel, err := tCachesModel(m.apiClient, model)
+	if err != nil {
+		return "", err
+	}
+	if config == nil {
+		config = &CreateCachedContentConfig{}
+	}
+	data, err := json.Marshal(struct {
+		Model             string      `json:"model"`
+		Contents          []*Content  `json:"contents,omitempty"`
+		SystemInstruction *Content    `json:"systemInstruction,omitempty"`
+		Tools             []*Tool     `json:"tools,omitempty"`
+		ToolConfig        *ToolConfig `json:"toolConfig,omitempty"`
+		KmsKeyName        string      `json:"kmsKeyName,omitempty"`
+	}{fullModel, config.Contents, config.SystemInstruction, config.Tools, config.ToolConfig, config.KmsKeyName})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type pendingAcquire struct {
+	done chan struct{}
+	err  error
+	// canceled is set when the acquisition stopped because the context of
+	// its caller ended, so that the waiters try again with theirs.
+	canceled bool
+}
+
+// Acquire returns a cache holding the content that Create would make for model
+// and config, adding a reference to it that must be dropped with
+// [CacheManager.Release].
+//
+// Caches are identified by [Caches.ContentKey]. A cache already referenced by
+// the manager is shared; otherwise an unexpired cache with the same key made by
+// any manager of the same owner is looked up by display name and reused, and a
+// new cache is created only if none is found. Concurrent calls for the same key
+// wait for a single lookup. Processes racing to create the same key may each
+// create a cache; both are valid.
+func (cm *CacheManager) Acquire(ctx context.Context, model string, config *CreateCachedContentConfig) (*CachedContent, error) {
+	key, err := cm.caches.ContentKey(model, config)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		cm.mu.Lock()
+		if name, ok := cm.byKey[key]; ok {
+			e := cm.entries[name]
+			e.refs++
+			cm.mu.Unlock()
+			return e.cache, nil
+		}
+		if p, ok := cm.pending[key]; ok {
+			cm.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-p.done:
+			}
+			if p.err != nil && !p.canceled {
+				return nil, p.err
+			}
+			continue
+		}
+		p := &pendingAcquire{done: make(chan struct{})}
+		cm.pending[key] = p
+		cm.mu.Unlock()
+
+		cache, err := cm.findOrCreate(ctx, key, model, config)
+
+		cm.mu.Lock()
+		delete(cm.pending, key)
+		if err == nil {
+			cm.entries[cache.Name] = &managedCache{cache: cache, refs: 1, key: key, shared: true}
+			cm.byKey[key] = cache.Name
+		}
+		p.err = err
+		p.canceled = err != nil && ctx.Err() != nil
+		close(p.done)
+		cm.mu.Unlock()
+		return cache, err
+	}
+}
+
+func (cm *CacheManager) findOrCreate(ctx context.Context, key, model string, config *CreateCachedContentConfig) (*CachedContent, error) {
+	displayName := cm.displayName("key-" + key)
+	minExpireTime := time.Now().Add(minReusableCacheLifetime)
+	for cache, err := range cm.caches.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		if cache.DisplayName != displayName || cache.ExpireTime.Before(minExpireTime) {
+			continue
+		}
+		// Extending the TTL right away also checks the cache was not deleted
+		// since it was listed.
+		updated, err := cm.caches.Update(ctx, cache.Name, &UpdateCachedContentConfig{HTTPOptions: cloneHTTPOptions(cm.config.HTTPOptions), TTL: cm.config.TTL})
+		var apiErr APIError
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+	return cm.create(ctx, model, "key-"+key, config)
+}
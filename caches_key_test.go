@@ -0,0 +1,224 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCachesContentKey(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(ctx, &ClientConfig{Backend: BackendGeminiAPI, APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	config := func(text string, ttl time.Duration) *CreateCachedContentConfig {
+		return &CreateCachedContentConfig{
+			TTL:               ttl,
+			DisplayName:       "ignored " + ttl.String(),
+			Contents:          []*Content{NewContentFromText(text, RoleUser)},
+			SystemInstruction: NewContentFromText("be brief", RoleUser),
+		}
+	}
+	key := func(model string, c *CreateCachedContentConfig) string {
+		k, err := client.Caches.ContentKey(model, c)
+		if err != nil {
+			t.Fatalf("ContentKey() unexpected error: %v", err)
+		}
+		return k
+	}
+
+	base := key("gemini-2.0-flash", config("doc", time.Hour))
+	if got := key("models/gemini-2.0-flash", config("doc", time.Minute)); got != base {
+		t.Errorf("ContentKey() differs for the same content: %s != %s", got, base)
+	}
+	if got := key("gemini-2.0-flash", config("other doc", time.Hour)); got == base {
+		t.Errorf("ContentKey() is the same for different contents")
+	}
+	if got := key("gemini-2.5-flash", config("doc", time.Hour)); got == base {
+		t.Errorf("ContentKey() is the same for different models")
+	}
+}
+
+func TestCacheManagerAcquireShared(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCachesServer(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	cm, err := client.Caches.NewManager(ctx, &CacheManagerConfig{Owner: "svc"})
+	if err != nil {
+		t.Fatalf("NewManager() unexpected error: %v", err)
+	}
+	config := &CreateCachedContentConfig{Contents: []*Content{NewContentFromText("doc", RoleUser)}}
+
+	const workers = 8
+	names := make([]string, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache, err := cm.Acquire(ctx, "gemini-2.0-flash", config)
+			if err != nil {
+				t.Errorf("Acquire() unexpected error: %v", err)
+				return
+			}
+			names[i] = cache.Name
+		}()
+	}
+	wg.Wait()
+	for _, name := range names {
+		if name != names[0] {
+			t.Fatalf("Acquire() returned different caches: %v", names)
+		}
+	}
+	if got := fake.count("POST cachedContents"); got != 1 {
+		t.Errorf("caches created = %d, want 1", got)
+	}
+
+	for range workers {
+		if err := cm.Release(ctx, names[0]); err != nil {
+			t.Fatalf("Release() unexpected error: %v", err)
+		}
+	}
+	if err := cm.Release(ctx, names[0]); err == nil {
+		t.Errorf("Release() of an unreferenced cache: want error, got nil")
+	}
+	// Shared caches are left to expire, since other processes may use them.
+	if got := fake.names(); len(got) != 1 {
+		t.Errorf("caches after Release() = %v, want 1", got)
+	}
+	if err := cm.Close(ctx); err != nil {
+		t.Errorf("Close() unexpected error: %v", err)
+	}
+}
+
+func TestCacheManagerAcquireReusesPeerCache(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCachesServer(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	config := &CreateCachedContentConfig{Contents: []*Content{NewContentFromText("doc", RoleUser)}}
+	key, err := client.Caches.ContentKey("gemini-2.0-flash", config)
+	if err != nil {
+		t.Fatalf("ContentKey() unexpected error: %v", err)
+	}
+	fake.add("cachedContents/expiring", "svc/key-"+key, time.Now().Add(-time.Hour))
+	fake.add("cachedContents/peer", "svc/key-"+key, time.Now())
+
+	cm, err := client.Caches.NewManager(ctx, &CacheManagerConfig{Owner: "svc"})
+	if err != nil {
+		t.Fatalf("NewManager() unexpected error: %v", err)
+	}
+	defer cm.Close(ctx)
+	cache, err := cm.Acquire(ctx, "gemini-2.0-flash", config)
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error: %v", err)
+	}
+	if cache.Name != "cachedContents/peer" {
+		t.Errorf("Acquire() = %s, want cachedContents/peer", cache.Name)
+	}
+	if got := fake.count("POST cachedContents"); got != 0 {
+		t.Errorf("caches created = %d, want 0", got)
+	}
+	if got := fake.count("PATCH cachedContents/peer"); got != 1 {
+		t.Errorf("TTL extensions of the reused cache = %d, want 1", got)
+	}
+}
+
+func TestCacheManagerAcquireCreatorCanceled(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCachesServer(t)
+	listing := make(chan struct{})
+	var once sync.Once
+	// Block the lookup of the first caller until its context is canceled.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			blocked := false
+			once.Do(func() { blocked = true })
+			if blocked {
+				close(listing)
+				<-r.Context().Done()
+				return
+			}
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	cm, err := client.Caches.NewManager(ctx, &CacheManagerConfig{Owner: "svc"})
+	if err != nil {
+		t.Fatalf("NewManager() unexpected error: %v", err)
+	}
+	defer cm.Close(ctx)
+	config := &CreateCachedContentConfig{Contents: []*Content{NewContentFromText("doc", RoleUser)}}
+
+	creatorCtx, cancel := context.WithCancel(ctx)
+	creatorErr := make(chan error)
+	go func() {
+		_, err := cm.Acquire(creatorCtx, "gemini-2.0-flash", config)
+		creatorErr <- err
+	}()
+	<-listing
+	waiter := make(chan error)
+	go func() {
+		_, err := cm.Acquire(ctx, "gemini-2.0-flash", config)
+		waiter <- err
+	}()
+	// Let the waiter wait for the lookup of the creator.
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-creatorErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() with a canceled context error = %v, want context.Canceled", err)
+	}
+	if err := <-waiter; err != nil {
+		t.Errorf("Acquire() of the waiter unexpected error: %v", err)
+	}
+}
+
+func TestCacheManagerOwnerTooLong(t *testing.T) {
+	client, err := NewClient(context.Background(), &ClientConfig{Backend: BackendGeminiAPI, APIKey: "test-api-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Caches.NewManager(context.Background(), &CacheManagerConfig{Owner: strings.Repeat("o", 60)}); err == nil {
+		t.Error("NewManager() with a 60 character owner: want error, got nil")
+	}
+	cm, err := client.Caches.NewManager(context.Background(), &CacheManagerConfig{Owner: strings.Repeat("o", 59)})
+	if err != nil {
+		t.Fatalf("NewManager() with a 59 character owner unexpected error: %v", err)
+	}
+	key, err := client.Caches.ContentKey("gemini-2.0-flash", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cm.displayName("key-" + key)); n > maxCacheDisplayNameLength {
+		t.Errorf("display name length = %d, want at most %d", n, maxCacheDisplayNameLength)
+	}
+	cm.Close(context.Background())
+}
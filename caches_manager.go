@@ -0,0 +1,352 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultManagedCacheTTL = time.Hour
+	// maxCacheDisplayNameLength is the longest display name the API accepts.
+	maxCacheDisplayNameLength = 128
+	// maxCacheOwnerLength is the longest owner whose display names fit the
+	// content keys of [CacheManager.Acquire]: owner + "/key-" + 64 hex digits.
+	maxCacheOwnerLength = maxCacheDisplayNameLength - len("/key-") - 2*sha256.Size
+)
+
+// CacheManagerConfig holds parameters for [Caches.NewManager].
+type CacheManagerConfig struct {
+	// Optional. Used to override HTTP request options of every call the manager
+	// makes.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Required. Identifies the caches created by managers of this service. It is
+	// used as the prefix of the display name of every cache, so that caches
+	// leaked by a crashed process can be found and deleted. Cached contents do
+	// not support labels. At most 59 characters long.
+	Owner string `json:"owner,omitempty"`
+	// Optional. Time to live set on creation and on every extension. Defaults to
+	// 1 hour.
+	TTL time.Duration `json:"ttl,omitempty"`
+	// Optional. Delay between two TTL extensions of the referenced caches.
+	// Defaults to half of TTL.
+	RefreshInterval time.Duration `json:"refreshInterval,omitempty"`
+	// Optional. An owned cache that is not referenced by this manager is
+	// considered orphaned by [CacheManager.CollectGarbage] if it has not been
+	// updated for this long. Defaults to twice RefreshInterval, so that caches
+	// kept alive by other running managers of the same owner are left alone.
+	OrphanAfter time.Duration `json:"orphanAfter,omitempty"`
+	// Optional. Called when a background TTL extension fails.
+	OnRefreshError func(name string, err error) `json:"-"`
+}
+
+// CacheManager creates cached contents, keeps them alive for as long as they
+// are referenced, and deletes them when they no longer are. It is safe for
+// concurrent use.
+type CacheManager struct {
+	caches Caches
+	config CacheManagerConfig
+
+	mu      sync.Mutex
+	entries map[string]*managedCache
+	// byKey maps content keys to the names of the caches in entries.
+	byKey map[string]string
+	// pending holds the in-flight acquisitions by content key.
+	pending map[string]*pendingAcquire
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+type managedCache struct {
+	cache *CachedContent
+	refs  int
+	// key is the content key of caches obtained by [CacheManager.Acquire].
+	key string
+	// shared is set for caches that other managers may also use, which are
+	// left to expire instead of being deleted when released.
+	shared bool
+}
+
+// NewManager returns a [CacheManager] for caches owned by config.Owner and
+// starts extending the TTL of its caches in the background. The background work
+// stops when ctx is done or [CacheManager.Close] is called.
+func (m Caches) NewManager(ctx context.Context, config *CacheManagerConfig) (*CacheManager, error) {
+	if config == nil || config.Owner == "" {
+		return nil, fmt.Errorf("CacheManagerConfig.Owner is required")
+	}
+	if strings.Contains(config.Owner, "/") {
+		return nil, fmt.Errorf("CacheManagerConfig.Owner must not contain '/'")
+	}
+	if len(config.Owner) > maxCacheOwnerLength {
+		return nil, fmt.Errorf("CacheManagerConfig.Owner must be at most %d characters long, got %d", maxCacheOwnerLength, len(config.Owner))
+	}
+	cm := &CacheManager{
+		caches:  m,
+		config:  *config,
+		entries: make(map[string]*managedCache),
+		byKey:   make(map[string]string),
+		pending: make(map[string]*pendingAcquire),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if cm.config.TTL <= 0 {
+		cm.config.TTL = defaultManagedCacheTTL
+	}
+	if cm.config.RefreshInterval <= 0 {
+		cm.config.RefreshInterval = cm.config.TTL / 2
+	}
+	if cm.config.OrphanAfter <= 0 {
+		cm.config.OrphanAfter = 2 * cm.config.RefreshInterval
+	}
+	go cm.refreshLoop(ctx)
+	return cm, nil
+}
+
+func (cm *CacheManager) refreshLoop(ctx context.Context) {
+	defer close(cm.done)
+	ticker := time.NewTicker(cm.config.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-cm.stop:
+			return
+		case <-ticker.C:
+			for name, err := range cm.refresh(ctx) {
+				if cm.config.OnRefreshError != nil {
+					cm.config.OnRefreshError(name, err)
+				}
+			}
+		}
+	}
+}
+
+// displayName returns the display name of an owned cache.
+func (cm *CacheManager) displayName(name string) string {
+	return cm.config.Owner + "/" + name
+}
+
+// Owns reports whether the cache was created by a manager with the same owner.
+func (cm *CacheManager) Owns(cache *CachedContent) bool {
+	return strings.HasPrefix(cache.DisplayName, cm.config.Owner+"/")
+}
+
+// Create creates a cached content holding one reference, and keeps it alive
+// until the reference is released with [CacheManager.Release]. displayName is
+// prefixed with the owner of the manager. config.TTL and config.ExpireTime are
+// ignored in favor of the manager's TTL.
+func (cm *CacheManager) Create(ctx context.Context, model, displayName string, config *CreateCachedContentConfig) (*CachedContent, error) {
+	cache, err := cm.create(ctx, model, displayName, config)
+	if err != nil {
+		return nil, err
+	}
+	cm.mu.Lock()
+	cm.entries[cache.Name] = &managedCache{cache: cache, refs: 1}
+	cm.mu.Unlock()
+	return cache, nil
+}
+
+func (cm *CacheManager) create(ctx context.Context, model, displayName string, config *CreateCachedContentConfig) (*CachedContent, error) {
+	c := CreateCachedContentConfig{}
+	if config != nil {
+		c = *config
+	}
+	c.DisplayName = cm.displayName(displayName)
+	if len(c.DisplayName) > maxCacheDisplayNameLength {
+		return nil, fmt.Errorf("cache display name %q is longer than %d characters", c.DisplayName, maxCacheDisplayNameLength)
+	}
+	c.TTL = cm.config.TTL
+	c.ExpireTime = time.Time{}
+	if c.HTTPOptions == nil {
+		c.HTTPOptions = cloneHTTPOptions(cm.config.HTTPOptions)
+	}
+	return cm.caches.Create(ctx, model, &c)
+}
+
+// Retain adds a reference to a cache created by the manager.
+func (cm *CacheManager) Retain(name string) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	e, ok := cm.entries[name]
+	if !ok {
+		return fmt.Errorf("cache %s is not managed", name)
+	}
+	e.refs++
+	return nil
+}
+
+// Release drops a reference to a cache of the manager. A cache made by
+// [CacheManager.Create] is deleted when its last reference is released; one
+// obtained by [CacheManager.Acquire] is no longer kept alive and expires on its
+// own, since other processes may still use it.
+func (cm *CacheManager) Release(ctx context.Context, name string) error {
+	cm.mu.Lock()
+	e, ok := cm.entries[name]
+	if !ok {
+		cm.mu.Unlock()
+		return fmt.Errorf("cache %s is not managed", name)
+	}
+	e.refs--
+	if e.refs > 0 {
+		cm.mu.Unlock()
+		return nil
+	}
+	delete(cm.entries, name)
+	if e.key != "" {
+		delete(cm.byKey, e.key)
+	}
+	cm.mu.Unlock()
+	if e.shared {
+		return nil
+	}
+	return cm.delete(ctx, name)
+}
+
+func (cm *CacheManager) delete(ctx context.Context, name string) error {
+	_, err := cm.caches.Delete(ctx, name, &DeleteCachedContentConfig{HTTPOptions: cloneHTTPOptions(cm.config.HTTPOptions)})
+	var apiErr APIError
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Refresh extends the TTL of every referenced cache now, instead of waiting for
+// the background extension. It returns the errors keyed by cache name.
+func (cm *CacheManager) Refresh(ctx context.Context) map[string]error {
+	return cm.refresh(ctx)
+}
+
+func (cm *CacheManager) refresh(ctx context.Context) map[string]error {
+	cm.mu.Lock()
+	names := make([]string, 0, len(cm.entries))
+	for name := range cm.entries {
+		names = append(names, name)
+	}
+	cm.mu.Unlock()
+
+	errs := make(map[string]error)
+	for _, name := range names {
+		// Skip the caches released since, which may be deleted already.
+		if !cm.referenced(name) {
+			continue
+		}
+		cache, err := cm.caches.Update(ctx, name, &UpdateCachedContentConfig{HTTPOptions: cloneHTTPOptions(cm.config.HTTPOptions), TTL: cm.config.TTL})
+		cm.mu.Lock()
+		e, ok := cm.entries[name]
+		if ok && err == nil {
+			e.cache = cache
+		}
+		cm.mu.Unlock()
+		if ok && err != nil {
+			errs[name] = err
+		}
+	}
+	return errs
+}
+
+// referenced reports whether the cache is referenced by the manager.
+func (cm *CacheManager) referenced(name string) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	_, ok := cm.entries[name]
+	return ok
+}
+
+// CollectGarbage deletes the owned caches that are not referenced by this
+// manager and have not been updated for config.OrphanAfter, which are left
+// behind by crashed processes. Call it on startup. It returns the names of the
+// deleted caches.
+func (cm *CacheManager) CollectGarbage(ctx context.Context) ([]string, error) {
+	var deleted []string
+	now := time.Now()
+	for cache, err := range cm.caches.All(ctx) {
+		if err != nil {
+			return deleted, err
+		}
+		if !cm.Owns(cache) {
+			continue
+		}
+		referenced := cm.referenced(cache.Name)
+		lastUpdate := cache.UpdateTime
+		if lastUpdate.IsZero() {
+			lastUpdate = cache.CreateTime
+		}
+		if referenced || now.Sub(lastUpdate) < cm.config.OrphanAfter {
+			continue
+		}
+		if err := cm.delete(ctx, cache.Name); err != nil {
+			return deleted, fmt.Errorf("deleting orphaned cache %s: %w", cache.Name, err)
+		}
+		deleted = append(deleted, cache.Name)
+	}
+	return deleted, nil
+}
+
+// Usage returns the summed usage metadata of the referenced caches.
+func (cm *CacheManager) Usage() *CachedContentUsageMetadata {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	total := &CachedContentUsageMetadata{}
+	for _, e := range cm.entries {
+		u := e.cache.UsageMetadata
+		if u == nil {
+			continue
+		}
+		total.AudioDurationSeconds += u.AudioDurationSeconds
+		total.ImageCount += u.ImageCount
+		total.TextCount += u.TextCount
+		total.TotalTokenCount += u.TotalTokenCount
+		total.VideoDurationSeconds += u.VideoDurationSeconds
+	}
+	return total
+}
+
+// Close stops the background TTL extension and deletes every cache still
+// referenced by the manager, except the shared ones obtained by
+// [CacheManager.Acquire].
+func (cm *CacheManager) Close(ctx context.Context) error {
+	cm.closeOnce.Do(func() { close(cm.stop) })
+	<-cm.done
+
+	cm.mu.Lock()
+	names := make([]string, 0, len(cm.entries))
+	for name, e := range cm.entries {
+		if !e.shared {
+			names = append(names, name)
+		}
+	}
+	cm.entries = make(map[string]*managedCache)
+	cm.byKey = make(map[string]string)
+	cm.mu.Unlock()
+
+	var errs []error
+	for _, name := range names {
+		if err := cm.delete(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("deleting cache %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
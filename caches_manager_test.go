@@ -0,0 +1,295 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeCachesServer is an in-memory cachedContents service of the Gemini API.
+type fakeCachesServer struct {
+	t      *testing.T
+	mu     sync.Mutex
+	caches map[string]map[string]any
+	nextID int
+	calls  []string
+}
+
+func newFakeCachesServer(t *testing.T) *fakeCachesServer {
+	return &fakeCachesServer{t: t, caches: make(map[string]map[string]any)}
+}
+
+func (s *fakeCachesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := strings.TrimPrefix(r.URL.Path, "/v1beta/")
+	s.calls = append(s.calls, r.Method+" "+name)
+	switch {
+	case r.Method == http.MethodPost && name == "cachedContents":
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.t.Errorf("Failed to decode request: %v", err)
+		}
+		s.nextID++
+		now := time.Now().UTC().Format(time.RFC3339)
+		cache := map[string]any{
+			"name":          fmt.Sprintf("cachedContents/c%d", s.nextID),
+			"displayName":   body["displayName"],
+			"model":         body["model"],
+			"createTime":    now,
+			"updateTime":    now,
+			"expireTime":    time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			"usageMetadata": map[string]any{"totalTokenCount": 100},
+		}
+		s.caches[cache["name"].(string)] = cache
+		_ = json.NewEncoder(w).Encode(cache)
+	case r.Method == http.MethodGet && name == "cachedContents":
+		var list []map[string]any
+		for _, c := range s.caches {
+			list = append(list, c)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"cachedContents": list})
+	case r.Method == http.MethodPatch && s.caches[name] != nil:
+		s.caches[name]["updateTime"] = time.Now().UTC().Format(time.RFC3339)
+		s.caches[name]["expireTime"] = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		_ = json.NewEncoder(w).Encode(s.caches[name])
+	case r.Method == http.MethodDelete && s.caches[name] != nil:
+		delete(s.caches, name)
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found","status":"NOT_FOUND"}}`))
+	}
+}
+
+func (s *fakeCachesServer) add(name, displayName string, updateTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caches[name] = map[string]any{
+		"name":        name,
+		"displayName": displayName,
+		"updateTime":  updateTime.UTC().Format(time.RFC3339),
+		"expireTime":  updateTime.Add(time.Hour).UTC().Format(time.RFC3339),
+	}
+}
+
+func (s *fakeCachesServer) count(call string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, c := range s.calls {
+		if c == call {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *fakeCachesServer) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCacheManagerLifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCachesServer(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	cm, err := client.Caches.NewManager(ctx, &CacheManagerConfig{Owner: "svc", TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("NewManager() unexpected error: %v", err)
+	}
+	cache, err := cm.Create(ctx, "gemini-2.0-flash", "docs", &CreateCachedContentConfig{
+		Contents: []*Content{NewContentFromText("hello", RoleUser)},
+	})
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if cache.DisplayName != "svc/docs" {
+		t.Errorf("DisplayName = %q, want %q", cache.DisplayName, "svc/docs")
+	}
+	if err := cm.Retain(cache.Name); err != nil {
+		t.Fatalf("Retain() unexpected error: %v", err)
+	}
+	if errs := cm.Refresh(ctx); len(errs) != 0 {
+		t.Errorf("Refresh() unexpected errors: %v", errs)
+	}
+	if got := cm.Usage().TotalTokenCount; got != 100 {
+		t.Errorf("Usage().TotalTokenCount = %d, want 100", got)
+	}
+
+	if err := cm.Release(ctx, cache.Name); err != nil {
+		t.Fatalf("Release() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{cache.Name}, fake.names()); diff != "" {
+		t.Errorf("caches after first Release() mismatch (-want +got):\n%s", diff)
+	}
+	if err := cm.Release(ctx, cache.Name); err != nil {
+		t.Fatalf("Release() unexpected error: %v", err)
+	}
+	if got := fake.names(); len(got) != 0 {
+		t.Errorf("caches after last Release() = %v, want none", got)
+	}
+	if err := cm.Close(ctx); err != nil {
+		t.Errorf("Close() unexpected error: %v", err)
+	}
+}
+
+func TestCacheManagerCollectGarbage(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCachesServer(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	old := time.Now().Add(-3 * time.Hour)
+	fake.add("cachedContents/orphan", "svc/leaked", old)
+	fake.add("cachedContents/live-peer", "svc/peer", time.Now())
+	fake.add("cachedContents/other-owner", "other/leaked", old)
+
+	cm, err := client.Caches.NewManager(ctx, &CacheManagerConfig{Owner: "svc", TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("NewManager() unexpected error: %v", err)
+	}
+	defer cm.Close(ctx)
+	deleted, err := cm.CollectGarbage(ctx)
+	if err != nil {
+		t.Fatalf("CollectGarbage() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"cachedContents/orphan"}, deleted); diff != "" {
+		t.Errorf("CollectGarbage() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"cachedContents/live-peer", "cachedContents/other-owner"}, fake.names()); diff != "" {
+		t.Errorf("remaining caches mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCacheManagerBackgroundRefresh(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCachesServer(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	cm, err := client.Caches.NewManager(ctx, &CacheManagerConfig{Owner: "svc", RefreshInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewManager() unexpected error: %v", err)
+	}
+	cache, err := cm.Create(ctx, "gemini-2.0-flash", "docs", nil)
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		fake.mu.Lock()
+		calls := strings.Join(fake.calls, "\n")
+		fake.mu.Unlock()
+		if strings.Contains(calls, "PATCH "+cache.Name) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache %s was not refreshed in the background; calls:\n%s", cache.Name, calls)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := cm.Close(ctx); err != nil {
+		t.Errorf("Close() unexpected error: %v", err)
+	}
+	if got := fake.names(); len(got) != 0 {
+		t.Errorf("caches after Close() = %v, want none", got)
+	}
+}
+
+func TestCacheManagerRefreshSkipsReleasedAndConcurrentClose(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeCachesServer(t)
+	var (
+		cm       *CacheManager
+		names    []string
+		released string
+		once     sync.Once
+	)
+	// Release the other cache while the first one is refreshed.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			once.Do(func() {
+				patched := strings.TrimPrefix(r.URL.Path, "/v1beta/")
+				for _, name := range names {
+					if name != patched {
+						released = name
+					}
+				}
+				if err := cm.Release(ctx, released); err != nil {
+					t.Errorf("Release() unexpected error: %v", err)
+				}
+			})
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	cm, err := client.Caches.NewManager(ctx, &CacheManagerConfig{Owner: "svc", RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewManager() unexpected error: %v", err)
+	}
+	for _, displayName := range []string{"a", "b"} {
+		cache, err := cm.Create(ctx, "gemini-2.0-flash", displayName, nil)
+		if err != nil {
+			t.Fatalf("Create() unexpected error: %v", err)
+		}
+		names = append(names, cache.Name)
+	}
+	if errs := cm.Refresh(ctx); len(errs) != 0 {
+		t.Errorf("Refresh() errors = %v, want none", errs)
+	}
+	if n := fake.count("PATCH " + released); n != 0 {
+		t.Errorf("released cache %s was refreshed %d times, want 0", released, n)
+	}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cm.Close(ctx); err != nil {
+				t.Errorf("Close() unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := fake.names(); len(got) != 0 {
+		t.Errorf("caches after Close() = %v, want none", got)
+	}
+}
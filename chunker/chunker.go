@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chunker splits documents into chunks for embedding and retrieval.
+//
+// Chunks are measured in tokens. By default a token is a whitespace-separated
+// word, which matches the server-side chunking configured by
+// [genai.WhiteSpaceConfig]; [NewLocalTokenizer] measures them with the model
+// tokenizer instead. Every chunk carries the byte offsets of the text it was
+// taken from, so that search results can cite their source.
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultMaxTokensPerChunk is the chunk size used when none is configured.
+	DefaultMaxTokensPerChunk = 256
+)
+
+// Chunk is a piece of a document.
+type Chunk struct {
+	// Text is the content of the chunk. For plain text and Markdown it is the
+	// document between Start and End; for HTML it is the text of the elements
+	// in that range, without markup.
+	Text string `json:"text"`
+	// Start is the byte offset of the chunk in the document.
+	Start int `json:"start"`
+	// End is the byte offset just after the chunk in the document.
+	End int `json:"end"`
+	// Headings are the titles of the sections enclosing the chunk, outermost
+	// first. Only set by [SplitMarkdown] and [SplitHTML].
+	Headings []string `json:"headings,omitempty"`
+}
+
+// Span is the byte range of a token in a text.
+type Span struct {
+	Start, End int
+}
+
+// Tokenizer splits text into tokens.
+type Tokenizer interface {
+	// Tokens returns the byte ranges of the tokens of text, in order.
+	Tokens(text string) ([]Span, error)
+}
+
+// Whitespace is the [Tokenizer] that treats every run of non-space characters
+// as a token.
+var Whitespace Tokenizer = whitespaceTokenizer{}
+
+type whitespaceTokenizer struct{}
+
+func (whitespaceTokenizer) Tokens(text string) ([]Span, error) {
+	var spans []Span
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, Span{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, Span{start, len(text)})
+	}
+	return spans, nil
+}
+
+// Config holds the parameters of the chunkers.
+type Config struct {
+	// Optional. Maximum number of tokens per chunk. Defaults to
+	// [DefaultMaxTokensPerChunk].
+	MaxTokensPerChunk int
+	// Optional. Number of tokens repeated at the start of a chunk from the end
+	// of the previous one, when a run of text is split into several chunks.
+	// Must be less than MaxTokensPerChunk.
+	MaxOverlapTokens int
+	// Optional. Used to split text into tokens. Defaults to [Whitespace].
+	Tokenizer Tokenizer
+}
+
+// ConfigFromChunkingConfig returns the Config that mirrors the server-side
+// chunking of a file search store configured with c.
+func ConfigFromChunkingConfig(c *genai.ChunkingConfig) *Config {
+	config := &Config{}
+	if c == nil || c.WhiteSpaceConfig == nil {
+		return config
+	}
+	if c.WhiteSpaceConfig.MaxTokensPerChunk != nil {
+		config.MaxTokensPerChunk = int(*c.WhiteSpaceConfig.MaxTokensPerChunk)
+	}
+	if c.WhiteSpaceConfig.MaxOverlapTokens != nil {
+		config.MaxOverlapTokens = int(*c.WhiteSpaceConfig.MaxOverlapTokens)
+	}
+	return config
+}
+
+func (c *Config) normalized() (Config, error) {
+	out := Config{}
+	if c != nil {
+		out = *c
+	}
+	if out.MaxTokensPerChunk <= 0 {
+		out.MaxTokensPerChunk = DefaultMaxTokensPerChunk
+	}
+	if out.MaxOverlapTokens < 0 || out.MaxOverlapTokens >= out.MaxTokensPerChunk {
+		return out, fmt.Errorf("MaxOverlapTokens must be in [0, %d), got %d", out.MaxTokensPerChunk, out.MaxOverlapTokens)
+	}
+	if out.Tokenizer == nil {
+		out.Tokenizer = Whitespace
+	}
+	return out, nil
+}
+
+// Split splits text into chunks of at most config.MaxTokensPerChunk tokens,
+// each overlapping the previous one by config.MaxOverlapTokens tokens.
+func Split(text string, config *Config) ([]Chunk, error) {
+	c, err := config.normalized()
+	if err != nil {
+		return nil, err
+	}
+	return splitWindows(text, 0, c)
+}
+
+// splitWindows splits text, which starts at offset in the document, into
+// overlapping windows of tokens.
+func splitWindows(text string, offset int, c Config) ([]Chunk, error) {
+	spans, err := c.Tokenizer.Tokens(text)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []Chunk
+	step := c.MaxTokensPerChunk - c.MaxOverlapTokens
+	for i := 0; i < len(spans); i += step {
+		j := min(i+c.MaxTokensPerChunk, len(spans))
+		start, end := trimSpace(text, spans[i].Start, spans[j-1].End)
+		if start < end {
+			chunks = append(chunks, Chunk{Text: text[start:end], Start: offset + start, End: offset + end})
+		}
+		if j == len(spans) {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// trimSpace narrows [start, end) of text to exclude surrounding white space.
+func trimSpace(text string, start, end int) (int, int) {
+	for start < end {
+		r, size := utf8.DecodeRuneInString(text[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+	for end > start {
+		r, size := utf8.DecodeLastRuneInString(text[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+	return start, end
+}
+
+// block is a structural unit of a document, such as a paragraph, that is kept
+// whole in a chunk when it fits.
+type block struct {
+	text       string
+	start, end int
+	// exact is set if text is the document between start and end, which allows
+	// precise offsets when the block is split.
+	exact    bool
+	headings []string
+	// section is set for the first block of a section, which always starts a
+	// new chunk.
+	section bool
+}
+
+// pack groups consecutive blocks into chunks of at most c.MaxTokensPerChunk
+// tokens. Chunks never span two sections, and blocks larger than a chunk are
+// split into overlapping windows.
+func pack(doc string, blocks []block, c Config) ([]Chunk, error) {
+	var chunks []Chunk
+	var group []block
+	groupTokens := 0
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		first, last := group[0], group[len(group)-1]
+		chunk := Chunk{Start: first.start, End: last.end, Headings: first.headings}
+		exact := true
+		texts := make([]string, len(group))
+		for i, b := range group {
+			exact = exact && b.exact
+			texts[i] = b.text
+		}
+		if exact {
+			chunk.Text = doc[chunk.Start:chunk.End]
+		} else {
+			chunk.Text = strings.Join(texts, "\n\n")
+		}
+		chunks = append(chunks, chunk)
+		group, groupTokens = nil, 0
+	}
+	for _, b := range blocks {
+		spans, err := c.Tokenizer.Tokens(b.text)
+		if err != nil {
+			return nil, err
+		}
+		n := len(spans)
+		if n == 0 {
+			continue
+		}
+		if b.section || groupTokens+n > c.MaxTokensPerChunk {
+			flush()
+		}
+		if n <= c.MaxTokensPerChunk {
+			group = append(group, b)
+			groupTokens += n
+			continue
+		}
+		windows, err := splitWindows(b.text, b.start, c)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range windows {
+			if !b.exact {
+				// Offsets inside extracted text do not map to the document.
+				w.Start, w.End = b.start, b.end
+			}
+			w.Headings = b.headings
+			chunks = append(chunks, w)
+		}
+	}
+	flush()
+	return chunks, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunker
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+func TestSplit(t *testing.T) {
+	text := "one two  three four\nfive six seven"
+	got, err := Split(text, &Config{MaxTokensPerChunk: 3, MaxOverlapTokens: 1})
+	if err != nil {
+		t.Fatalf("Split() unexpected error: %v", err)
+	}
+	want := []Chunk{
+		{Text: "one two  three", Start: 0, End: 14},
+		{Text: "three four\nfive", Start: 9, End: 24},
+		{Text: "five six seven", Start: 20, End: 34},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Split() mismatch (-want +got):\n%s", diff)
+	}
+	for _, c := range got {
+		if text[c.Start:c.End] != c.Text {
+			t.Errorf("chunk %q does not match its offsets [%d, %d)", c.Text, c.Start, c.End)
+		}
+	}
+}
+
+func TestSplitInvalidOverlap(t *testing.T) {
+	if _, err := Split("a b", &Config{MaxTokensPerChunk: 2, MaxOverlapTokens: 2}); err == nil {
+		t.Errorf("Split() want error, got nil")
+	}
+}
+
+func TestConfigFromChunkingConfig(t *testing.T) {
+	got := ConfigFromChunkingConfig(&genai.ChunkingConfig{WhiteSpaceConfig: &genai.WhiteSpaceConfig{
+		MaxTokensPerChunk: genai.Ptr[int32](200),
+		MaxOverlapTokens:  genai.Ptr[int32](20),
+	}})
+	want := &Config{MaxTokensPerChunk: 200, MaxOverlapTokens: 20}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ConfigFromChunkingConfig() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSplitMarkdown(t *testing.T) {
+	doc := `# Guide
+
+Intro paragraph.
+
+## Install
+
+Run the installer.
+
+` + "```" + `
+go get x
+
+go build
+` + "```" + `
+
+## Use
+Call it with a b c d e.
+`
+	got, err := SplitMarkdown(doc, &Config{MaxTokensPerChunk: 7})
+	if err != nil {
+		t.Fatalf("SplitMarkdown() unexpected error: %v", err)
+	}
+	want := []Chunk{
+		{Text: "# Guide\n\nIntro paragraph.", Headings: []string{"Guide"}},
+		{Text: "## Install\n\nRun the installer.", Headings: []string{"Guide", "Install"}},
+		{Text: "```\ngo get x\n\ngo build\n```", Headings: []string{"Guide", "Install"}},
+		{Text: "## Use", Headings: []string{"Guide", "Use"}},
+		{Text: "Call it with a b c d", Headings: []string{"Guide", "Use"}},
+		{Text: "e.", Headings: []string{"Guide", "Use"}},
+	}
+	for _, c := range got {
+		if doc[c.Start:c.End] != c.Text {
+			t.Errorf("chunk %q does not match its offsets [%d, %d)", c.Text, c.Start, c.End)
+		}
+	}
+	for i := range got {
+		got[i].Start, got[i].End = 0, 0
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SplitMarkdown() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSplitHTML(t *testing.T) {
+	doc := `<html><head><style>p { color: red }</style></head><body>
+<h1>Title</h1><p>First &amp; second.</p>
+<h2>Part</h2><ul><li>one</li><li>two</li></ul><script>var x = 1;</script>
+</body></html>`
+	got, err := SplitHTML(doc, &Config{MaxTokensPerChunk: 4})
+	if err != nil {
+		t.Fatalf("SplitHTML() unexpected error: %v", err)
+	}
+	want := []Chunk{
+		{Text: "Title\n\nFirst & second.", Headings: []string{"Title"}},
+		{Text: "Part\n\none\n\ntwo", Headings: []string{"Title", "Part"}},
+	}
+	wantSource := []string{"Title</h1><p>First &amp; second.", "Part</h2><ul><li>one</li><li>two"}
+	for i, c := range got {
+		if i < len(wantSource) && doc[c.Start:c.End] != wantSource[i] {
+			t.Errorf("chunk %d source = %q, want %q", i, doc[c.Start:c.End], wantSource[i])
+		}
+		got[i].Start, got[i].End = 0, 0
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SplitHTML() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAlignPieces(t *testing.T) {
+	text := "Hello world\n!"
+	got, err := alignPieces(text, []string{"▁Hello", "▁world", "<0x0A>", "!"})
+	if err != nil {
+		t.Fatalf("alignPieces() unexpected error: %v", err)
+	}
+	want := []Span{{0, 5}, {5, 11}, {11, 12}, {12, 13}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("alignPieces() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := alignPieces(text, []string{"Bye"}); err == nil {
+		t.Errorf("alignPieces() with mismatching pieces: want error, got nil")
+	}
+
+	// "€" is encoded as the bytes E2 82 AC.
+	got, err = alignPieces("a€b", []string{"▁a", "<0xE2>", "<0x82>", "<0xAC>", "b"})
+	if err != nil {
+		t.Fatalf("alignPieces() unexpected error: %v", err)
+	}
+	want = []Span{{0, 1}, {1, 4}, {4, 5}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("alignPieces() with byte pieces mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := alignPieces("a€b", []string{"▁a", "<0xE2>", "b"}); err == nil {
+		t.Errorf("alignPieces() with an incomplete character: want error, got nil")
+	}
+}
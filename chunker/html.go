@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunker
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlBlockElements end the text block they appear in.
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Br: true, atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Td: true, atom.Th: true, atom.Tr: true, atom.Ul: true,
+}
+
+var htmlHeadingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// SplitHTML splits an HTML document along its structure, like
+// [SplitMarkdown]: h1 to h6 elements start new sections, and the text of block
+// elements such as paragraphs and list items is kept whole as long as it fits
+// in a chunk. Chunk texts are stripped of markup, and their offsets refer to
+// the HTML source. Scripts and styles are ignored.
+func SplitHTML(doc string, config *Config) ([]Chunk, error) {
+	c, err := config.normalized()
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := htmlBlocks(doc)
+	if err != nil {
+		return nil, err
+	}
+	return pack(doc, blocks, c)
+}
+
+func htmlBlocks(doc string) ([]block, error) {
+	var blocks []block
+	var headings []string
+	var text strings.Builder
+	start, end := -1, -1
+	headingLevel := 0
+	skip := 0
+	section := false
+	flush := func() {
+		t := strings.Join(strings.Fields(text.String()), " ")
+		text.Reset()
+		if t != "" {
+			if headingLevel > 0 {
+				headings = append(slices.Clip(headings[:min(headingLevel-1, len(headings))]), t)
+				section = true
+			}
+			blocks = append(blocks, block{text: t, start: start, end: end, headings: headings, section: section})
+			section = false
+		}
+		start, end = -1, -1
+	}
+
+	z := html.NewTokenizer(strings.NewReader(doc))
+	offset := 0
+	for {
+		tt := z.Next()
+		raw := len(z.Raw())
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				break
+			}
+			return nil, z.Err()
+		}
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				t := string(z.Text())
+				if strings.TrimSpace(t) != "" {
+					if start < 0 {
+						start = offset
+					}
+					end = offset + raw
+				}
+				text.WriteString(t)
+			}
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if a == atom.Script || a == atom.Style {
+				if tt == html.StartTagToken {
+					skip++
+				} else if tt == html.EndTagToken && skip > 0 {
+					skip--
+				}
+			}
+			if htmlBlockElements[a] {
+				flush()
+				headingLevel = 0
+				if level, ok := htmlHeadingLevels[a]; ok && tt == html.StartTagToken {
+					headingLevel = level
+				}
+			}
+		}
+		offset += raw
+	}
+	flush()
+	return blocks, nil
+}
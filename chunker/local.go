@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/genai"
+	"google.golang.org/genai/tokenizer"
+)
+
+// NewLocalTokenizer returns a [Tokenizer] that counts tokens like the model
+// of tok does, except that a character encoded as several byte tokens counts
+// as one token.
+func NewLocalTokenizer(tok *tokenizer.LocalTokenizer) Tokenizer {
+	return localTokenizer{tok}
+}
+
+type localTokenizer struct {
+	tok *tokenizer.LocalTokenizer
+}
+
+func (t localTokenizer) Tokens(text string) ([]Span, error) {
+	if text == "" {
+		return nil, nil
+	}
+	result, err := t.tok.ComputeTokens([]*genai.Content{genai.NewContentFromText(text, genai.RoleUser)})
+	if err != nil {
+		return nil, err
+	}
+	var pieces []string
+	for _, info := range result.TokensInfo {
+		for _, p := range info.Tokens {
+			pieces = append(pieces, string(p))
+		}
+	}
+	return alignPieces(text, pieces)
+}
+
+// alignPieces returns the spans of SentencePiece pieces in the text they were
+// encoded from. Pieces mark spaces with U+2581 and encode bytes missing from the
+// vocabulary as "<0xNN>". Consecutive byte pieces that encode a character are
+// merged into a single span, so that spans never split a character.
+func alignPieces(text string, pieces []string) ([]Span, error) {
+	spans := make([]Span, 0, len(pieces))
+	offset := 0
+	// pending holds the bytes of the byte pieces of an incomplete character.
+	var pending []byte
+	for i, p := range pieces {
+		var piece string
+		if len(p) == 6 && strings.HasPrefix(p, "<0x") && strings.HasSuffix(p, ">") {
+			b, err := strconv.ParseUint(p[3:5], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid byte piece %q", p)
+			}
+			pending = append(pending, byte(b))
+			if !utf8.FullRune(pending) && i < len(pieces)-1 {
+				continue
+			}
+			piece, pending = string(pending), nil
+		} else {
+			if len(pending) > 0 {
+				return nil, fmt.Errorf("incomplete character before token %q at offset %d", p, offset)
+			}
+			piece = strings.ReplaceAll(p, "▁", " ")
+		}
+		if i == 0 && !strings.HasPrefix(text, piece) && strings.HasPrefix(piece, " ") {
+			// The encoder may have added a dummy space before the text.
+			piece = piece[1:]
+		}
+		if !strings.HasPrefix(text[offset:], piece) {
+			return nil, fmt.Errorf("cannot align token %q with the text at offset %d", p, offset)
+		}
+		spans = append(spans, Span{offset, offset + len(piece)})
+		offset += len(piece)
+	}
+	return spans, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunker
+
+import (
+	"slices"
+	"strings"
+)
+
+// SplitMarkdown splits a Markdown document along its structure. Headings start
+// new chunks, and paragraphs, lists and fenced code blocks are kept whole as
+// long as they fit in a chunk; consecutive ones are grouped up to
+// config.MaxTokensPerChunk tokens. Each chunk records the headings of the
+// section it belongs to.
+func SplitMarkdown(doc string, config *Config) ([]Chunk, error) {
+	c, err := config.normalized()
+	if err != nil {
+		return nil, err
+	}
+	return pack(doc, markdownBlocks(doc), c)
+}
+
+// markdownBlocks splits doc into blocks separated by blank lines and headings.
+func markdownBlocks(doc string) []block {
+	var blocks []block
+	var headings []string
+	start, end := -1, -1
+	section := false
+	inFence := ""
+	flush := func() {
+		if start >= 0 {
+			blocks = append(blocks, block{text: doc[start:end], start: start, end: end, exact: true, headings: headings, section: section})
+			section = false
+		}
+		start, end = -1, -1
+	}
+	for offset := 0; offset < len(doc); {
+		lineEnd := strings.IndexByte(doc[offset:], '\n')
+		next := offset + lineEnd + 1
+		if lineEnd < 0 {
+			lineEnd = len(doc) - offset
+			next = len(doc)
+		}
+		line := doc[offset : offset+lineEnd]
+		trimmed := strings.TrimSpace(line)
+
+		heading := false
+		switch {
+		case inFence != "":
+			if strings.HasPrefix(trimmed, inFence) {
+				inFence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			inFence = trimmed[:3]
+		case trimmed == "":
+			flush()
+			offset = next
+			continue
+		default:
+			var level int
+			var title string
+			if level, title, heading = markdownHeading(trimmed); heading {
+				flush()
+				headings = append(slices.Clip(headings[:min(level-1, len(headings))]), title)
+				section = true
+			}
+		}
+		if start < 0 {
+			start = offset
+		}
+		end = max(end, offset+len(strings.TrimRight(line, " \t\r")))
+		if heading {
+			// A heading is a block of its own, but starts the chunk of the
+			// section it introduces.
+			flush()
+		}
+		offset = next
+	}
+	flush()
+	return blocks
+}
+
+// markdownHeading parses an ATX heading such as "## Title".
+func markdownHeading(line string) (level int, title string, ok bool) {
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0, "", false
+	}
+	return level, strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#")), true
+}
@@ -44,6 +44,8 @@ type Client struct {
 	Operations *Operations
 	// FileSearchStores provides access to the File Search Stores service.
 	FileSearchStores *FileSearchStores
+	// RAGCorpora provides access to the Vertex AI RAG Engine corpora.
+	RAGCorpora *RAGCorpora
 	// Batches provides access to the Batch service.
 	Batches *Batches
 	// Tunings provides access to the Tunings service.
@@ -336,6 +338,7 @@ func NewClient(ctx context.Context, cc *ClientConfig) (*Client, error) {
 		Chats:            &Chats{apiClient: ac},
 		Operations:       &Operations{apiClient: ac},
 		FileSearchStores: &FileSearchStores{apiClient: ac, Documents: &Documents{apiClient: ac}},
+		RAGCorpora:       &RAGCorpora{apiClient: ac, Files: &RAGFiles{apiClient: ac}},
 		Files:            &Files{apiClient: ac},
 		Batches:          &Batches{apiClient: ac},
 		Tunings:          &Tunings{apiClient: ac},
@@ -0,0 +1,416 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// FieldError is a problem with a field of a config, reported by the Validate
+// methods of the configs, such as [GenerateContentConfig.Validate].
+type FieldError struct {
+	// Field is the JSON path of the field in the config, such as "labels" or
+	// "tools[0].functionDeclarations[1].behavior".
+	Field string
+	// Message describes the problem, such as "is not supported in Gemini API".
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is the error returned by the Validate methods of the
+// configs. It lists every problem found: the unsupported fields first, then
+// the invalid values.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the [FieldError]s, for errors.As.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Validate reports the fields of the config that backend doesn't support,
+// the mutually exclusive fields that are both set, such as ResponseSchema and
+// ResponseJsonSchema, and the values out of range, such as a Temperature
+// above 2. It doesn't send any request, so it can check configs at startup.
+//
+// The returned error is a [*ValidationError] listing every problem, or an
+// error if backend is neither [BackendGeminiAPI] nor [BackendVertexAI].
+func (c *GenerateContentConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	ac := &apiClient{clientConfig: &ClientConfig{Backend: backend}}
+	return validateConfig(backend, c,
+		func(from, parent map[string]any) (map[string]any, error) {
+			return generateContentConfigToMldev(ac, from, parent)
+		},
+		func(from, parent map[string]any) (map[string]any, error) {
+			return generateContentConfigToVertex(ac, from, parent)
+		},
+		func(v *configValidator) {
+			v.sampling("", samplingParams{
+				temperature:      c.Temperature,
+				topP:             c.TopP,
+				topK:             c.TopK,
+				candidateCount:   c.CandidateCount,
+				maxOutputTokens:  c.MaxOutputTokens,
+				responseLogprobs: c.ResponseLogprobs,
+				logprobs:         c.Logprobs,
+				presencePenalty:  c.PresencePenalty,
+				frequencyPenalty: c.FrequencyPenalty,
+			})
+			v.exclusive("responseSchema", c.ResponseSchema != nil, "responseJsonSchema", c.ResponseJsonSchema != nil)
+			v.thinking("thinkingConfig", c.ThinkingConfig)
+			v.tools("tools", c.Tools)
+		})
+}
+
+// Validate reports the fields of the config that backend doesn't support, the
+// mutually exclusive fields that are both set and the values out of range, as
+// [GenerateContentConfig.Validate] does.
+func (c *LiveConnectConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	return validateConfig(backend, c, liveConnectConfigToMldev, liveConnectConfigToVertex, func(v *configValidator) {
+		v.sampling("", samplingParams{
+			temperature:     c.Temperature,
+			topP:            c.TopP,
+			topK:            c.TopK,
+			maxOutputTokens: c.MaxOutputTokens,
+		})
+		v.thinking("thinkingConfig", c.ThinkingConfig)
+		v.tools("tools", c.Tools)
+	})
+}
+
+// Validate reports the fields of the config that backend doesn't support, such
+// as Dest in the Gemini API.
+func (c *CreateBatchJobConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	return validateConfig(backend, c, createBatchJobConfigToMldev, createBatchJobConfigToVertex, nil)
+}
+
+// Validate reports the fields of the config that backend doesn't support, the
+// mutually exclusive fields that are both set and the values out of range, as
+// [GenerateContentConfig.Validate] does.
+func (c *CountTokensConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	return validateConfig(backend, c, countTokensConfigToMldev, countTokensConfigToVertex, func(v *configValidator) {
+		v.tools("tools", c.Tools)
+		if g := c.GenerationConfig; g != nil {
+			v.sampling("generationConfig.", samplingParams{
+				temperature:      g.Temperature,
+				topP:             g.TopP,
+				topK:             g.TopK,
+				candidateCount:   g.CandidateCount,
+				maxOutputTokens:  g.MaxOutputTokens,
+				responseLogprobs: g.ResponseLogprobs,
+				logprobs:         g.Logprobs,
+				presencePenalty:  g.PresencePenalty,
+				frequencyPenalty: g.FrequencyPenalty,
+			})
+			v.exclusive("generationConfig.responseSchema", g.ResponseSchema != nil, "generationConfig.responseJsonSchema", g.ResponseJsonSchema != nil)
+			v.thinking("generationConfig.thinkingConfig", g.ThinkingConfig)
+		}
+	})
+}
+
+// Validate reports the fields of the config that backend doesn't support and
+// the values out of range.
+func (c *EmbedContentConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	return validateConfig(backend, c, embedContentConfigToMldev, embedContentConfigToVertex, func(v *configValidator) {
+		if c.OutputDimensionality != nil && *c.OutputDimensionality <= 0 {
+			v.add("outputDimensionality", "must be positive, got %d", *c.OutputDimensionality)
+		}
+	})
+}
+
+// Validate reports the fields of the config that backend doesn't support and
+// the values out of range.
+func (c *GenerateImagesConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	return validateConfig(backend, c, generateImagesConfigToMldev, generateImagesConfigToVertex, func(v *configValidator) {
+		if c.NumberOfImages < 0 {
+			v.add("numberOfImages", "must not be negative, got %d", c.NumberOfImages)
+		}
+		inRange(v, "outputCompressionQuality", c.OutputCompressionQuality, 0, 100)
+	})
+}
+
+// Validate reports the fields of the config that backend doesn't support and
+// the values out of range.
+func (c *GenerateVideosConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	return validateConfig(backend, c, generateVideosConfigToMldev, generateVideosConfigToVertex, func(v *configValidator) {
+		if c.NumberOfVideos < 0 {
+			v.add("numberOfVideos", "must not be negative, got %d", c.NumberOfVideos)
+		}
+		if c.FPS != nil && *c.FPS <= 0 {
+			v.add("fps", "must be positive, got %d", *c.FPS)
+		}
+		if c.DurationSeconds != nil && *c.DurationSeconds <= 0 {
+			v.add("durationSeconds", "must be positive, got %d", *c.DurationSeconds)
+		}
+	})
+}
+
+// Validate reports the fields of the config that backend doesn't support, the
+// mutually exclusive fields that are both set, such as TTL and ExpireTime, and
+// the values out of range.
+func (c *CreateCachedContentConfig) Validate(backend Backend) error {
+	if c == nil {
+		return validateBackend(backend)
+	}
+	return validateConfig(backend, c, createCachedContentConfigToMldev, createCachedContentConfigToVertex, func(v *configValidator) {
+		if c.TTL < 0 {
+			v.add("ttl", "must not be negative, got %v", c.TTL)
+		}
+		v.exclusive("ttl", c.TTL != 0, "expireTime", !c.ExpireTime.IsZero())
+		v.tools("tools", c.Tools)
+	})
+}
+
+func validateBackend(backend Backend) error {
+	if backend != BackendGeminiAPI && backend != BackendVertexAI {
+		return fmt.Errorf("unsupported backend: %v", backend)
+	}
+	return nil
+}
+
+type configConverter func(fromObject map[string]any, parentObject map[string]any) (map[string]any, error)
+
+// validateConfig validates config for backend: unsupported fields with the
+// converters, and the other checks with check, which may be nil.
+func validateConfig(backend Backend, config any, mldev, vertex configConverter, check func(*configValidator)) error {
+	if err := validateBackend(backend); err != nil {
+		return err
+	}
+	v := &configValidator{}
+	converter := mldev
+	if backend == BackendVertexAI {
+		converter = vertex
+	}
+	v.unsupported(config, converter)
+	if check != nil {
+		check(v)
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+// configValidator collects the problems of a config.
+type configValidator struct {
+	errs []*FieldError
+}
+
+func (v *configValidator) add(field, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// unsupported reports the fields of config that converter rejects.
+//
+// Converters return at the first unsupported field, naming it by its key only,
+// such as "behavior parameter is not supported in Vertex AI". So the fields
+// with that key are removed, and put back one at a time to find the ones
+// rejected, and the conversion is repeated until it succeeds.
+func (v *configValidator) unsupported(config any, converter configConverter) {
+	var params map[string]any
+	if err := deepMarshal(config, &params); err != nil {
+		v.add("", "%v", err)
+		return
+	}
+	delete(params, "httpOptions")
+
+	convert := func() error {
+		// Convert a copy, since converters may modify their input.
+		var p map[string]any
+		if err := deepMarshal(params, &p); err != nil {
+			return err
+		}
+		_, err := converter(p, map[string]any{})
+		return err
+	}
+	sameError := func(a, b error) bool {
+		return a != nil && b != nil && a.Error() == b.Error()
+	}
+	for {
+		err := convert()
+		if err == nil {
+			return
+		}
+		key, message, _ := strings.Cut(err.Error(), " parameter ")
+		var fields []configField
+		configFields(params, "", key, &fields)
+		for _, f := range fields {
+			delete(f.m, key)
+		}
+		if len(fields) == 0 || sameError(convert(), err) {
+			// The error isn't about a field of the config, such as an
+			// invalid value rejected by a transformer.
+			v.add("", "%v", err)
+			return
+		}
+		for _, f := range fields {
+			f.m[key] = f.value
+			if sameError(convert(), err) {
+				v.add(joinParityPath(f.path, key), "%s", message)
+				delete(f.m, key)
+			}
+		}
+	}
+}
+
+// configField is a field of a config converted to a map.
+type configField struct {
+	// m is the map holding the field, at path in the config.
+	m     map[string]any
+	path  string
+	value any
+}
+
+// configFields appends to out the fields named key of the maps in v, in the
+// order of their paths.
+func configFields(v any, path, key string, out *[]configField) {
+	switch v := v.(type) {
+	case map[string]any:
+		if value := v[key]; value != nil {
+			*out = append(*out, configField{m: v, path: path, value: value})
+		}
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			configFields(v[k], joinParityPath(path, k), key, out)
+		}
+	case []any:
+		for i, value := range v {
+			configFields(value, fmt.Sprintf("%s[%d]", path, i), key, out)
+		}
+	}
+}
+
+// samplingParams are the sampling fields shared by [GenerateContentConfig],
+// [GenerationConfig] and [LiveConnectConfig].
+type samplingParams struct {
+	temperature      *float32
+	topP             *float32
+	topK             *float32
+	candidateCount   int32
+	maxOutputTokens  int32
+	responseLogprobs bool
+	logprobs         *int32
+	presencePenalty  *float32
+	frequencyPenalty *float32
+}
+
+func (v *configValidator) sampling(prefix string, p samplingParams) {
+	inRange(v, prefix+"temperature", p.temperature, 0, 2)
+	inRange(v, prefix+"topP", p.topP, 0, 1)
+	if p.topK != nil && *p.topK < 0 {
+		v.add(prefix+"topK", "must not be negative, got %v", *p.topK)
+	}
+	if p.candidateCount < 0 {
+		v.add(prefix+"candidateCount", "must not be negative, got %d", p.candidateCount)
+	}
+	if p.maxOutputTokens < 0 {
+		v.add(prefix+"maxOutputTokens", "must not be negative, got %d", p.maxOutputTokens)
+	}
+	if p.logprobs != nil {
+		inRange(v, prefix+"logprobs", p.logprobs, 0, 20)
+		if !p.responseLogprobs {
+			v.add(prefix+"logprobs", "requires responseLogprobs")
+		}
+	}
+	v.penalty(prefix+"presencePenalty", p.presencePenalty)
+	v.penalty(prefix+"frequencyPenalty", p.frequencyPenalty)
+}
+
+func (v *configValidator) penalty(field string, value *float32) {
+	if value != nil && (*value < -2 || *value >= 2) {
+		v.add(field, "must be in [-2, 2), got %v", *value)
+	}
+}
+
+func (v *configValidator) thinking(field string, c *ThinkingConfig) {
+	if c == nil {
+		return
+	}
+	if c.ThinkingBudget != nil && *c.ThinkingBudget < -1 {
+		v.add(field+".thinkingBudget", "must be -1 for dynamic thinking or at least 0, got %d", *c.ThinkingBudget)
+	}
+	v.exclusive(field+".thinkingBudget", c.ThinkingBudget != nil, field+".thinkingLevel", c.ThinkingLevel != "")
+}
+
+func (v *configValidator) tools(field string, tools []*Tool) {
+	for i, tool := range tools {
+		if tool == nil {
+			continue
+		}
+		for j, fd := range tool.FunctionDeclarations {
+			if fd == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s[%d].functionDeclarations[%d].", field, i, j)
+			v.exclusive(path+"parameters", fd.Parameters != nil, path+"parametersJsonSchema", fd.ParametersJsonSchema != nil)
+			v.exclusive(path+"response", fd.Response != nil, path+"responseJsonSchema", fd.ResponseJsonSchema != nil)
+		}
+	}
+}
+
+// exclusive reports field2 if both fields are set.
+func (v *configValidator) exclusive(field1 string, set1 bool, field2 string, set2 bool) {
+	if set1 && set2 {
+		v.add(field2, "cannot be set together with %s", field1)
+	}
+}
+
+func inRange[T int32 | float32](v *configValidator, field string, value *T, min, max T) {
+	if value != nil && (*value < min || *value > max) {
+		v.add(field, "must be in [%v, %v], got %v", min, max, *value)
+	}
+}
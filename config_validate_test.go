@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  interface{ Validate(Backend) error }
+		backend Backend
+		want    []string
+	}{
+		{
+			name: "valid",
+			config: &GenerateContentConfig{
+				HTTPOptions:      &HTTPOptions{Headers: http.Header{"X-Test": []string{"1"}}},
+				Temperature:      Ptr[float32](0.5),
+				TopP:             Ptr[float32](1),
+				ResponseMIMEType: "application/json",
+				ResponseSchema:   &Schema{Type: TypeString},
+				ThinkingConfig:   &ThinkingConfig{ThinkingBudget: Ptr[int32](-1)},
+				Tools:            []*Tool{{FunctionDeclarations: []*FunctionDeclaration{{Name: "f", Parameters: &Schema{Type: TypeObject}}}}},
+			},
+			backend: BackendGeminiAPI,
+		},
+		{
+			name: "every unsupported field",
+			config: &GenerateContentConfig{
+				Labels:         map[string]string{"team": "a"},
+				AudioTimestamp: true,
+				RoutingConfig:  &GenerationConfigRoutingConfig{AutoMode: &GenerationConfigRoutingConfigAutoRoutingMode{}},
+			},
+			backend: BackendGeminiAPI,
+			want: []string{
+				"routingConfig: is not supported in Gemini API",
+				"labels: is not supported in Gemini API",
+				"audioTimestamp: is not supported in Gemini API",
+			},
+		},
+		{
+			name: "nested unsupported fields",
+			config: &GenerateContentConfig{
+				Tools: []*Tool{
+					{FunctionDeclarations: []*FunctionDeclaration{{Name: "f"}, {Name: "g", Behavior: BehaviorNonBlocking}}},
+					{FunctionDeclarations: []*FunctionDeclaration{{Name: "h", Behavior: BehaviorBlocking}}},
+				},
+				EnableEnhancedCivicAnswers: Ptr(true),
+			},
+			backend: BackendVertexAI,
+			want: []string{
+				"tools[0].functionDeclarations[1].behavior: is not supported in Vertex AI",
+				"tools[1].functionDeclarations[0].behavior: is not supported in Vertex AI",
+				"enableEnhancedCivicAnswers: is not supported in Vertex AI",
+			},
+		},
+		{
+			name: "mutually exclusive and out of range",
+			config: &GenerateContentConfig{
+				Temperature:        Ptr[float32](3),
+				TopP:               Ptr[float32](-0.1),
+				CandidateCount:     -1,
+				Logprobs:           Ptr[int32](21),
+				PresencePenalty:    Ptr[float32](2),
+				ResponseSchema:     &Schema{Type: TypeString},
+				ResponseJsonSchema: map[string]any{"type": "string"},
+				ThinkingConfig:     &ThinkingConfig{ThinkingBudget: Ptr[int32](-2), ThinkingLevel: ThinkingLevelLow},
+				Tools: []*Tool{{FunctionDeclarations: []*FunctionDeclaration{{
+					Name:                 "f",
+					Parameters:           &Schema{Type: TypeObject},
+					ParametersJsonSchema: map[string]any{"type": "object"},
+				}}}},
+			},
+			backend: BackendVertexAI,
+			want: []string{
+				"temperature: must be in [0, 2], got 3",
+				"topP: must be in [0, 1], got -0.1",
+				"candidateCount: must not be negative, got -1",
+				"logprobs: must be in [0, 20], got 21",
+				"logprobs: requires responseLogprobs",
+				"presencePenalty: must be in [-2, 2), got 2",
+				"responseJsonSchema: cannot be set together with responseSchema",
+				"thinkingConfig.thinkingBudget: must be -1 for dynamic thinking or at least 0, got -2",
+				"thinkingConfig.thinkingLevel: cannot be set together with thinkingConfig.thinkingBudget",
+				"tools[0].functionDeclarations[0].parametersJsonSchema: cannot be set together with tools[0].functionDeclarations[0].parameters",
+			},
+		},
+		{
+			name:    "live",
+			config:  &LiveConnectConfig{ExplicitVADSignal: Ptr(true), TopK: Ptr[float32](-1)},
+			backend: BackendGeminiAPI,
+			want: []string{
+				"explicitVadSignal: is not supported in Gemini API",
+				"topK: must not be negative, got -1",
+			},
+		},
+		{
+			name:    "batch",
+			config:  &CreateBatchJobConfig{DisplayName: "batch", Dest: &BatchJobDestination{GCSURI: "gs://bucket/out"}},
+			backend: BackendGeminiAPI,
+			want:    []string{"dest: is not supported in Gemini API"},
+		},
+		{
+			name: "count tokens",
+			config: &CountTokensConfig{
+				SystemInstruction: NewContentFromText("x", RoleUser),
+				Tools:             []*Tool{{FunctionDeclarations: []*FunctionDeclaration{{Name: "f"}}}},
+				GenerationConfig:  &GenerationConfig{Temperature: Ptr[float32](-1)},
+			},
+			backend: BackendGeminiAPI,
+			want: []string{
+				"systemInstruction: is not supported in Gemini API",
+				"tools: is not supported in Gemini API",
+				"generationConfig: is not supported in Gemini API",
+				"generationConfig.temperature: must be in [0, 2], got -1",
+			},
+		},
+		{
+			name:    "embed",
+			config:  &EmbedContentConfig{AutoTruncate: true, OutputDimensionality: Ptr[int32](0)},
+			backend: BackendGeminiAPI,
+			want: []string{
+				"autoTruncate: is not supported in Gemini API",
+				"outputDimensionality: must be positive, got 0",
+			},
+		},
+		{
+			name:    "images",
+			config:  &GenerateImagesConfig{NumberOfImages: 1, OutputCompressionQuality: Ptr[int32](101)},
+			backend: BackendVertexAI,
+			want:    []string{"outputCompressionQuality: must be in [0, 100], got 101"},
+		},
+		{
+			name:    "videos",
+			config:  &GenerateVideosConfig{PubsubTopic: "topic", FPS: Ptr[int32](0)},
+			backend: BackendGeminiAPI,
+			want: []string{
+				"fps: is not supported in Gemini API",
+				"pubsubTopic: is not supported in Gemini API",
+				"fps: must be positive, got 0",
+			},
+		},
+		{
+			name:    "caches",
+			config:  &CreateCachedContentConfig{TTL: time.Hour, ExpireTime: time.Now(), KmsKeyName: "key"},
+			backend: BackendGeminiAPI,
+			want: []string{
+				"kmsKeyName: is not supported in Gemini API",
+				"expireTime: cannot be set together with ttl",
+			},
+		},
+		{
+			name:    "nil config",
+			config:  (*GenerateContentConfig)(nil),
+			backend: BackendVertexAI,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate(tt.backend)
+			var got []string
+			if err != nil {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("Validate() returned %T, want *ValidationError: %v", err, err)
+				}
+				for _, ferr := range verr.Errors {
+					got = append(got, ferr.Error())
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateConfigErrors(t *testing.T) {
+	config := &GenerateContentConfig{Labels: map[string]string{"team": "a"}, Temperature: Ptr[float32](5)}
+	err := config.Validate(BackendGeminiAPI)
+	want := "labels: is not supported in Gemini API; temperature: must be in [0, 2], got 5"
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate() = %v, want %q", err, want)
+	}
+	var ferr *FieldError
+	if !errors.As(err, &ferr) || ferr.Field != "labels" {
+		t.Errorf("errors.As(*FieldError) = %v, want the labels error", ferr)
+	}
+	if err := config.Validate(BackendUnspecified); err == nil {
+		t.Error("Validate(BackendUnspecified) = nil, want error")
+	}
+	// The config is left as is.
+	if config.Labels == nil {
+		t.Error("Validate() modified the config")
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package embeddings provides helpers around [genai.Models.EmbedContent]:
+// embedding input lists of any length in concurrent, size-limited requests,
+// vector math, and a small in-memory vector index.
+//
+// The index does a linear scan over all vectors. It is meant for tests and
+// small applications, not as a replacement for a vector database.
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultBatchSize is the number of contents sent per request by default.
+	// It is the limit of the Gemini Developer API.
+	DefaultBatchSize = 100
+	// DefaultConcurrency is the number of requests in flight by default.
+	DefaultConcurrency = 4
+)
+
+// ContentEmbedder embeds contents. [genai.Models] implements it.
+type ContentEmbedder interface {
+	EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error)
+}
+
+// Config holds optional parameters for [Embed] and [EmbedTexts].
+type Config struct {
+	// Optional. Applied to every request, for example to set TaskType or
+	// OutputDimensionality.
+	EmbedConfig *genai.EmbedContentConfig
+	// Optional. Maximum number of contents per request. Defaults to
+	// [DefaultBatchSize]. Some Vertex AI models accept a single content per
+	// request.
+	BatchSize int
+	// Optional. Maximum number of requests in flight. Defaults to
+	// [DefaultConcurrency].
+	Concurrency int
+	// Optional. If true, the returned vectors are scaled to unit length. Models
+	// only normalize their output at full dimensionality, so this is needed to
+	// use dot products as cosine similarities with a reduced
+	// OutputDimensionality.
+	Normalize bool
+}
+
+// Embed embeds contents, splitting them into requests of at most
+// config.BatchSize contents of which at most config.Concurrency are in flight.
+// The vectors are returned in the order of contents. If any request fails, the
+// first error is returned and the remaining requests are cancelled.
+func Embed(ctx context.Context, e ContentEmbedder, model string, contents []*genai.Content, config *Config) ([][]float32, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	vectors := make([][]float32, len(contents))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for start := 0; start < len(contents); start += batchSize {
+		end := min(start+batchSize, len(contents))
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(ctx.Err())
+		}
+		if ctx.Err() != nil {
+			// The parent context may be done even though a slot was free.
+			fail(ctx.Err())
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := e.EmbedContent(ctx, model, contents[start:end], config.EmbedConfig)
+			if err != nil {
+				fail(fmt.Errorf("embedding contents %d to %d: %w", start, end-1, err))
+				return
+			}
+			if len(resp.Embeddings) != end-start {
+				fail(fmt.Errorf("embedding contents %d to %d: got %d embeddings, want %d", start, end-1, len(resp.Embeddings), end-start))
+				return
+			}
+			for i, emb := range resp.Embeddings {
+				if emb == nil {
+					fail(fmt.Errorf("embedding content %d: no embedding returned", start+i))
+					return
+				}
+				v := emb.Values
+				if config.Normalize {
+					v = Normalize(v)
+				}
+				vectors[start+i] = v
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return vectors, nil
+}
+
+// EmbedTexts embeds texts with [Embed], each text being a single content.
+func EmbedTexts(ctx context.Context, e ContentEmbedder, model string, texts []string, config *Config) ([][]float32, error) {
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+	return Embed(ctx, e, model, contents, config)
+}
+
+// Norm returns the Euclidean length of v.
+func Norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// Normalize returns a copy of v scaled to unit length. A zero vector is
+// returned unchanged.
+func Normalize(v []float32) []float32 {
+	out := make([]float32, len(v))
+	n := Norm(v)
+	if n == 0 {
+		copy(out, v)
+		return out
+	}
+	for i, x := range v {
+		out[i] = float32(float64(x) / n)
+	}
+	return out
+}
+
+// Dot returns the dot product of a and b. It panics if their lengths differ.
+func Dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		panic(fmt.Sprintf("embeddings: vectors have different lengths %d and %d", len(a), len(b)))
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0 if
+// either is a zero vector. It panics if their lengths differ.
+func CosineSimilarity(a, b []float32) float64 {
+	na, nb := Norm(a), Norm(b)
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return Dot(a, b) / (na * nb)
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+// fakeEmbedder embeds the text "n" as the vector [n, 1].
+type fakeEmbedder struct {
+	mu            sync.Mutex
+	inFlight      int
+	maxInFlight   int
+	batchSizes    []int
+	taskTypes     []string
+	failOnRequest int
+}
+
+func (f *fakeEmbedder) EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.batchSizes = append(f.batchSizes, len(contents))
+	if config != nil {
+		f.taskTypes = append(f.taskTypes, config.TaskType)
+	}
+	n := len(f.batchSizes)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+	time.Sleep(time.Millisecond)
+	if n == f.failOnRequest {
+		return nil, errors.New("quota exceeded")
+	}
+	resp := &genai.EmbedContentResponse{}
+	for _, c := range contents {
+		v, err := strconv.Atoi(c.Parts[0].Text)
+		if err != nil {
+			return nil, err
+		}
+		resp.Embeddings = append(resp.Embeddings, &genai.ContentEmbedding{Values: []float32{float32(v), 1}})
+	}
+	return resp, nil
+}
+
+func TestEmbedTexts(t *testing.T) {
+	ctx := context.Background()
+	texts := make([]string, 25)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+	f := &fakeEmbedder{}
+	got, err := EmbedTexts(ctx, f, "gemini-embedding-001", texts, &Config{
+		BatchSize:   10,
+		Concurrency: 2,
+		EmbedConfig: &genai.EmbedContentConfig{TaskType: "RETRIEVAL_DOCUMENT"},
+	})
+	if err != nil {
+		t.Fatalf("EmbedTexts() unexpected error: %v", err)
+	}
+	for i, v := range got {
+		if diff := cmp.Diff([]float32{float32(i), 1}, v); diff != "" {
+			t.Errorf("EmbedTexts()[%d] mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+	if f.maxInFlight > 2 {
+		t.Errorf("max requests in flight = %d, want at most 2", f.maxInFlight)
+	}
+	if diff := cmp.Diff([]int{5, 10, 10}, slices.Sorted(slices.Values(f.batchSizes))); diff != "" {
+		t.Errorf("batch sizes mismatch (-want +got):\n%s", diff)
+	}
+	for _, tt := range f.taskTypes {
+		if tt != "RETRIEVAL_DOCUMENT" {
+			t.Errorf("TaskType = %q, want RETRIEVAL_DOCUMENT", tt)
+		}
+	}
+}
+
+func TestEmbedError(t *testing.T) {
+	f := &fakeEmbedder{failOnRequest: 2}
+	_, err := EmbedTexts(context.Background(), f, "m", []string{"1", "2", "3"}, &Config{BatchSize: 1, Concurrency: 1})
+	if err == nil {
+		t.Fatalf("EmbedTexts() want error, got nil")
+	}
+}
+
+func TestEmbedCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Embed selects between the context and a free slot at random, so call
+	// it several times.
+	for range 20 {
+		got, err := EmbedTexts(ctx, &fakeEmbedder{}, "m", []string{"1", "2", "3"}, &Config{BatchSize: 1})
+		if !errors.Is(err, context.Canceled) || got != nil {
+			t.Fatalf("EmbedTexts() = %v, %v, want nil, context.Canceled", got, err)
+		}
+	}
+}
+
+func TestEmbedNormalize(t *testing.T) {
+	got, err := EmbedTexts(context.Background(), &fakeEmbedder{}, "m", []string{"0"}, &Config{Normalize: true})
+	if err != nil {
+		t.Fatalf("EmbedTexts() unexpected error: %v", err)
+	}
+	if n := Norm(got[0]); math.Abs(n-1) > 1e-6 {
+		t.Errorf("Norm() = %v, want 1", n)
+	}
+}
+
+func TestVectorMath(t *testing.T) {
+	if got := Norm([]float32{3, 4}); got != 5 {
+		t.Errorf("Norm() = %v, want 5", got)
+	}
+	if diff := cmp.Diff([]float32{0.6, 0.8}, Normalize([]float32{3, 4})); diff != "" {
+		t.Errorf("Normalize() mismatch (-want +got):\n%s", diff)
+	}
+	if got := CosineSimilarity([]float32{1, 0}, []float32{0, 2}); got != 0 {
+		t.Errorf("CosineSimilarity() of orthogonal vectors = %v, want 0", got)
+	}
+	if got := CosineSimilarity([]float32{1, 1}, []float32{2, 2}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("CosineSimilarity() of parallel vectors = %v, want 1", got)
+	}
+	if got := CosineSimilarity([]float32{0, 0}, []float32{1, 1}); got != 0 {
+		t.Errorf("CosineSimilarity() with a zero vector = %v, want 0", got)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	var x Index
+	for _, e := range []Entry{
+		{ID: "east", Vector: []float32{1, 0}},
+		{ID: "north", Vector: []float32{0, 1}, Metadata: map[string]string{"text": "up"}},
+		{ID: "northeast", Vector: []float32{1, 1}},
+	} {
+		if err := x.Add(e); err != nil {
+			t.Fatalf("Add() unexpected error: %v", err)
+		}
+	}
+	if err := x.Add(Entry{ID: "bad", Vector: []float32{1, 2, 3}}); err == nil {
+		t.Errorf("Add() with a different dimension: want error, got nil")
+	}
+
+	matches, err := x.Search([]float32{0.1, 1}, 2)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	var ids []string
+	for _, m := range matches {
+		ids = append(ids, m.ID)
+	}
+	if diff := cmp.Diff([]string{"north", "northeast"}, ids); diff != "" {
+		t.Errorf("Search() mismatch (-want +got):\n%s", diff)
+	}
+	if matches[0].Metadata["text"] != "up" {
+		t.Errorf("Search() metadata = %v, want text=up", matches[0].Metadata)
+	}
+
+	if !x.Delete("north") || x.Delete("north") {
+		t.Errorf("Delete() did not report the entry existence correctly")
+	}
+	if _, ok := x.Get("northeast"); !ok || x.Len() != 2 {
+		t.Errorf("index after Delete() has %d entries, want 2 with northeast", x.Len())
+	}
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := x.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile() unexpected error: %v", err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() unexpected error: %v", err)
+	}
+	want, _ := x.Search([]float32{1, 0}, -1)
+	got, err := loaded.Search([]float32{1, 0}, -1)
+	if err != nil {
+		t.Fatalf("Search() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("loaded index Search() mismatch (-want +got):\n%s", diff)
+	}
+}
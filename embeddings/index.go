@@ -0,0 +1,217 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embeddings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Entry is a vector stored in an [Index].
+type Entry struct {
+	// ID identifies the entry in the index.
+	ID string `json:"id"`
+	// Vector is the embedding.
+	Vector []float32 `json:"vector"`
+	// Metadata is free-form data returned with search results, such as the
+	// embedded text or its source.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Match is a search result of [Index.Search].
+type Match struct {
+	Entry
+	// Score is the cosine similarity between the query and the entry.
+	Score float64
+}
+
+// Index is an in-memory vector index searched by cosine similarity. The zero
+// value is an empty index ready to use. It is safe for concurrent use.
+type Index struct {
+	mu      sync.RWMutex
+	entries []*indexEntry
+	byID    map[string]int
+}
+
+type indexEntry struct {
+	Entry
+	norm float64
+}
+
+// Len returns the number of entries in the index.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Dimension returns the length of the vectors in the index, or 0 if it is
+// empty.
+func (x *Index) Dimension() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(x.entries) == 0 {
+		return 0
+	}
+	return len(x.entries[0].Vector)
+}
+
+// Add stores an entry, replacing any entry with the same ID. All vectors of an
+// index must have the same length.
+func (x *Index) Add(e Entry) error {
+	if e.ID == "" {
+		return fmt.Errorf("entry ID is empty")
+	}
+	if len(e.Vector) == 0 {
+		return fmt.Errorf("entry %q has an empty vector", e.ID)
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.entries) > 0 && len(e.Vector) != len(x.entries[0].Vector) {
+		return fmt.Errorf("entry %q has dimension %d, want %d", e.ID, len(e.Vector), len(x.entries[0].Vector))
+	}
+	if x.byID == nil {
+		x.byID = make(map[string]int)
+	}
+	ie := &indexEntry{Entry: e, norm: Norm(e.Vector)}
+	if i, ok := x.byID[e.ID]; ok {
+		x.entries[i] = ie
+		return nil
+	}
+	x.byID[e.ID] = len(x.entries)
+	x.entries = append(x.entries, ie)
+	return nil
+}
+
+// Get returns the entry with the given ID.
+func (x *Index) Get(id string) (Entry, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	i, ok := x.byID[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return x.entries[i].Entry, true
+}
+
+// Delete removes the entry with the given ID and reports whether it existed.
+func (x *Index) Delete(id string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	i, ok := x.byID[id]
+	if !ok {
+		return false
+	}
+	last := len(x.entries) - 1
+	x.entries[i] = x.entries[last]
+	x.byID[x.entries[i].ID] = i
+	x.entries = x.entries[:last]
+	delete(x.byID, id)
+	return true
+}
+
+// Search returns the k entries most similar to query, most similar first.
+func (x *Index) Search(query []float32, k int) ([]Match, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(x.entries) > 0 && len(query) != len(x.entries[0].Vector) {
+		return nil, fmt.Errorf("query has dimension %d, want %d", len(query), len(x.entries[0].Vector))
+	}
+	qn := Norm(query)
+	matches := make([]Match, 0, len(x.entries))
+	for _, e := range x.entries {
+		var score float64
+		if qn != 0 && e.norm != 0 {
+			score = Dot(query, e.Vector) / (qn * e.norm)
+		}
+		matches = append(matches, Match{Entry: e.Entry, Score: score})
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if k >= 0 && k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+type indexFile struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Save writes the index as JSON to w.
+func (x *Index) Save(w io.Writer) error {
+	x.mu.RLock()
+	f := indexFile{Entries: make([]Entry, len(x.entries))}
+	for i, e := range x.entries {
+		f.Entries[i] = e.Entry
+	}
+	x.mu.RUnlock()
+	return json.NewEncoder(w).Encode(f)
+}
+
+// Load reads an index written by [Index.Save].
+func Load(r io.Reader) (*Index, error) {
+	var f indexFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decoding index: %w", err)
+	}
+	x := &Index{}
+	for _, e := range f.Entries {
+		if err := x.Add(e); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// SaveFile writes the index to the file at path, replacing it atomically.
+func (x *Index) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := x.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile reads an index written by [Index.SaveFile].
+func LoadFile(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
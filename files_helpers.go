@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WaitUntilActiveConfig configures how [Files.WaitUntilActive] polls a file.
+type WaitUntilActiveConfig struct {
+	// Optional. Used to override HTTP request options of each Files.Get call.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. Delay before the first poll. Defaults to 1 second.
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+	// Optional. Upper bound for the delay between polls. Defaults to 30 seconds.
+	MaxPollInterval time.Duration `json:"maxPollInterval,omitempty"`
+	// Optional. Factor applied to the delay after every poll. Defaults to 1.5.
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Optional. Maximum total time to wait. If zero, only the context deadline
+	// bounds the wait.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// FileProcessingError is returned when a file ends up in the FAILED state.
+type FileProcessingError struct {
+	// Name is the resource name of the file, for example "files/abc-123".
+	Name string
+	// Status is the error reported by the service in [File.Error]. It may be nil.
+	Status *FileStatus
+}
+
+// Error returns a string representation of the FileProcessingError.
+func (e *FileProcessingError) Error() string {
+	if e.Status == nil {
+		return fmt.Sprintf("file %s failed processing", e.Name)
+	}
+	code := int32(0)
+	if e.Status.Code != nil {
+		code = *e.Status.Code
+	}
+	return fmt.Sprintf("file %s failed processing: code %d, message: %s", e.Name, code, e.Status.Message)
+}
+
+// WaitUntilActive polls [Files.Get] until the named file leaves the PROCESSING
+// state. It returns the file once it is ACTIVE, or a [*FileProcessingError]
+// when it is FAILED. The wait is bounded by the context and by
+// config.Timeout, whichever expires first.
+//
+// Files such as videos and large PDFs must be ACTIVE before they can be used in
+// [Models.GenerateContent].
+func (m Files) WaitUntilActive(ctx context.Context, name string, config *WaitUntilActiveConfig) (*File, error) {
+	if m.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, fmt.Errorf("method WaitUntilActive is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
+	}
+	if config == nil {
+		config = &WaitUntilActiveConfig{}
+	}
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	backoff := newPollBackoff(config.PollInterval, config.MaxPollInterval, config.Multiplier)
+	for {
+		file, err := m.Get(ctx, name, &GetFileConfig{HTTPOptions: config.HTTPOptions})
+		if err != nil {
+			return nil, err
+		}
+		if done, err := fileProcessingDone(file); done {
+			return file, err
+		}
+		if err := sleepContext(ctx, backoff.next()); err != nil {
+			return nil, fmt.Errorf("waiting for file %s to become active: %w", name, err)
+		}
+	}
+}
+
+// UploadAndWait uploads the contents of r as [Files.Upload] does, then waits
+// for the file to finish processing as [Files.WaitUntilActive] does, polling as
+// described by wait.
+//
+// UploadAndWait takes the place of a WaitUntilActive option on
+// [UploadFileConfig]: that type is generated from the API definition, so
+// waiting is configured here instead.
+func (m Files) UploadAndWait(ctx context.Context, r io.Reader, config *UploadFileConfig, wait *WaitUntilActiveConfig) (*File, error) {
+	file, err := m.Upload(ctx, r, config)
+	if err != nil {
+		return nil, err
+	}
+	if done, err := fileProcessingDone(file); done {
+		return file, err
+	}
+	return m.WaitUntilActive(ctx, file.Name, wait)
+}
+
+// fileProcessingDone reports whether the file has reached a terminal state and
+// returns the processing error if that state is FAILED.
+func fileProcessingDone(file *File) (bool, error) {
+	switch file.State {
+	case FileStateActive:
+		return true, nil
+	case FileStateFailed:
+		return true, &FileProcessingError{Name: file.Name, Status: file.Error}
+	default:
+		return false, nil
+	}
+}
@@ -831,3 +831,159 @@ type errorReader struct{}
 func (r *errorReader) Read(p []byte) (n int, err error) {
 	return 0, fmt.Errorf("intentional read error")
 }
+
+func TestFilesWaitUntilActive(t *testing.T) {
+	ctx := context.Background()
+	fastPoll := &WaitUntilActiveConfig{PollInterval: time.Millisecond, MaxPollInterval: 5 * time.Millisecond}
+	tests := []struct {
+		name       string
+		states     []*File
+		config     *WaitUntilActiveConfig
+		wantFile   *File
+		wantErrMsg string
+		wantGets   int
+	}{
+		{
+			name: "AlreadyActive",
+			states: []*File{
+				{Name: "files/abc", State: FileStateActive},
+			},
+			config:   fastPoll,
+			wantFile: &File{Name: "files/abc", State: FileStateActive},
+			wantGets: 1,
+		},
+		{
+			name: "ProcessingThenActive",
+			states: []*File{
+				{Name: "files/abc", State: FileStateProcessing},
+				{Name: "files/abc", State: FileStateProcessing},
+				{Name: "files/abc", State: FileStateActive},
+			},
+			config:   fastPoll,
+			wantFile: &File{Name: "files/abc", State: FileStateActive},
+			wantGets: 3,
+		},
+		{
+			name: "Failed",
+			states: []*File{
+				{Name: "files/abc", State: FileStateProcessing},
+				{Name: "files/abc", State: FileStateFailed, Error: &FileStatus{Code: Ptr[int32](3), Message: "unsupported codec"}},
+			},
+			config:     fastPoll,
+			wantFile:   &File{Name: "files/abc", State: FileStateFailed, Error: &FileStatus{Code: Ptr[int32](3), Message: "unsupported codec"}},
+			wantErrMsg: "file files/abc failed processing: code 3, message: unsupported codec",
+			wantGets:   2,
+		},
+		{
+			name: "Timeout",
+			states: []*File{
+				{Name: "files/abc", State: FileStateProcessing},
+			},
+			config:     &WaitUntilActiveConfig{PollInterval: 50 * time.Millisecond, Timeout: 10 * time.Millisecond},
+			wantErrMsg: "waiting for file files/abc to become active: context deadline exceeded",
+			wantGets:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			gets := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/v1beta/files/abc" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					http.NotFound(w, r)
+					return
+				}
+				mu.Lock()
+				state := tt.states[min(gets, len(tt.states)-1)]
+				gets++
+				mu.Unlock()
+				if err := json.NewEncoder(w).Encode(state); err != nil {
+					t.Errorf("Failed to write response: %v", err)
+				}
+			}))
+			defer ts.Close()
+
+			client, err := NewClient(ctx, &ClientConfig{
+				Backend:     BackendGeminiAPI,
+				APIKey:      "test-api-key",
+				HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+				HTTPClient:  ts.Client(),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			got, err := client.Files.WaitUntilActive(ctx, "files/abc", tt.config)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Fatalf("WaitUntilActive() error = %v, want %q", err, tt.wantErrMsg)
+				}
+			} else if err != nil {
+				t.Fatalf("WaitUntilActive() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantFile, got); diff != "" {
+				t.Errorf("WaitUntilActive() file mismatch (-want +got):\n%s", diff)
+			}
+			if gets != tt.wantGets {
+				t.Errorf("WaitUntilActive() made %d Get calls, want %d", gets, tt.wantGets)
+			}
+		})
+	}
+}
+
+func TestFilesUploadAndWait(t *testing.T) {
+	ctx := context.Background()
+	mockServer := NewMockUploadServer(t)
+	gets := 0
+	mockServer.uploadHandler = func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		mockServer.handleUpload(rec, r)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		// Report the finalized file as still processing.
+		body := strings.Replace(rec.Body.String(), `"state":"ACTIVE"`, `"state":"PROCESSING"`, 1)
+		_, _ = w.Write([]byte(body))
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/v1beta/files/video" {
+			gets++
+			state := FileStateProcessing
+			if gets > 1 {
+				state = FileStateActive
+			}
+			_ = json.NewEncoder(w).Encode(&File{Name: "files/video", State: state})
+			return
+		}
+		mockServer.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	mockServer.baseURL = ts.URL
+
+	client, err := NewClient(ctx, &ClientConfig{
+		Backend:     BackendGeminiAPI,
+		APIKey:      "test-api-key",
+		HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+		HTTPClient:  ts.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	got, err := client.Files.UploadAndWait(ctx, strings.NewReader("video bytes"), &UploadFileConfig{
+		Name:     "video",
+		MIMEType: "video/mp4",
+	}, &WaitUntilActiveConfig{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("UploadAndWait() unexpected error: %v", err)
+	}
+	if got.State != FileStateActive {
+		t.Errorf("UploadAndWait() state = %s, want %s", got.State, FileStateActive)
+	}
+	if gets != 2 {
+		t.Errorf("UploadAndWait() made %d Get calls, want 2", gets)
+	}
+}
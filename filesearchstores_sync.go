@@ -0,0 +1,337 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// SyncPathMetadataKey is the custom metadata key holding the path, relative
+	// to the synced directory, of a document uploaded by
+	// [FileSearchStores.SyncDirectory].
+	SyncPathMetadataKey = "sync_path"
+	// SyncHashMetadataKey is the custom metadata key holding the SHA-256 of the
+	// contents of a document uploaded by [FileSearchStores.SyncDirectory].
+	SyncHashMetadataKey = "sync_sha256"
+
+	defaultSyncConcurrency = 4
+)
+
+// SyncDirectoryConfig holds optional parameters for
+// [FileSearchStores.SyncDirectory].
+type SyncDirectoryConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. If true, the changes are computed and reported but not applied.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Optional. If true, documents whose file no longer exists are kept.
+	KeepMissing bool `json:"keepMissing,omitempty"`
+	// Optional. Selects the files to sync, given their slash-separated path
+	// relative to the directory. All regular files are synced if nil.
+	Include func(path string) bool `json:"-"`
+	// Optional. Custom metadata added to every uploaded document.
+	CustomMetadata []*CustomMetadata `json:"customMetadata,omitempty"`
+	// Optional. Chunking configuration of every uploaded document.
+	ChunkingConfig *ChunkingConfig `json:"chunkingConfig,omitempty"`
+	// Optional. Maximum number of files uploaded at once. Defaults to 4.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Optional. Initial delay between two polls of an upload operation.
+	// Defaults to 1 second.
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+	// Optional. Upper bound of the delay between two polls of an upload
+	// operation. Defaults to 30 seconds.
+	MaxPollInterval time.Duration `json:"maxPollInterval,omitempty"`
+}
+
+// SyncError is a failure to sync a single file.
+type SyncError struct {
+	// Path is the slash-separated path of the file relative to the directory.
+	Path string
+	// Err is the error.
+	Err error
+}
+
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("syncing %s: %v", e.Path, e.Err)
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// SyncDirectoryReport lists the changes made by [FileSearchStores.SyncDirectory].
+// Paths are slash-separated and relative to the synced directory.
+type SyncDirectoryReport struct {
+	// Added are the files uploaded for the first time.
+	Added []string
+	// Updated are the files whose contents changed and were uploaded again.
+	Updated []string
+	// Deleted are the files whose documents were deleted because the file no
+	// longer exists.
+	Deleted []string
+	// Unchanged are the files whose document is up to date.
+	Unchanged []string
+	// Errors are the files that could not be synced.
+	Errors []*SyncError
+}
+
+type syncDocument struct {
+	doc  *Document
+	hash string
+}
+
+// SyncDirectory mirrors the regular files of dir, recursively, into a file
+// search store.
+//
+// Documents are matched to files by the [SyncPathMetadataKey] custom metadata
+// set when they are uploaded, and are up to date if their
+// [SyncHashMetadataKey] matches the file contents. New files are uploaded,
+// changed files are uploaded again and their previous document deleted once
+// the new one is ready, and documents of files that no longer exist are
+// deleted. Documents not uploaded by SyncDirectory are left untouched.
+//
+// All uploads are awaited. The report lists the changes; if some files could
+// not be synced they are listed in the report and a non-nil error joining their
+// errors is returned as well.
+func (m FileSearchStores) SyncDirectory(ctx context.Context, dir string, fileSearchStoreName string, config *SyncDirectoryConfig) (*SyncDirectoryReport, error) {
+	if m.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, fmt.Errorf("method SyncDirectory is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
+	}
+	if config == nil {
+		config = &SyncDirectoryConfig{}
+	}
+
+	local, err := hashDirectory(dir, config.Include)
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string][]syncDocument)
+	for doc, err := range m.Documents.All(ctx, fileSearchStoreName) {
+		if err != nil {
+			return nil, fmt.Errorf("listing documents of %s: %w", fileSearchStoreName, err)
+		}
+		path, hash := syncMetadata(doc)
+		if path != "" {
+			remote[path] = append(remote[path], syncDocument{doc: doc, hash: hash})
+		}
+	}
+
+	report := &SyncDirectoryReport{}
+	var uploads, deletes []string
+	// stale are the documents to delete once the upload of their path succeeds.
+	stale := make(map[string][]*Document)
+	for path, hash := range local {
+		docs := remote[path]
+		i := slices.IndexFunc(docs, func(d syncDocument) bool { return d.hash == hash })
+		switch {
+		case len(docs) == 0:
+			report.Added = append(report.Added, path)
+			uploads = append(uploads, path)
+		case i < 0:
+			report.Updated = append(report.Updated, path)
+			uploads = append(uploads, path)
+			for _, d := range docs {
+				stale[path] = append(stale[path], d.doc)
+			}
+		default:
+			report.Unchanged = append(report.Unchanged, path)
+			// Duplicates left behind by an interrupted sync.
+			for j, d := range docs {
+				if j != i {
+					stale[path] = append(stale[path], d.doc)
+				}
+			}
+			if len(stale[path]) > 0 {
+				deletes = append(deletes, path)
+			}
+		}
+	}
+	for path, docs := range remote {
+		if _, ok := local[path]; ok || config.KeepMissing {
+			continue
+		}
+		report.Deleted = append(report.Deleted, path)
+		deletes = append(deletes, path)
+		for _, d := range docs {
+			stale[path] = append(stale[path], d.doc)
+		}
+	}
+	for _, s := range [][]string{report.Added, report.Updated, report.Deleted, report.Unchanged} {
+		sort.Strings(s)
+	}
+	if config.DryRun {
+		return report, nil
+	}
+
+	var mu sync.Mutex
+	fail := func(path string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		report.Errors = append(report.Errors, &SyncError{Path: path, Err: err})
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultSyncConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, path := range uploads {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := m.syncUpload(ctx, dir, path, local[path], fileSearchStoreName, config); err != nil {
+				fail(path, err)
+				return
+			}
+			if err := m.deleteSyncDocuments(ctx, stale[path], config); err != nil {
+				fail(path, err)
+			}
+		}()
+	}
+	for _, path := range deletes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := m.deleteSyncDocuments(ctx, stale[path], config); err != nil {
+				fail(path, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(report.Errors) == 0 {
+		return report, nil
+	}
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Path < report.Errors[j].Path })
+	errs := make([]error, len(report.Errors))
+	for i, e := range report.Errors {
+		errs[i] = e
+	}
+	return report, errors.Join(errs...)
+}
+
+// hashDirectory returns the SHA-256 of the selected regular files of dir, keyed
+// by slash-separated relative path.
+func hashDirectory(dir string, include func(string) bool) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if include != nil && !include(rel) {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		hashes[rel] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading directory %s: %w", dir, err)
+	}
+	return hashes, nil
+}
+
+func syncMetadata(doc *Document) (path, hash string) {
+	for _, m := range doc.CustomMetadata {
+		if m == nil {
+			continue
+		}
+		switch m.Key {
+		case SyncPathMetadataKey:
+			path = m.StringValue
+		case SyncHashMetadataKey:
+			hash = m.StringValue
+		}
+	}
+	return path, hash
+}
+
+func (m FileSearchStores) syncUpload(ctx context.Context, dir, path, hash, fileSearchStoreName string, config *SyncDirectoryConfig) error {
+	uploadConfig := &UploadToFileSearchStoreConfig{
+		DisplayName:    path,
+		ChunkingConfig: config.ChunkingConfig,
+		CustomMetadata: append(slices.Clone(config.CustomMetadata),
+			&CustomMetadata{Key: SyncPathMetadataKey, StringValue: path},
+			&CustomMetadata{Key: SyncHashMetadataKey, StringValue: hash},
+		),
+	}
+	uploadConfig.HTTPOptions = cloneHTTPOptions(config.HTTPOptions)
+	op, err := m.UploadToFileSearchStoreFromPath(ctx, filepath.Join(dir, filepath.FromSlash(path)), fileSearchStoreName, uploadConfig)
+	if err != nil {
+		return err
+	}
+	operations := Operations{apiClient: m.apiClient}
+	backoff := newPollBackoff(config.PollInterval, config.MaxPollInterval, 0)
+	for !op.Done {
+		if err := sleepContext(ctx, backoff.next()); err != nil {
+			return err
+		}
+		var opConfig *GetOperationConfig
+		if config.HTTPOptions != nil {
+			opConfig = &GetOperationConfig{HTTPOptions: cloneHTTPOptions(config.HTTPOptions)}
+		}
+		op, err = operations.GetUploadToFileSearchStoreOperation(ctx, op, opConfig)
+		if err != nil {
+			return err
+		}
+	}
+	if op.Error != nil {
+		return fmt.Errorf("upload operation %s failed: %v", op.Name, op.Error["message"])
+	}
+	return nil
+}
+
+func (m FileSearchStores) deleteSyncDocuments(ctx context.Context, docs []*Document, config *SyncDirectoryConfig) error {
+	force := true
+	for _, doc := range docs {
+		if err := m.Documents.Delete(ctx, doc.Name, &DeleteDocumentConfig{HTTPOptions: cloneHTTPOptions(config.HTTPOptions), Force: &force}); err != nil {
+			return fmt.Errorf("deleting document %s: %w", doc.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// fakeDocumentsServer serves the document and upload endpoints of a single file
+// search store. Uploads complete on the first poll of their operation.
+type fakeDocumentsServer struct {
+	t     *testing.T
+	store string
+	url   string
+
+	mu        sync.Mutex
+	next      int
+	docs      map[string]map[string]any
+	sessions  map[string]map[string]any
+	pending   map[string]map[string]any
+	failPaths map[string]bool
+}
+
+func newFakeDocumentsServer(t *testing.T, store string) (*fakeDocumentsServer, *httptest.Server) {
+	f := &fakeDocumentsServer{
+		t:         t,
+		store:     store,
+		docs:      make(map[string]map[string]any),
+		sessions:  make(map[string]map[string]any),
+		pending:   make(map[string]map[string]any),
+		failPaths: make(map[string]bool),
+	}
+	ts := httptest.NewServer(f)
+	f.url = ts.URL
+	return f, ts
+}
+
+// add stores a document uploaded by SyncDirectory for path with contents.
+func (f *fakeDocumentsServer) add(path, contents string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sum := sha256.Sum256([]byte(contents))
+	return f.addLocked(map[string]any{
+		"displayName": path,
+		"customMetadata": []any{
+			map[string]any{"key": SyncPathMetadataKey, "stringValue": path},
+			map[string]any{"key": SyncHashMetadataKey, "stringValue": hex.EncodeToString(sum[:])},
+		},
+	})
+}
+
+func (f *fakeDocumentsServer) addLocked(doc map[string]any) string {
+	f.next++
+	name := fmt.Sprintf("%s/documents/doc%d", f.store, f.next)
+	doc["name"] = name
+	f.docs[name] = doc
+	return name
+}
+
+// paths returns the sync paths of the stored documents, sorted.
+func (f *fakeDocumentsServer) paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var paths []string
+	for _, doc := range f.docs {
+		path, _ := syncMetadata(documentFromMap(f.t, doc))
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func documentFromMap(t *testing.T, m map[string]any) *Document {
+	t.Helper()
+	doc := new(Document)
+	if err := mapToStruct(m, doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func (f *fakeDocumentsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodPost && path == "/upload/v1beta/"+f.store+":uploadToFileSearchStore":
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Errorf("decoding upload start body: %v", err)
+		}
+		f.next++
+		session := fmt.Sprintf("/session%d", f.next)
+		f.sessions[session] = body
+		w.Header().Set("X-Goog-Upload-Url", f.url+session)
+		w.Write([]byte("{}"))
+	case r.Method == http.MethodPost && f.sessions[path] != nil:
+		io.Copy(io.Discard, r.Body)
+		body := f.sessions[path]
+		delete(f.sessions, path)
+		f.next++
+		op := fmt.Sprintf("%s/upload/operations/op%d", f.store, f.next)
+		f.pending[op] = body
+		w.Header().Set("X-Goog-Upload-Status", "final")
+		json.NewEncoder(w).Encode(map[string]any{"name": op})
+	case r.Method == http.MethodGet && path == "/v1beta/"+f.store+"/documents":
+		var docs []any
+		for _, doc := range f.docs {
+			docs = append(docs, doc)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"documents": docs})
+	case r.Method == http.MethodGet && f.pending[strings.TrimPrefix(path, "/v1beta/")] != nil:
+		op := strings.TrimPrefix(path, "/v1beta/")
+		body := f.pending[op]
+		delete(f.pending, op)
+		if f.failPaths[body["displayName"].(string)] {
+			json.NewEncoder(w).Encode(map[string]any{"name": op, "done": true, "error": map[string]any{"code": 3, "message": "unsupported file"}})
+			return
+		}
+		name := f.addLocked(body)
+		json.NewEncoder(w).Encode(map[string]any{"name": op, "done": true, "response": map[string]any{"documentName": name}})
+	case r.Method == http.MethodDelete && f.docs[strings.TrimPrefix(path, "/v1beta/")] != nil:
+		if r.URL.Query().Get("force") != "true" {
+			f.t.Errorf("DELETE %s without force", path)
+		}
+		name := strings.TrimPrefix(path, "/v1beta/")
+		delete(f.docs, name)
+		w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": {"code": 404, "message": "not found", "status": "NOT_FOUND"}}`))
+	}
+}
+
+func writeSyncFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFileSearchStoresSyncDirectory(t *testing.T) {
+	ctx := context.Background()
+	store := "fileSearchStores/s1"
+	files := map[string]string{
+		"same.txt":        "unchanged",
+		"changed.txt":     "new contents",
+		"docs/added.md":   "# added",
+		"docs/ignored.go": "package ignored",
+	}
+	config := &SyncDirectoryConfig{
+		// The uploads run concurrently and must not fill in these options.
+		HTTPOptions:  &HTTPOptions{},
+		PollInterval: time.Millisecond,
+		Include:      func(path string) bool { return !strings.HasSuffix(path, ".go") },
+	}
+
+	tests := []struct {
+		name       string
+		config     SyncDirectoryConfig
+		failPaths  []string
+		want       *SyncDirectoryReport
+		wantPaths  []string
+		wantErrMsg string
+	}{
+		{
+			name:   "Success",
+			config: *config,
+			want: &SyncDirectoryReport{
+				Added:     []string{"docs/added.md"},
+				Updated:   []string{"changed.txt"},
+				Deleted:   []string{"removed.txt"},
+				Unchanged: []string{"same.txt"},
+			},
+			wantPaths: []string{"", "changed.txt", "docs/added.md", "same.txt"},
+		},
+		{
+			name: "DryRun",
+			config: SyncDirectoryConfig{
+				DryRun:  true,
+				Include: config.Include,
+			},
+			want: &SyncDirectoryReport{
+				Added:     []string{"docs/added.md"},
+				Updated:   []string{"changed.txt"},
+				Deleted:   []string{"removed.txt"},
+				Unchanged: []string{"same.txt"},
+			},
+			wantPaths: []string{"", "changed.txt", "removed.txt", "same.txt", "same.txt"},
+		},
+		{
+			name: "KeepMissing",
+			config: SyncDirectoryConfig{
+				PollInterval: time.Millisecond,
+				Include:      config.Include,
+				KeepMissing:  true,
+			},
+			want: &SyncDirectoryReport{
+				Added:     []string{"docs/added.md"},
+				Updated:   []string{"changed.txt"},
+				Unchanged: []string{"same.txt"},
+			},
+			wantPaths: []string{"", "changed.txt", "docs/added.md", "removed.txt", "same.txt"},
+		},
+		{
+			name:      "UploadFailureKeepsPreviousDocument",
+			config:    *config,
+			failPaths: []string{"changed.txt"},
+			want: &SyncDirectoryReport{
+				Added:     []string{"docs/added.md"},
+				Updated:   []string{"changed.txt"},
+				Deleted:   []string{"removed.txt"},
+				Unchanged: []string{"same.txt"},
+				Errors:    []*SyncError{{Path: "changed.txt"}},
+			},
+			wantPaths:  []string{"", "changed.txt", "docs/added.md", "same.txt"},
+			wantErrMsg: "syncing changed.txt: upload operation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ts := newFakeDocumentsServer(t, store)
+			defer ts.Close()
+			client := newTestBatchesClient(t, ts)
+			for _, p := range tt.failPaths {
+				f.failPaths[p] = true
+			}
+			f.add("same.txt", "unchanged")
+			f.add("same.txt", "unchanged")
+			f.add("changed.txt", "old contents")
+			f.add("removed.txt", "gone")
+			f.mu.Lock()
+			f.addLocked(map[string]any{"displayName": "uploaded by hand"})
+			f.mu.Unlock()
+
+			dir := writeSyncFiles(t, files)
+			got, err := client.FileSearchStores.SyncDirectory(ctx, dir, store, &tt.config)
+			if tt.wantErrMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("SyncDirectory() error = %v, want error containing %q", err, tt.wantErrMsg)
+				}
+			} else if err != nil {
+				t.Fatalf("SyncDirectory() failed unexpectedly: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(SyncError{}, "Err")); diff != "" {
+				t.Errorf("SyncDirectory() report mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantPaths, f.paths()); diff != "" {
+				t.Errorf("documents after SyncDirectory() mismatch (-want +got):\n%s", diff)
+			}
+			if config.HTTPOptions.Headers != nil {
+				t.Errorf("SyncDirectory() modified the HTTPOptions of the config: %v", config.HTTPOptions)
+			}
+		})
+	}
+}
+
+func TestFileSearchStoresSyncDirectoryUploadMetadata(t *testing.T) {
+	ctx := context.Background()
+	store := "fileSearchStores/s1"
+	_, ts := newFakeDocumentsServer(t, store)
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	dir := writeSyncFiles(t, map[string]string{"a/b.txt": "hello"})
+	_, err := client.FileSearchStores.SyncDirectory(ctx, dir, store, &SyncDirectoryConfig{
+		PollInterval:   time.Millisecond,
+		CustomMetadata: []*CustomMetadata{{Key: "team", StringValue: "docs"}},
+	})
+	if err != nil {
+		t.Fatalf("SyncDirectory() failed unexpectedly: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("hello"))
+	want := []*Document{{
+		Name:        store + "/documents/doc3",
+		DisplayName: "a/b.txt",
+		CustomMetadata: []*CustomMetadata{
+			{Key: "team", StringValue: "docs"},
+			{Key: SyncPathMetadataKey, StringValue: "a/b.txt"},
+			{Key: SyncHashMetadataKey, StringValue: hex.EncodeToString(sum[:])},
+		},
+	}}
+	var got []*Document
+	for doc, err := range client.FileSearchStores.Documents.All(ctx, store) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, doc)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(Document{}, "MIMEType")); diff != "" {
+		t.Errorf("uploaded documents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileSearchStoresSyncDirectoryVertexAI(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	client, err := NewClient(context.Background(), &ClientConfig{
+		Backend:     BackendVertexAI,
+		Project:     "test-project",
+		Location:    "us-central1",
+		HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+		HTTPClient:  ts.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.FileSearchStores.SyncDirectory(context.Background(), t.TempDir(), "fileSearchStores/s1", nil)
+	if err == nil || !strings.Contains(err.Error(), "only supported in the Gemini Developer client") {
+		t.Errorf("SyncDirectory() error = %v, want Gemini-only error", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// storageBaseURL is the Cloud Storage JSON API endpoint. It is a variable so
+// tests can point it at a local server.
+var storageBaseURL = "https://storage.googleapis.com/"
+
+// splitGCSURI splits "gs://bucket/path/to/object" into its bucket and object.
+func splitGCSURI(uri string) (bucket, object string, err error) {
+	rest, ok := strings.CutPrefix(uri, "gs://")
+	if !ok {
+		return "", "", fmt.Errorf("invalid Cloud Storage URI %q: must start with gs://", uri)
+	}
+	bucket, object, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid Cloud Storage URI %q: missing bucket", uri)
+	}
+	return bucket, object, nil
+}
+
+// doGCSRequest sends a request to the Cloud Storage JSON API with the HTTP
+// client of the API client, which carries the Vertex AI credentials. API keys
+// of the Vertex AI express mode don't authorize Cloud Storage requests, so
+// clients using one are rejected.
+func doGCSRequest(ctx context.Context, ac *apiClient, method, path string, query url.Values, body []byte) ([]byte, error) {
+	if ac.clientConfig.APIKey != "" {
+		return nil, fmt.Errorf("Cloud Storage requests are not supported with an API key: use a Vertex AI client with credentials that can access the bucket")
+	}
+	u := storageBaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	resp, err := doRequest(ac, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if !httpStatusOk(resp) {
+		return nil, newAPIError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// uploadGCSObject writes data to the object at uri, replacing any previous
+// contents.
+func uploadGCSObject(ctx context.Context, ac *apiClient, uri string, data []byte) error {
+	bucket, object, err := splitGCSURI(uri)
+	if err != nil {
+		return err
+	}
+	if object == "" {
+		return fmt.Errorf("invalid Cloud Storage URI %q: missing object name", uri)
+	}
+	query := url.Values{"uploadType": {"media"}, "name": {object}}
+	_, err = doGCSRequest(ctx, ac, http.MethodPost, "upload/storage/v1/b/"+url.PathEscape(bucket)+"/o", query, data)
+	return err
+}
+
+// downloadGCSObject returns the contents of the object at uri.
+func downloadGCSObject(ctx context.Context, ac *apiClient, uri string) ([]byte, error) {
+	bucket, object, err := splitGCSURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	path := "storage/v1/b/" + url.PathEscape(bucket) + "/o/" + url.PathEscape(object)
+	return doGCSRequest(ctx, ac, http.MethodGet, path, url.Values{"alt": {"media"}}, nil)
+}
+
+// listGCSObjects returns the URIs of all objects under the directory dirURI,
+// at any depth. Objects of sibling directories sharing its name as a prefix,
+// such as "out2/" for "out", are not listed.
+func listGCSObjects(ctx context.Context, ac *apiClient, dirURI string) ([]string, error) {
+	bucket, prefix, err := splitGCSURI(dirURI)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var uris []string
+	pageToken := ""
+	for {
+		query := url.Values{"prefix": {prefix}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		data, err := doGCSRequest(ctx, ac, http.MethodGet, "storage/v1/b/"+url.PathEscape(bucket)+"/o", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			uris = append(uris, "gs://"+bucket+"/"+item.Name)
+		}
+		if page.NextPageToken == "" {
+			return uris, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
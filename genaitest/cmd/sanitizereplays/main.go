@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Sanitizereplays re-sanitizes the genaitest recordings in directories with
+// the default rules of genaitest.Sanitizer: it redacts secrets and replaces
+// personal data, timestamps, resource IDs and media with placeholders. The
+// snake_case replay files shared with the other SDKs are not supported, since
+// their tests don't map the placeholders back to values.
+//
+// Usage:
+//
+//	go run google.golang.org/genai/genaitest/cmd/sanitizereplays [-check] dir...
+//
+// With -check, it only lists the files that need sanitizing, and exits with
+// status 1 if there are any.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/genai/genaitest"
+)
+
+var check = flag.Bool("check", false, "list the files that need sanitizing without rewriting them")
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("sanitizereplays: ")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: sanitizereplays [-check] dir...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	sanitizer := genaitest.DefaultSanitizer()
+	var changed int
+	for _, dir := range flag.Args() {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+				return err
+			}
+			ok, err := sanitize(sanitizer, path)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if ok {
+				changed++
+				fmt.Println(path)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *check && changed > 0 {
+		log.Printf("%d files need sanitizing", changed)
+		os.Exit(1)
+	}
+}
+
+// sanitize sanitizes the recording at path and reports whether it changed.
+// With -check, the file is left as is.
+func sanitize(sanitizer *genaitest.Sanitizer, path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	sanitized, err := sanitizer.SanitizeJSON(data)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(sanitized, data) {
+		return false, nil
+	}
+	if *check {
+		return true, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, sanitized, info.Mode().Perm())
+}
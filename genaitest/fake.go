@@ -0,0 +1,547 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// FakeServer is an in-process fake of the Gemini API, for tests that need
+// more than a fixed recording: it keeps the resources it creates, computes
+// responses with functions the test provides, and can fail or delay requests.
+//
+// It implements the REST methods the SDK uses with [genai.BackendGeminiAPI]:
+//
+//   - "generateContent" and "streamGenerateContent" of [genai.Models.GenerateContent]
+//     and [genai.Models.GenerateContentStream].
+//   - "countTokens" and "batchEmbedContents" of [genai.Models.CountTokens] and
+//     [genai.Models.EmbedContent].
+//   - "files.create", "files.upload", "files.get", "files.list" and
+//     "files.delete" of [genai.Files], including the resumable upload protocol.
+//   - "cachedContents.create", "cachedContents.get", "cachedContents.list",
+//     "cachedContents.patch" and "cachedContents.delete" of [genai.Caches].
+//   - "batchGenerateContent", "batches.get", "batches.list", "batches.cancel"
+//     and "batches.delete" of [genai.Batches].
+//   - "operations.get" of [genai.Operations].
+//   - "live.connect", "live.setup", "live.clientContent", "live.realtimeInput"
+//     and "live.toolResponse": the websocket handshake and the messages of a
+//     [genai.Live] session.
+//
+// These names identify the methods in [FakeServer.Requests],
+// [FakeServer.FailNext] and [FakeServer.SetLatency].
+//
+// The exported fields must be set before the server receives requests. A
+// FakeServer is safe for concurrent use by multiple goroutines.
+type FakeServer struct {
+	// Generate computes the responses of generateContent and
+	// streamGenerateContent requests, and of the requests of batches
+	// completed with [FakeServer.CompleteBatch]. A unary request gets the
+	// first response. A returned *[genai.APIError] is sent as the error
+	// response. Defaults to echoing the text of the last content, in one
+	// chunk per word when streaming.
+	Generate func(req *GenerateRequest) ([]*genai.GenerateContentResponse, error)
+	// CountTokens counts the tokens of a countTokens request. Defaults to
+	// counting the words of the text parts.
+	CountTokens func(req *GenerateRequest) int32
+	// Embed computes the embedding of a text. Defaults to a vector derived
+	// from the hash of the text, with the requested dimensionality or 8.
+	Embed func(text string, dimensions int) []float32
+	// Live computes the messages the server sends in response to a message of
+	// a live session. Defaults to echoing the text of client content and
+	// realtime input, followed by the end of the turn.
+	Live func(msg *LiveMessage) []*genai.LiveServerMessage
+
+	t      testing.TB
+	server *httptest.Server
+
+	mu         sync.Mutex
+	requests   []*FakeRequest
+	queued     [][]*genai.GenerateContentResponse
+	failures   map[string][]*genai.APIError
+	latency    map[string]time.Duration
+	nextID     int
+	files      map[string]*genai.File
+	fileOrder  []string
+	uploads    map[string]*fakeUpload
+	caches     map[string]*genai.CachedContent
+	cacheOrder []string
+	batches    map[string]*fakeBatch
+	batchOrder []string
+	operations map[string]map[string]any
+}
+
+// GenerateRequest is a generateContent, streamGenerateContent or countTokens
+// request received by a [FakeServer].
+type GenerateRequest struct {
+	// Model is the resource name of the model, such as
+	// "models/gemini-2.0-flash".
+	Model string `json:"model,omitempty"`
+	// Stream reports whether the request is a streamGenerateContent request.
+	Stream            bool              `json:"-"`
+	Contents          []*genai.Content  `json:"contents,omitempty"`
+	SystemInstruction *genai.Content    `json:"systemInstruction,omitempty"`
+	Tools             []*genai.Tool     `json:"tools,omitempty"`
+	ToolConfig        *genai.ToolConfig `json:"toolConfig,omitempty"`
+	CachedContent     string            `json:"cachedContent,omitempty"`
+	// GenerationConfig is the generation config as sent on the wire.
+	GenerationConfig map[string]any `json:"generationConfig,omitempty"`
+}
+
+// FakeRequest is a request received by a [FakeServer].
+type FakeRequest struct {
+	// Method is the name of the API method, such as "generateContent".
+	Method string
+	// Path is the URL path of the request. For live messages it is the path
+	// of the websocket endpoint.
+	Path string
+	// Header holds the request headers. Live messages have the headers of the
+	// websocket handshake.
+	Header http.Header
+	// Body is the request body, or the live message.
+	Body []byte
+}
+
+// Decode unmarshals the JSON body of the request into v.
+func (r *FakeRequest) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// NewFakeServer starts a [FakeServer] that is closed when the test ends.
+func NewFakeServer(t testing.TB) *FakeServer {
+	t.Helper()
+	f := &FakeServer{
+		t:          t,
+		failures:   make(map[string][]*genai.APIError),
+		latency:    make(map[string]time.Duration),
+		files:      make(map[string]*genai.File),
+		uploads:    make(map[string]*fakeUpload),
+		caches:     make(map[string]*genai.CachedContent),
+		batches:    make(map[string]*fakeBatch),
+		operations: make(map[string]map[string]any),
+	}
+	f.server = httptest.NewServer(f)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// URL returns the base URL of the server, to be set as
+// [genai.HTTPOptions.BaseURL].
+func (f *FakeServer) URL() string {
+	return f.server.URL
+}
+
+// ClientConfig returns a configuration for a Gemini API client that talks to
+// the server.
+func (f *FakeServer) ClientConfig() *genai.ClientConfig {
+	return &genai.ClientConfig{
+		Backend:     genai.BackendGeminiAPI,
+		APIKey:      "fake-api-key",
+		HTTPClient:  f.server.Client(),
+		HTTPOptions: genai.HTTPOptions{BaseURL: f.server.URL},
+	}
+}
+
+// LiveClientConfig returns a configuration for a Gemini API client whose live
+// sessions connect to the server. The server does not serve TLS, so the base
+// URL uses the ws scheme, and the client is only good for [genai.Live].
+func (f *FakeServer) LiveClientConfig() *genai.ClientConfig {
+	config := f.ClientConfig()
+	config.HTTPOptions.BaseURL = "ws" + strings.TrimPrefix(f.server.URL, "http")
+	return config
+}
+
+// QueueResponse queues the response of a future generateContent or
+// streamGenerateContent request. Queued responses are used in order, before
+// [FakeServer.Generate]. A unary request gets the first chunk, a streaming
+// request gets all of them.
+func (f *FakeServer) QueueResponse(chunks ...*genai.GenerateContentResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queued = append(f.queued, chunks)
+}
+
+// FailNext makes the next request of method fail with err. An empty method
+// matches any method. Failures of the same method are used in the order they
+// were added.
+func (f *FakeServer) FailNext(method string, err genai.APIError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method] = append(f.failures[method], &err)
+}
+
+// SetLatency delays the responses of method by d, or of all methods if method
+// is empty. A delay is cut short when the client cancels the request.
+func (f *FakeServer) SetLatency(method string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency[method] = d
+}
+
+// Requests returns the requests received for method so far, or all of them if
+// method is empty.
+func (f *FakeServer) Requests(method string) []*FakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var requests []*FakeRequest
+	for _, r := range f.requests {
+		if method == "" || r.Method == method {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// SetOperation sets the JSON representation of the long-running operation
+// name, returned by "operations.get", for example
+// {"name": name, "done": true, "response": {...}}.
+func (f *FakeServer) SetOperation(name string, operation map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.operations[name] = operation
+}
+
+// ServeHTTP serves the API.
+func (f *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/ws/") {
+		f.serveLive(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, &genai.APIError{Code: http.StatusBadRequest, Message: err.Error(), Status: "INVALID_ARGUMENT"})
+		return
+	}
+	method, handler := f.route(r)
+	if handler == nil {
+		writeError(w, &genai.APIError{Code: http.StatusNotFound, Message: fmt.Sprintf("%s %s not found", r.Method, r.URL.Path), Status: "NOT_FOUND"})
+		return
+	}
+	if apiErr := f.receive(r, method, body); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	handler(w, r, body)
+}
+
+type fakeHandler func(w http.ResponseWriter, r *http.Request, body []byte)
+
+// route returns the API method of r and its handler.
+func (f *FakeServer) route(r *http.Request) (string, fakeHandler) {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	if rest, ok := strings.CutPrefix(p, fakeUploadPath); ok {
+		return "files.upload", func(w http.ResponseWriter, r *http.Request, body []byte) { f.upload(w, r, rest, body) }
+	}
+	if strings.HasPrefix(p, "upload/") {
+		if r.Method == http.MethodPost && strings.HasSuffix(p, "/files") {
+			return "files.create", f.createFile
+		}
+		return "", nil
+	}
+	// Strip the API version.
+	if _, rest, ok := strings.Cut(p, "/"); ok {
+		p = rest
+	}
+	resource, verb, _ := strings.Cut(p, ":")
+	switch {
+	case strings.HasPrefix(resource, "models/") && r.Method == http.MethodPost:
+		switch verb {
+		case "generateContent", "streamGenerateContent":
+			return verb, func(w http.ResponseWriter, r *http.Request, body []byte) {
+				f.generate(w, r, resource, verb == "streamGenerateContent", body)
+			}
+		case "countTokens":
+			return verb, func(w http.ResponseWriter, r *http.Request, body []byte) { f.countTokens(w, resource, body) }
+		case "batchEmbedContents":
+			return verb, func(w http.ResponseWriter, r *http.Request, body []byte) { f.embed(w, body) }
+		case "batchGenerateContent":
+			return verb, func(w http.ResponseWriter, r *http.Request, body []byte) { f.createBatch(w, resource, body) }
+		}
+	case strings.Contains("/"+resource, "/operations/") && r.Method == http.MethodGet:
+		return "operations.get", func(w http.ResponseWriter, r *http.Request, body []byte) { f.getOperation(w, resource) }
+	case resource == "files" && r.Method == http.MethodGet:
+		return "files.list", f.listFiles
+	case strings.HasPrefix(resource, "files/"):
+		switch r.Method {
+		case http.MethodGet:
+			return "files.get", func(w http.ResponseWriter, r *http.Request, body []byte) { f.getFile(w, resource) }
+		case http.MethodDelete:
+			return "files.delete", func(w http.ResponseWriter, r *http.Request, body []byte) { f.deleteFile(w, resource) }
+		}
+	case resource == "cachedContents":
+		switch r.Method {
+		case http.MethodPost:
+			return "cachedContents.create", f.createCache
+		case http.MethodGet:
+			return "cachedContents.list", f.listCaches
+		}
+	case strings.HasPrefix(resource, "cachedContents/"):
+		switch r.Method {
+		case http.MethodGet:
+			return "cachedContents.get", func(w http.ResponseWriter, r *http.Request, body []byte) { f.getCache(w, resource) }
+		case http.MethodPatch:
+			return "cachedContents.patch", func(w http.ResponseWriter, r *http.Request, body []byte) { f.patchCache(w, resource, body) }
+		case http.MethodDelete:
+			return "cachedContents.delete", func(w http.ResponseWriter, r *http.Request, body []byte) { f.deleteCache(w, resource) }
+		}
+	case resource == "batches" && r.Method == http.MethodGet:
+		return "batches.list", f.listBatches
+	case strings.HasPrefix(resource, "batches/"):
+		switch {
+		case verb == "cancel" && r.Method == http.MethodPost:
+			return "batches.cancel", func(w http.ResponseWriter, r *http.Request, body []byte) { f.cancelBatch(w, resource) }
+		case verb == "" && r.Method == http.MethodGet:
+			return "batches.get", func(w http.ResponseWriter, r *http.Request, body []byte) { f.getBatch(w, resource) }
+		case verb == "" && r.Method == http.MethodDelete:
+			return "batches.delete", func(w http.ResponseWriter, r *http.Request, body []byte) { f.deleteBatch(w, resource) }
+		}
+	}
+	return "", nil
+}
+
+// receive records a request and applies the latency and failure injected for
+// its method. It returns the error to respond with, if any.
+func (f *FakeServer) receive(r *http.Request, method string, body []byte) *genai.APIError {
+	f.mu.Lock()
+	f.requests = append(f.requests, &FakeRequest{Method: method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	delay, ok := f.latency[method]
+	if !ok {
+		delay = f.latency[""]
+	}
+	var apiErr *genai.APIError
+	for _, key := range []string{method, ""} {
+		if failures := f.failures[key]; len(failures) > 0 {
+			apiErr, f.failures[key] = failures[0], failures[1:]
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}
+	return apiErr
+}
+
+func (f *FakeServer) generate(w http.ResponseWriter, r *http.Request, model string, stream bool, body []byte) {
+	req := &GenerateRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, invalidArgument(err))
+		return
+	}
+	req.Model, req.Stream = model, stream
+	if req.CachedContent != "" {
+		f.mu.Lock()
+		_, ok := f.caches[req.CachedContent]
+		f.mu.Unlock()
+		if !ok {
+			writeError(w, notFound(req.CachedContent))
+			return
+		}
+	}
+	chunks, err := f.responses(req)
+	if err != nil {
+		writeError(w, toAPIError(err))
+		return
+	}
+	if !stream {
+		var resp *genai.GenerateContentResponse
+		if len(chunks) > 0 {
+			resp = chunks[0]
+		}
+		writeJSON(w, resp)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+	for _, chunk := range chunks {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			f.t.Errorf("FakeServer: marshaling response: %v", err)
+			return
+		}
+		fmt.Fprintf(w, "data: %s\r\n\r\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// responses returns the next queued response, or the responses computed by
+// Generate.
+func (f *FakeServer) responses(req *GenerateRequest) ([]*genai.GenerateContentResponse, error) {
+	f.mu.Lock()
+	if len(f.queued) > 0 {
+		chunks := f.queued[0]
+		f.queued = f.queued[1:]
+		f.mu.Unlock()
+		return chunks, nil
+	}
+	f.mu.Unlock()
+	if f.Generate != nil {
+		return f.Generate(req)
+	}
+	return f.echo(req), nil
+}
+
+// echo returns the text of the last content of req as the model response.
+func (f *FakeServer) echo(req *GenerateRequest) []*genai.GenerateContentResponse {
+	var text string
+	if len(req.Contents) > 0 && req.Contents[len(req.Contents)-1] != nil {
+		text = contentText(req.Contents[len(req.Contents)-1])
+	}
+	pieces := []string{text}
+	if req.Stream {
+		pieces = strings.SplitAfter(text, " ")
+	}
+	promptTokens := f.countTokens32(req)
+	var chunks []*genai.GenerateContentResponse
+	for i, piece := range pieces {
+		candidate := &genai.Candidate{Content: genai.NewContentFromText(piece, genai.RoleModel)}
+		if i == len(pieces)-1 {
+			candidate.FinishReason = genai.FinishReasonStop
+		}
+		candidateTokens := int32(len(strings.Fields(piece)))
+		chunks = append(chunks, &genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{candidate},
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+				PromptTokenCount:     promptTokens,
+				CandidatesTokenCount: candidateTokens,
+				TotalTokenCount:      promptTokens + candidateTokens,
+			},
+			ModelVersion: strings.TrimPrefix(req.Model, "models/"),
+		})
+	}
+	return chunks
+}
+
+func (f *FakeServer) countTokens(w http.ResponseWriter, model string, body []byte) {
+	req := &GenerateRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, invalidArgument(err))
+		return
+	}
+	req.Model = model
+	writeJSON(w, map[string]any{"totalTokens": f.countTokens32(req)})
+}
+
+func (f *FakeServer) countTokens32(req *GenerateRequest) int32 {
+	if f.CountTokens != nil {
+		return f.CountTokens(req)
+	}
+	n := 0
+	for _, content := range append(req.Contents, req.SystemInstruction) {
+		if content != nil {
+			n += len(strings.Fields(contentText(content)))
+		}
+	}
+	return int32(n)
+}
+
+func (f *FakeServer) embed(w http.ResponseWriter, body []byte) {
+	var req struct {
+		Requests []struct {
+			Content              *genai.Content `json:"content"`
+			OutputDimensionality int            `json:"outputDimensionality"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, invalidArgument(err))
+		return
+	}
+	embed := f.Embed
+	if embed == nil {
+		embed = hashEmbedding
+	}
+	var embeddings []map[string]any
+	for _, r := range req.Requests {
+		var text string
+		if r.Content != nil {
+			text = contentText(r.Content)
+		}
+		embeddings = append(embeddings, map[string]any{"values": embed(text, r.OutputDimensionality)})
+	}
+	writeJSON(w, map[string]any{"embeddings": embeddings})
+}
+
+// hashEmbedding derives a deterministic embedding from the hash of text.
+func hashEmbedding(text string, dimensions int) []float32 {
+	if dimensions <= 0 {
+		dimensions = 8
+	}
+	values := make([]float32, dimensions)
+	sum := sha256.Sum256([]byte(text))
+	for i := range values {
+		values[i] = float32(sum[i%len(sum)])/127.5 - 1
+	}
+	return values
+}
+
+func contentText(content *genai.Content) string {
+	var sb strings.Builder
+	for _, part := range content.Parts {
+		if part != nil {
+			sb.WriteString(part.Text)
+		}
+	}
+	return sb.String()
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, apiErr *genai.APIError) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(apiErr.Code)
+	json.NewEncoder(w).Encode(map[string]any{"error": apiErr})
+}
+
+// toAPIError converts an error returned by a FakeServer function to the error
+// response.
+func toAPIError(err error) *genai.APIError {
+	var apiErr *genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var apiErrValue genai.APIError
+	if errors.As(err, &apiErrValue) {
+		return &apiErrValue
+	}
+	return &genai.APIError{Code: http.StatusInternalServerError, Message: err.Error(), Status: "INTERNAL"}
+}
+
+func invalidArgument(err error) *genai.APIError {
+	return &genai.APIError{Code: http.StatusBadRequest, Message: err.Error(), Status: "INVALID_ARGUMENT"}
+}
+
+func notFound(name string) *genai.APIError {
+	return &genai.APIError{Code: http.StatusNotFound, Message: fmt.Sprintf("%s not found", name), Status: "NOT_FOUND"}
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/genai"
+)
+
+var liveUpgrader = websocket.Upgrader{}
+
+// LiveMessage is a message received by a [FakeServer] in a live session.
+type LiveMessage struct {
+	genai.LiveClientMessage
+	// RealtimeText is the text of realtime input, sent with
+	// [genai.LiveRealtimeInput.Text].
+	RealtimeText string
+}
+
+// serveLive serves a live session on the websocket endpoint.
+func (f *FakeServer) serveLive(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.URL.Path, ".GenerativeService.BidiGenerateContent") {
+		writeError(w, notFound(r.URL.Path))
+		return
+	}
+	// Failures and latency injected for "live.connect" apply to the handshake.
+	if apiErr := f.receive(r, "live.connect", nil); apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg LiveMessage
+		var text struct {
+			RealtimeInput struct {
+				Text string `json:"text"`
+			} `json:"realtimeInput"`
+		}
+		if err := json.Unmarshal(data, &msg.LiveClientMessage); err != nil {
+			f.t.Errorf("FakeServer: invalid live message %s: %v", data, err)
+			return
+		}
+		json.Unmarshal(data, &text)
+		msg.RealtimeText = text.RealtimeInput.Text
+		method := "live.unknown"
+		switch {
+		case msg.Setup != nil:
+			method = "live.setup"
+		case msg.ClientContent != nil:
+			method = "live.clientContent"
+		case msg.RealtimeInput != nil:
+			method = "live.realtimeInput"
+		case msg.ToolResponse != nil:
+			method = "live.toolResponse"
+		}
+		if apiErr := f.receive(r, method, data); apiErr != nil {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, apiErr.Message))
+			return
+		}
+
+		var replies []*genai.LiveServerMessage
+		switch {
+		case msg.Setup != nil:
+			replies = []*genai.LiveServerMessage{{SetupComplete: &genai.LiveServerSetupComplete{}}}
+		case f.Live != nil:
+			replies = f.Live(&msg)
+		default:
+			replies = echoLive(&msg)
+		}
+		for _, reply := range replies {
+			data, err := json.Marshal(reply)
+			if err != nil {
+				f.t.Errorf("FakeServer: marshaling live message: %v", err)
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// echoLive answers client content that completes a turn, and realtime text
+// input, with their text.
+func echoLive(msg *LiveMessage) []*genai.LiveServerMessage {
+	var text string
+	switch {
+	case msg.ClientContent != nil:
+		if !msg.ClientContent.TurnComplete {
+			return nil
+		}
+		for _, content := range msg.ClientContent.Turns {
+			if content != nil {
+				text += contentText(content)
+			}
+		}
+	case msg.RealtimeText != "":
+		text = msg.RealtimeText
+	default:
+		return nil
+	}
+	return []*genai.LiveServerMessage{
+		{ServerContent: &genai.LiveServerContent{ModelTurn: genai.NewContentFromText(text, genai.RoleModel)}},
+		{ServerContent: &genai.LiveServerContent{TurnComplete: true}},
+	}
+}
@@ -0,0 +1,511 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// fakeUploadPath is the path prefix of the upload URLs of a FakeServer.
+const fakeUploadPath = "fake-upload/"
+
+// Default lifetimes of the resources of a FakeServer.
+const (
+	fakeFileTTL  = 48 * time.Hour
+	fakeCacheTTL = time.Hour
+)
+
+type fakeUpload struct {
+	file *genai.File
+	data []byte
+}
+
+// newName returns a new resource name in collection. The caller must hold
+// f.mu.
+func (f *FakeServer) newName(collection string) string {
+	f.nextID++
+	return fmt.Sprintf("%s/fake-%d", collection, f.nextID)
+}
+
+func (f *FakeServer) createFile(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req struct {
+		File *genai.File `json:"file"`
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, invalidArgument(err))
+			return
+		}
+	}
+	file := req.File
+	if file == nil {
+		file = &genai.File{}
+	}
+	if file.MIMEType == "" {
+		file.MIMEType = r.Header.Get("X-Goog-Upload-Header-Content-Type")
+	}
+
+	f.mu.Lock()
+	f.nextID++
+	id := strconv.Itoa(f.nextID)
+	f.uploads[id] = &fakeUpload{file: file}
+	f.mu.Unlock()
+
+	w.Header().Set("X-Goog-Upload-URL", f.server.URL+"/"+fakeUploadPath+id)
+	w.Header().Set("X-Goog-Upload-Status", "active")
+	writeJSON(w, map[string]any{})
+}
+
+// upload receives a chunk of the upload session id.
+func (f *FakeServer) upload(w http.ResponseWriter, r *http.Request, id string, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.uploads[id]
+	if !ok {
+		writeError(w, notFound("upload session "+id))
+		return
+	}
+	offset, err := strconv.Atoi(r.Header.Get("X-Goog-Upload-Offset"))
+	if err != nil || offset != len(session.data) {
+		writeError(w, invalidArgument(fmt.Errorf("upload offset %q, want %d", r.Header.Get("X-Goog-Upload-Offset"), len(session.data))))
+		return
+	}
+	session.data = append(session.data, body...)
+	if !strings.Contains(r.Header.Get("X-Goog-Upload-Command"), "finalize") {
+		w.Header().Set("X-Goog-Upload-Status", "active")
+		writeJSON(w, map[string]any{})
+		return
+	}
+	delete(f.uploads, id)
+
+	file := session.file
+	if file.Name == "" {
+		file.Name = f.newName("files")
+	} else if !strings.HasPrefix(file.Name, "files/") {
+		file.Name = "files/" + file.Name
+	}
+	if _, ok := f.files[file.Name]; ok {
+		writeError(w, &genai.APIError{Code: http.StatusConflict, Message: fmt.Sprintf("%s already exists", file.Name), Status: "ALREADY_EXISTS"})
+		return
+	}
+	now := time.Now().UTC()
+	sum := sha256.Sum256(session.data)
+	size := int64(len(session.data))
+	file.SizeBytes = &size
+	file.CreateTime, file.UpdateTime = now, now
+	file.ExpirationTime = now.Add(fakeFileTTL)
+	file.Sha256Hash = base64.StdEncoding.EncodeToString(sum[:])
+	file.URI = f.server.URL + "/v1beta/" + file.Name
+	file.State = genai.FileStateActive
+	file.Source = genai.FileSourceUploaded
+	f.files[file.Name] = file
+	f.fileOrder = append(f.fileOrder, file.Name)
+
+	w.Header().Set("X-Goog-Upload-Status", "final")
+	writeJSON(w, map[string]any{"file": file})
+}
+
+func (f *FakeServer) getFile(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, ok := f.files[name]
+	if !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	writeJSON(w, file)
+}
+
+func (f *FakeServer) deleteFile(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.files[name]; !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	delete(f.files, name)
+	f.fileOrder = remove(f.fileOrder, name)
+	writeJSON(w, map[string]any{})
+}
+
+func (f *FakeServer) listFiles(w http.ResponseWriter, r *http.Request, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	page, next := paginate(f.fileOrder, r)
+	files := make([]*genai.File, len(page))
+	for i, name := range page {
+		files[i] = f.files[name]
+	}
+	writeJSON(w, map[string]any{"files": files, "nextPageToken": next})
+}
+
+func (f *FakeServer) createCache(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req struct {
+		GenerateRequest
+		DisplayName string    `json:"displayName"`
+		TTL         string    `json:"ttl"`
+		ExpireTime  time.Time `json:"expireTime"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, invalidArgument(err))
+		return
+	}
+	if req.Model == "" {
+		writeError(w, invalidArgument(fmt.Errorf("model is required")))
+		return
+	}
+	now := time.Now().UTC()
+	expireTime, err := expiration(now, req.TTL, req.ExpireTime)
+	if err != nil {
+		writeError(w, invalidArgument(err))
+		return
+	}
+	tokens := f.countTokens32(&req.GenerateRequest)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cache := &genai.CachedContent{
+		Name:          f.newName("cachedContents"),
+		DisplayName:   req.DisplayName,
+		Model:         req.Model,
+		CreateTime:    now,
+		UpdateTime:    now,
+		ExpireTime:    expireTime,
+		UsageMetadata: &genai.CachedContentUsageMetadata{TotalTokenCount: tokens},
+	}
+	f.caches[cache.Name] = cache
+	f.cacheOrder = append(f.cacheOrder, cache.Name)
+	writeJSON(w, cache)
+}
+
+func (f *FakeServer) getCache(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cache, ok := f.caches[name]
+	if !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	writeJSON(w, cache)
+}
+
+func (f *FakeServer) patchCache(w http.ResponseWriter, name string, body []byte) {
+	var req struct {
+		TTL        string    `json:"ttl"`
+		ExpireTime time.Time `json:"expireTime"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, invalidArgument(err))
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cache, ok := f.caches[name]
+	if !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	now := time.Now().UTC()
+	if req.TTL != "" || !req.ExpireTime.IsZero() {
+		expireTime, err := expiration(now, req.TTL, req.ExpireTime)
+		if err != nil {
+			writeError(w, invalidArgument(err))
+			return
+		}
+		cache.ExpireTime = expireTime
+	}
+	cache.UpdateTime = now
+	writeJSON(w, cache)
+}
+
+func (f *FakeServer) deleteCache(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.caches[name]; !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	delete(f.caches, name)
+	f.cacheOrder = remove(f.cacheOrder, name)
+	writeJSON(w, map[string]any{})
+}
+
+func (f *FakeServer) listCaches(w http.ResponseWriter, r *http.Request, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	page, next := paginate(f.cacheOrder, r)
+	caches := make([]*genai.CachedContent, len(page))
+	for i, name := range page {
+		caches[i] = f.caches[name]
+	}
+	writeJSON(w, map[string]any{"cachedContents": caches, "nextPageToken": next})
+}
+
+// expiration returns the expiration time set by a ttl such as "3600s" or an
+// expire time, defaulting to one hour from now.
+func expiration(now time.Time, ttl string, expireTime time.Time) (time.Time, error) {
+	switch {
+	case ttl != "":
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid ttl %q: %w", ttl, err)
+		}
+		return now.Add(d), nil
+	case !expireTime.IsZero():
+		return expireTime, nil
+	}
+	return now.Add(fakeCacheTTL), nil
+}
+
+type fakeBatch struct {
+	name        string
+	displayName string
+	model       string
+	state       genai.JobState
+	createTime  time.Time
+	updateTime  time.Time
+	endTime     time.Time
+	fileName    string
+	requests    []*GenerateRequest
+	metadata    []map[string]any
+	responses   []map[string]any
+}
+
+// wire returns the batch as the API represents it: an operation whose metadata
+// is the batch.
+func (b *fakeBatch) wire() map[string]any {
+	metadata := map[string]any{
+		"@type":       "type.googleapis.com/google.ai.generativelanguage.v1beta.GenerateContentBatch",
+		"name":        b.name,
+		"displayName": b.displayName,
+		"model":       b.model,
+		"state":       strings.Replace(string(b.state), "JOB_STATE_", "BATCH_STATE_", 1),
+		"createTime":  b.createTime.Format(time.RFC3339Nano),
+		"updateTime":  b.updateTime.Format(time.RFC3339Nano),
+	}
+	if !b.endTime.IsZero() {
+		metadata["endTime"] = b.endTime.Format(time.RFC3339Nano)
+	}
+	if b.responses != nil {
+		metadata["output"] = map[string]any{"inlinedResponses": map[string]any{"inlinedResponses": b.responses}}
+	}
+	return map[string]any{"name": b.name, "metadata": metadata, "done": isTerminal(b.state)}
+}
+
+func isTerminal(state genai.JobState) bool {
+	switch state {
+	case genai.JobStateSucceeded, genai.JobStateFailed, genai.JobStateCancelled, genai.JobStateExpired:
+		return true
+	}
+	return false
+}
+
+func (f *FakeServer) createBatch(w http.ResponseWriter, model string, body []byte) {
+	var req struct {
+		Batch struct {
+			DisplayName string `json:"displayName"`
+			InputConfig struct {
+				FileName string `json:"fileName"`
+				Requests struct {
+					Requests []struct {
+						Request  *GenerateRequest `json:"request"`
+						Metadata map[string]any   `json:"metadata"`
+					} `json:"requests"`
+				} `json:"requests"`
+			} `json:"inputConfig"`
+		} `json:"batch"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, invalidArgument(err))
+		return
+	}
+	now := time.Now().UTC()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b := &fakeBatch{
+		name:        f.newName("batches"),
+		displayName: req.Batch.DisplayName,
+		model:       model,
+		state:       genai.JobStatePending,
+		createTime:  now,
+		updateTime:  now,
+		fileName:    req.Batch.InputConfig.FileName,
+	}
+	for _, r := range req.Batch.InputConfig.Requests.Requests {
+		if r.Request == nil {
+			r.Request = &GenerateRequest{}
+		}
+		if r.Request.Model == "" {
+			r.Request.Model = model
+		}
+		b.requests = append(b.requests, r.Request)
+		b.metadata = append(b.metadata, r.Metadata)
+	}
+	f.batches[b.name] = b
+	f.batchOrder = append(f.batchOrder, b.name)
+	writeJSON(w, b.wire())
+}
+
+// CompleteBatch runs the inlined requests of the batch name through
+// [FakeServer.Generate] and marks it succeeded. Batches are pending until they
+// are completed or their state is set with [FakeServer.SetBatchState].
+func (f *FakeServer) CompleteBatch(name string) error {
+	f.mu.Lock()
+	b, ok := f.batches[name]
+	f.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("batch %s not found", name)
+	}
+	if b.fileName != "" {
+		return fmt.Errorf("batch %s reads its requests from %s; set its state with SetBatchState", name, b.fileName)
+	}
+	responses := make([]map[string]any, 0, len(b.requests))
+	for i, req := range b.requests {
+		response := map[string]any{}
+		chunks, err := f.responses(req)
+		switch {
+		case err != nil:
+			apiErr := toAPIError(err)
+			response["error"] = map[string]any{"code": apiErr.Code, "message": apiErr.Message}
+		case len(chunks) > 0:
+			response["response"] = chunks[0]
+		}
+		if b.metadata[i] != nil {
+			response["metadata"] = b.metadata[i]
+		}
+		responses = append(responses, response)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now().UTC()
+	b.responses = responses
+	b.state = genai.JobStateSucceeded
+	b.updateTime, b.endTime = now, now
+	return nil
+}
+
+// SetBatchState sets the state of the batch name.
+func (f *FakeServer) SetBatchState(name string, state genai.JobState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.batches[name]
+	if !ok {
+		return fmt.Errorf("batch %s not found", name)
+	}
+	now := time.Now().UTC()
+	b.state = state
+	b.updateTime = now
+	if isTerminal(state) {
+		b.endTime = now
+	}
+	return nil
+}
+
+func (f *FakeServer) getBatch(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.batches[name]
+	if !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	writeJSON(w, b.wire())
+}
+
+func (f *FakeServer) cancelBatch(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b, ok := f.batches[name]
+	if !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	if !isTerminal(b.state) {
+		now := time.Now().UTC()
+		b.state = genai.JobStateCancelled
+		b.updateTime, b.endTime = now, now
+	}
+	writeJSON(w, map[string]any{})
+}
+
+func (f *FakeServer) deleteBatch(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.batches[name]; !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	delete(f.batches, name)
+	f.batchOrder = remove(f.batchOrder, name)
+	writeJSON(w, map[string]any{"name": name, "done": true})
+}
+
+func (f *FakeServer) listBatches(w http.ResponseWriter, r *http.Request, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	page, next := paginate(f.batchOrder, r)
+	operations := make([]map[string]any, len(page))
+	for i, name := range page {
+		operations[i] = f.batches[name].wire()
+	}
+	writeJSON(w, map[string]any{"operations": operations, "nextPageToken": next})
+}
+
+func (f *FakeServer) getOperation(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	op, ok := f.operations[name]
+	if !ok {
+		writeError(w, notFound(name))
+		return
+	}
+	writeJSON(w, op)
+}
+
+// paginate returns the page of names selected by the pageSize and pageToken
+// query parameters of r, and the token of the next page.
+func paginate(names []string, r *http.Request) ([]string, string) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	start = min(max(start, 0), len(names))
+	size, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	end := len(names)
+	if size > 0 {
+		end = min(start+size, len(names))
+	}
+	var next string
+	if end < len(names) {
+		next = strconv.Itoa(end)
+	}
+	return names[start:end], next
+}
+
+func remove(names []string, name string) []string {
+	for i, n := range names {
+		if n == name {
+			return append(names[:i:i], names[i+1:]...)
+		}
+	}
+	return names
+}
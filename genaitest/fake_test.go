@@ -0,0 +1,355 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+func newFakeClient(t *testing.T) (*FakeServer, *genai.Client) {
+	t.Helper()
+	f := NewFakeServer(t)
+	client, err := genai.NewClient(context.Background(), f.ClientConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+func TestFakeServerGenerate(t *testing.T) {
+	ctx := context.Background()
+	f, client := newFakeClient(t)
+
+	resp, err := client.Models.GenerateContent(ctx, testModel, genai.Text("hello fake world"), nil)
+	if err != nil {
+		t.Fatalf("GenerateContent() failed unexpectedly: %v", err)
+	}
+	if got := resp.Text(); got != "hello fake world" {
+		t.Errorf("GenerateContent() = %q, want echo", got)
+	}
+	if got := resp.UsageMetadata.PromptTokenCount; got != 3 {
+		t.Errorf("PromptTokenCount = %d, want 3", got)
+	}
+
+	var chunks []string
+	for resp, err := range client.Models.GenerateContentStream(ctx, testModel, genai.Text("hello fake world"), nil) {
+		if err != nil {
+			t.Fatalf("GenerateContentStream() failed unexpectedly: %v", err)
+		}
+		chunks = append(chunks, resp.Text())
+	}
+	if diff := cmp.Diff([]string{"hello ", "fake ", "world"}, chunks); diff != "" {
+		t.Errorf("GenerateContentStream() chunks mismatch (-want +got):\n%s", diff)
+	}
+
+	f.QueueResponse(&genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: genai.NewContentFromText("scripted", genai.RoleModel)}}})
+	resp, err = client.Models.GenerateContent(ctx, testModel, genai.Text("anything"), nil)
+	if err != nil || resp.Text() != "scripted" {
+		t.Errorf("GenerateContent() with queued response = %v, %v, want %q", resp, err, "scripted")
+	}
+
+	requests := f.Requests("generateContent")
+	if len(requests) != 2 {
+		t.Fatalf("Requests(generateContent) has %d requests, want 2", len(requests))
+	}
+	var req GenerateRequest
+	if err := requests[1].Decode(&req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Contents[0].Parts[0].Text; got != "anything" {
+		t.Errorf("recorded request text = %q, want %q", got, "anything")
+	}
+	if got := requests[1].Header.Get("X-Goog-Api-Key"); got != "fake-api-key" {
+		t.Errorf("recorded API key = %q, want %q", got, "fake-api-key")
+	}
+}
+
+func TestFakeServerGenerateFunc(t *testing.T) {
+	ctx := context.Background()
+	f, client := newFakeClient(t)
+	f.Generate = func(req *GenerateRequest) ([]*genai.GenerateContentResponse, error) {
+		if req.SystemInstruction == nil {
+			return nil, &genai.APIError{Code: http.StatusBadRequest, Message: "system instruction required", Status: "INVALID_ARGUMENT"}
+		}
+		return []*genai.GenerateContentResponse{{Candidates: []*genai.Candidate{{Content: genai.NewContentFromText(req.Model, genai.RoleModel)}}}}, nil
+	}
+
+	_, err := client.Models.GenerateContent(ctx, testModel, genai.Text("hi"), nil)
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+		t.Errorf("GenerateContent() error = %v, want 400 APIError", err)
+	}
+	resp, err := client.Models.GenerateContent(ctx, testModel, genai.Text("hi"), &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText("be brief", genai.RoleUser),
+	})
+	if err != nil || resp.Text() != "models/"+testModel {
+		t.Errorf("GenerateContent() = %v, %v, want the model name", resp, err)
+	}
+}
+
+func TestFakeServerFailuresAndLatency(t *testing.T) {
+	ctx := context.Background()
+	f, client := newFakeClient(t)
+
+	f.FailNext("generateContent", genai.APIError{Code: http.StatusTooManyRequests, Message: "slow down", Status: "RESOURCE_EXHAUSTED"})
+	_, err := client.Models.GenerateContent(ctx, testModel, genai.Text("hi"), nil)
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		t.Errorf("GenerateContent() error = %v, want 429 APIError", err)
+	}
+	if _, err := client.Models.GenerateContent(ctx, testModel, genai.Text("hi"), nil); err != nil {
+		t.Errorf("GenerateContent() after the injected failure failed: %v", err)
+	}
+
+	f.SetLatency("countTokens", time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Models.CountTokens(ctx, testModel, genai.Text("hi"), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CountTokens() with latency error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestFakeServerCountAndEmbed(t *testing.T) {
+	ctx := context.Background()
+	_, client := newFakeClient(t)
+
+	count, err := client.Models.CountTokens(ctx, testModel, genai.Text("one two three four"), nil)
+	if err != nil || count.TotalTokens != 4 {
+		t.Errorf("CountTokens() = %v, %v, want 4", count, err)
+	}
+
+	dims := int32(16)
+	resp, err := client.Models.EmbedContent(ctx, "text-embedding-004", []*genai.Content{
+		genai.NewContentFromText("a", genai.RoleUser),
+		genai.NewContentFromText("b", genai.RoleUser),
+	}, &genai.EmbedContentConfig{OutputDimensionality: &dims})
+	if err != nil {
+		t.Fatalf("EmbedContent() failed unexpectedly: %v", err)
+	}
+	if len(resp.Embeddings) != 2 || len(resp.Embeddings[0].Values) != 16 {
+		t.Fatalf("EmbedContent() = %d embeddings, want 2 of 16 values", len(resp.Embeddings))
+	}
+	if cmp.Equal(resp.Embeddings[0].Values, resp.Embeddings[1].Values) {
+		t.Errorf("embeddings of different texts are equal")
+	}
+}
+
+func TestFakeServerFiles(t *testing.T) {
+	ctx := context.Background()
+	f, client := newFakeClient(t)
+
+	data := bytes.Repeat([]byte("x"), 1000)
+	file, err := client.Files.Upload(ctx, bytes.NewReader(data), &genai.UploadFileConfig{MIMEType: "text/plain", DisplayName: "notes"})
+	if err != nil {
+		t.Fatalf("Upload() failed unexpectedly: %v", err)
+	}
+	if file.State != genai.FileStateActive || *file.SizeBytes != 1000 || file.DisplayName != "notes" || file.MIMEType != "text/plain" {
+		t.Errorf("Upload() = %+v", file)
+	}
+	if got := len(f.Requests("files.upload")); got != 1 {
+		t.Errorf("got %d upload requests, want 1", got)
+	}
+
+	got, err := client.Files.Get(ctx, file.Name, nil)
+	if err != nil || got.Name != file.Name {
+		t.Errorf("Get() = %v, %v, want %s", got, err, file.Name)
+	}
+	var names []string
+	for file, err := range client.Files.All(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, file.Name)
+	}
+	if diff := cmp.Diff([]string{file.Name}, names); diff != "" {
+		t.Errorf("All() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := client.Files.Delete(ctx, file.Name, nil); err != nil {
+		t.Errorf("Delete() failed unexpectedly: %v", err)
+	}
+	if _, err := client.Files.Get(ctx, file.Name, nil); err == nil {
+		t.Errorf("Get() of deleted file got no error, want error")
+	}
+}
+
+func TestFakeServerCaches(t *testing.T) {
+	ctx := context.Background()
+	_, client := newFakeClient(t)
+
+	cache, err := client.Caches.Create(ctx, testModel, &genai.CreateCachedContentConfig{
+		Contents: genai.Text("a long document"),
+		TTL:      10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Create() failed unexpectedly: %v", err)
+	}
+	if d := time.Until(cache.ExpireTime); d < 9*time.Minute || d > 10*time.Minute {
+		t.Errorf("ExpireTime is in %v, want 10m", d)
+	}
+	if cache.UsageMetadata.TotalTokenCount != 3 {
+		t.Errorf("TotalTokenCount = %d, want 3", cache.UsageMetadata.TotalTokenCount)
+	}
+
+	updated, err := client.Caches.Update(ctx, cache.Name, &genai.UpdateCachedContentConfig{TTL: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("Update() failed unexpectedly: %v", err)
+	}
+	if !updated.ExpireTime.After(cache.ExpireTime.Add(time.Hour)) {
+		t.Errorf("Update() ExpireTime = %v, want about 2h from now", updated.ExpireTime)
+	}
+
+	if _, err := client.Models.GenerateContent(ctx, testModel, genai.Text("summarize"), &genai.GenerateContentConfig{CachedContent: cache.Name}); err != nil {
+		t.Errorf("GenerateContent() with cache failed: %v", err)
+	}
+	if _, err := client.Caches.Delete(ctx, cache.Name, nil); err != nil {
+		t.Fatalf("Delete() failed unexpectedly: %v", err)
+	}
+	if _, err := client.Models.GenerateContent(ctx, testModel, genai.Text("summarize"), &genai.GenerateContentConfig{CachedContent: cache.Name}); err == nil {
+		t.Errorf("GenerateContent() with deleted cache got no error, want error")
+	}
+}
+
+func TestFakeServerBatches(t *testing.T) {
+	ctx := context.Background()
+	f, client := newFakeClient(t)
+
+	job, err := client.Batches.Create(ctx, testModel, &genai.BatchJobSource{InlinedRequests: []*genai.InlinedRequest{
+		{Contents: genai.Text("first")},
+		{Contents: genai.Text("second")},
+	}}, &genai.CreateBatchJobConfig{DisplayName: "job"})
+	if err != nil {
+		t.Fatalf("Create() failed unexpectedly: %v", err)
+	}
+	if job.State != genai.JobStatePending || job.DisplayName != "job" {
+		t.Errorf("Create() = %+v, want a pending job", job)
+	}
+
+	if err := f.CompleteBatch(job.Name); err != nil {
+		t.Fatal(err)
+	}
+	job, err = client.Batches.Get(ctx, job.Name, nil)
+	if err != nil {
+		t.Fatalf("Get() failed unexpectedly: %v", err)
+	}
+	if job.State != genai.JobStateSucceeded || job.Dest == nil || len(job.Dest.InlinedResponses) != 2 {
+		t.Fatalf("Get() = %+v, want a succeeded job with 2 responses", job)
+	}
+	if got := job.Dest.InlinedResponses[1].Response.Text(); got != "second" {
+		t.Errorf("second response = %q, want %q", got, "second")
+	}
+
+	other, err := client.Batches.Create(ctx, testModel, &genai.BatchJobSource{InlinedRequests: []*genai.InlinedRequest{{Contents: genai.Text("x")}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Batches.Cancel(ctx, other.Name, nil); err != nil {
+		t.Fatalf("Cancel() failed unexpectedly: %v", err)
+	}
+	states := map[string]genai.JobState{}
+	for job, err := range client.Batches.All(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		states[job.Name] = job.State
+	}
+	want := map[string]genai.JobState{job.Name: genai.JobStateSucceeded, other.Name: genai.JobStateCancelled}
+	if diff := cmp.Diff(want, states); diff != "" {
+		t.Errorf("All() states mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := client.Batches.Delete(ctx, job.Name, nil); err != nil {
+		t.Errorf("Delete() failed unexpectedly: %v", err)
+	}
+}
+
+func TestFakeServerOperations(t *testing.T) {
+	ctx := context.Background()
+	f, client := newFakeClient(t)
+	name := "models/veo-2.0-generate-001/operations/op1"
+	f.SetOperation(name, map[string]any{"name": name, "done": true})
+
+	op, err := client.Operations.GetVideosOperation(ctx, &genai.GenerateVideosOperation{Name: name}, nil)
+	if err != nil {
+		t.Fatalf("GetVideosOperation() failed unexpectedly: %v", err)
+	}
+	if !op.Done {
+		t.Errorf("GetVideosOperation() = %+v, want done", op)
+	}
+	if _, err := client.Operations.GetVideosOperation(ctx, &genai.GenerateVideosOperation{Name: name + "x"}, nil); err == nil {
+		t.Errorf("GetVideosOperation() of unknown operation got no error, want error")
+	}
+}
+
+func TestFakeServerLive(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeServer(t)
+	client, err := genai.NewClient(ctx, f.LiveClientConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := client.Live.Connect(ctx, testModel, &genai.LiveConnectConfig{})
+	if err != nil {
+		t.Fatalf("Connect() failed unexpectedly: %v", err)
+	}
+	defer session.Close()
+
+	receiveText := func() string {
+		t.Helper()
+		var sb strings.Builder
+		for {
+			msg, err := session.Receive()
+			if err != nil {
+				t.Fatalf("Receive() failed unexpectedly: %v", err)
+			}
+			if msg.ServerContent == nil {
+				continue
+			}
+			if msg.ServerContent.ModelTurn != nil {
+				sb.WriteString(contentText(msg.ServerContent.ModelTurn))
+			}
+			if msg.ServerContent.TurnComplete {
+				return sb.String()
+			}
+		}
+	}
+
+	if err := session.SendClientContent(genai.LiveClientContentInput{Turns: genai.Text("hello live"), TurnComplete: genai.Ptr(true)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := receiveText(); got != "hello live" {
+		t.Errorf("reply to client content = %q, want %q", got, "hello live")
+	}
+	if err := session.SendRealtimeInput(genai.LiveRealtimeInput{Text: "realtime"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := receiveText(); got != "realtime" {
+		t.Errorf("reply to realtime input = %q, want %q", got, "realtime")
+	}
+
+	for _, method := range []string{"live.connect", "live.setup", "live.clientContent", "live.realtimeInput"} {
+		if got := len(f.Requests(method)); got != 1 {
+			t.Errorf("got %d %s requests, want 1", got, method)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package genaitest records the HTTP interactions of a [genai.Client] with the
+// API and replays them in hermetic tests.
+//
+// A [Recorder] wraps the HTTP client of a [genai.ClientConfig] and captures
+// every request and response, including the segments of streamed responses.
+// The [Recording] is saved as a JSON file, with API keys and credentials
+// redacted, and checked in next to the tests. A [Replayer] serves the
+// recording from an [httptest.Server], checking that the requests of the code
+// under test match the recorded ones.
+//
+// [NewClient] combines both: it records when the GOOGLE_GENAI_RECORD
+// environment variable is set and replays otherwise. Its recordings are
+// sanitized by a [Sanitizer], which replaces secrets, personal data and
+// volatile values such as timestamps and resource IDs with deterministic
+// placeholders, and the replayer maps the placeholders back. The
+// sanitizereplays command re-sanitizes existing recordings.
+//
+//	func TestSummarize(t *testing.T) {
+//		ctx := context.Background()
+//		client, err := genaitest.NewClient(ctx, t, "testdata/summarize.json", &genai.ClientConfig{Backend: genai.BackendGeminiAPI}, nil)
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		got, err := summarize(ctx, client, "...")
+//		...
+//	}
+//
+// When a test needs to script the API rather than replay it, a [FakeServer]
+// implements the Gemini API in process: content generation, token counting,
+// embeddings, files, cached contents, batches, operations and live sessions.
+// Tests queue responses, inject errors and latency, and inspect the requests
+// the server received.
+package genaitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/auth/httptransport"
+	"google.golang.org/genai"
+)
+
+// Recording is a recorded session: the interactions of a client with the API,
+// in the order the requests were sent.
+type Recording struct {
+	// ReplayID identifies the recording, by default the name of its file.
+	ReplayID string `json:"replayId,omitempty"`
+	// Interactions are the recorded requests and responses.
+	Interactions []*Interaction `json:"interactions,omitempty"`
+}
+
+// Interaction is a request and its response.
+type Interaction struct {
+	Request  *Request  `json:"request,omitempty"`
+	Response *Response `json:"response,omitempty"`
+}
+
+// Request is a recorded HTTP request.
+type Request struct {
+	// Method is the HTTP method, such as "POST".
+	Method string `json:"method,omitempty"`
+	// URL is the full URL of the request.
+	URL string `json:"url,omitempty"`
+	// Headers are the request headers, with lowercase names and the values of
+	// repeated headers joined by commas.
+	Headers map[string]string `json:"headers,omitempty"`
+	// BodySegments holds the body when it is a JSON object.
+	BodySegments []map[string]any `json:"bodySegments,omitempty"`
+	// Body holds the body when it is not a JSON object, such as an uploaded
+	// file.
+	Body []byte `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response.
+type Response struct {
+	// StatusCode is the HTTP status code.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Headers are the response headers, with lowercase names and the values of
+	// repeated headers joined by commas.
+	Headers map[string]string `json:"headers,omitempty"`
+	// BodySegments holds the body when it is a JSON object, or one segment per
+	// event when Stream is set.
+	BodySegments []map[string]any `json:"bodySegments,omitempty"`
+	// Body holds the body when it is not JSON, such as a downloaded file.
+	Body []byte `json:"body,omitempty"`
+	// Stream reports whether the body is a stream of server-sent events, as
+	// returned by the streaming methods such as
+	// [genai.Models.GenerateContentStream].
+	Stream bool `json:"stream,omitempty"`
+}
+
+// Load reads a recording saved by [Recording.Save].
+func Load(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("parsing recording %s: %w", path, err)
+	}
+	return &rec, nil
+}
+
+// Save writes the recording to path as indented JSON, creating its directory
+// if needed.
+func (rec *Recording) Save(path string) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Mode selects whether [NewClient] records or replays.
+type Mode int
+
+const (
+	// ModeAuto records when the GOOGLE_GENAI_RECORD environment variable is set
+	// to a non-empty value and replays otherwise.
+	ModeAuto Mode = iota
+	// ModeReplay replays a saved recording.
+	ModeReplay
+	// ModeRecord sends the requests to the API and saves the recording when
+	// the test ends.
+	ModeRecord
+)
+
+// RecordEnv is the environment variable that switches [ModeAuto] to recording.
+const RecordEnv = "GOOGLE_GENAI_RECORD"
+
+// Config configures [NewClient].
+type Config struct {
+	// Optional. Mode selects recording or replaying. Defaults to [ModeAuto].
+	Mode Mode
+	// Optional. Recorder configures the recorder in [ModeRecord].
+	Recorder RecorderConfig
+	// Optional. Replayer configures the replayer in [ModeReplay].
+	Replayer ReplayerConfig
+	// Optional. Sanitizer sanitizes the recording in [ModeRecord], and maps
+	// its placeholders in [ModeReplay]. It overrides
+	// [RecorderConfig.Sanitizer] and [ReplayerConfig.Sanitizer]. Defaults to
+	// [DefaultSanitizer]; use [NewSanitizer] without rules to save recordings
+	// as they are.
+	Sanitizer *Sanitizer
+}
+
+// NewClient returns a client for a test that records its interactions to, or
+// replays them from, the file at path.
+//
+// When recording, the client sends the requests to the API configured by cc,
+// and the recording is saved when the test ends. If cc has no HTTP client and
+// uses Vertex AI without an API key, the recording client is authorized with
+// cc.Credentials or the default credentials.
+//
+// When replaying, the client sends the requests to a [Replayer] serving the
+// recording. cc doesn't need real credentials: a placeholder API key is used
+// for the Gemini API when none is set. For Vertex AI, cc must name the location
+// the recording was made with, and the project too if the recording isn't
+// sanitized.
+func NewClient(ctx context.Context, t testing.TB, path string, cc *genai.ClientConfig, config *Config) (*genai.Client, error) {
+	t.Helper()
+	if config == nil {
+		config = &Config{}
+	}
+	var ccCopy genai.ClientConfig
+	if cc != nil {
+		ccCopy = *cc
+	}
+	sanitizer := config.Sanitizer
+	if sanitizer == nil {
+		sanitizer = DefaultSanitizer()
+	}
+	mode := config.Mode
+	if mode == ModeAuto {
+		mode = ModeReplay
+		if os.Getenv(RecordEnv) != "" {
+			mode = ModeRecord
+		}
+	}
+
+	switch mode {
+	case ModeRecord:
+		client := ccCopy.HTTPClient
+		needsAuth := client == nil && ccCopy.Backend == genai.BackendVertexAI && ccCopy.APIKey == ""
+		if client == nil {
+			client = &http.Client{}
+		}
+		recorderConfig := config.Recorder
+		recorderConfig.Sanitizer = sanitizer
+		rec := NewRecorder(client, &recorderConfig)
+		ccCopy.HTTPClient = rec.HTTPClient()
+		if needsAuth {
+			if ccCopy.Credentials != nil {
+				if err := httptransport.AddAuthorizationMiddleware(ccCopy.HTTPClient, ccCopy.Credentials); err != nil {
+					return nil, fmt.Errorf("authorizing recording client: %w", err)
+				}
+			} else if err := ccCopy.UseDefaultCredentials(); err != nil {
+				return nil, err
+			}
+		}
+		t.Cleanup(func() {
+			if err := rec.Save(path); err != nil {
+				t.Errorf("saving recording: %v", err)
+			}
+		})
+	case ModeReplay:
+		recording, err := Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading recording: %w", err)
+		}
+		replayerConfig := config.Replayer
+		replayerConfig.Sanitizer = sanitizer
+		r := NewReplayer(t, recording, &replayerConfig)
+		ccCopy.HTTPClient = r.HTTPClient()
+		ccCopy.HTTPOptions.BaseURL = r.URL()
+		if ccCopy.Backend != genai.BackendVertexAI && ccCopy.APIKey == "" {
+			ccCopy.APIKey = "replay-api-key"
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+	return genai.NewClient(ctx, &ccCopy)
+}
+
+func replayID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
@@ -0,0 +1,309 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+const testModel = "gemini-2.0-flash"
+
+// newFakeAPI returns a server that answers generateContent with the text of
+// the request in upper case, and streamGenerateContent with its words.
+func newFakeAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Goog-Api-Key") != "secret-key" {
+			http.Error(w, "missing API key", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Contents []*genai.Content `json:"contents"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		text := req.Contents[0].Parts[0].Text
+		response := func(text string) string {
+			return fmt.Sprintf(`{"candidates":[{"content":{"role":"model","parts":[{"text":%q}]}}]}`, text)
+		}
+		switch r.URL.Path {
+		case "/v1beta/models/" + testModel + ":generateContent":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, response(strings.ToUpper(text)))
+		case "/v1beta/models/" + testModel + ":streamGenerateContent":
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, word := range strings.Fields(text) {
+				fmt.Fprintf(w, "data: %s\r\n\r\n", response(word))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// exercise sends a unary and a streaming request and returns the texts of the
+// responses.
+func exercise(t *testing.T, client *genai.Client, prompt string) []string {
+	t.Helper()
+	ctx := context.Background()
+	var texts []string
+	resp, err := client.Models.GenerateContent(ctx, testModel, genai.Text(prompt), nil)
+	if err != nil {
+		t.Fatalf("GenerateContent() failed unexpectedly: %v", err)
+	}
+	texts = append(texts, resp.Text())
+	for resp, err := range client.Models.GenerateContentStream(ctx, testModel, genai.Text(prompt), nil) {
+		if err != nil {
+			t.Fatalf("GenerateContentStream() failed unexpectedly: %v", err)
+		}
+		texts = append(texts, resp.Text())
+	}
+	return texts
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "testdata", "hello.json")
+	want := []string{"HELLO WORLD", "hello", "world"}
+
+	t.Run("Record", func(t *testing.T) {
+		api := newFakeAPI(t)
+		client, err := NewClient(ctx, t, path, &genai.ClientConfig{
+			Backend:     genai.BackendGeminiAPI,
+			APIKey:      "secret-key",
+			HTTPOptions: genai.HTTPOptions{BaseURL: api.URL},
+		}, &Config{Mode: ModeRecord})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, exercise(t, client, "hello world")); diff != "" {
+			t.Errorf("responses mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Errorf("recording contains the API key:\n%s", data)
+	}
+	rec, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.ReplayID != "hello" || len(rec.Interactions) != 2 {
+		t.Fatalf("Load() = %q with %d interactions, want %q with 2", rec.ReplayID, len(rec.Interactions), "hello")
+	}
+	if stream := rec.Interactions[1].Response; !stream.Stream || len(stream.BodySegments) != 2 {
+		t.Errorf("streamed response = %+v, want 2 event segments", stream)
+	}
+	if got := rec.Interactions[0].Request.Headers["x-goog-api-key"]; got != Redacted {
+		t.Errorf("recorded x-goog-api-key = %q, want %q", got, Redacted)
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		// No API key and no API: everything is served from the recording.
+		client, err := NewClient(ctx, t, path, &genai.ClientConfig{Backend: genai.BackendGeminiAPI}, &Config{Mode: ModeReplay})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, exercise(t, client, "hello world")); diff != "" {
+			t.Errorf("replayed responses mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+// recordingTB records the errors of a test instead of failing it.
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+func (tb *recordingTB) runCleanups() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+func testRecording() *Recording {
+	return &Recording{
+		ReplayID: "test",
+		Interactions: []*Interaction{
+			{
+				Request: &Request{
+					Method:       http.MethodPost,
+					URL:          "https://generativelanguage.googleapis.com/v1beta/models/" + testModel + ":generateContent",
+					BodySegments: []map[string]any{{"contents": []any{map[string]any{"role": "user", "parts": []any{map[string]any{"text": "first"}}}}}},
+				},
+				Response: &Response{
+					StatusCode:   http.StatusOK,
+					BodySegments: []map[string]any{{"candidates": []any{map[string]any{"content": map[string]any{"parts": []any{map[string]any{"text": "1"}}}}}}},
+				},
+			},
+			{
+				Request: &Request{
+					Method:       http.MethodPost,
+					URL:          "https://generativelanguage.googleapis.com/v1beta/models/" + testModel + ":generateContent",
+					BodySegments: []map[string]any{{"contents": []any{map[string]any{"role": "user", "parts": []any{map[string]any{"text": "second"}}}}}},
+				},
+				Response: &Response{
+					StatusCode:   http.StatusOK,
+					BodySegments: []map[string]any{{"candidates": []any{map[string]any{"content": map[string]any{"parts": []any{map[string]any{"text": "2"}}}}}}},
+				},
+			},
+		},
+	}
+}
+
+func newReplayClient(t *testing.T, r *Replayer) *genai.Client {
+	t.Helper()
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		Backend:     genai.BackendGeminiAPI,
+		APIKey:      "key",
+		HTTPClient:  r.HTTPClient(),
+		HTTPOptions: genai.HTTPOptions{BaseURL: r.URL()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestReplayerMatching(t *testing.T) {
+	ctx := context.Background()
+	generate := func(client *genai.Client, prompt string) (string, error) {
+		resp, err := client.Models.GenerateContent(ctx, testModel, genai.Text(prompt), nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Text(), nil
+	}
+
+	t.Run("OutOfOrder", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		client := newReplayClient(t, NewReplayer(tb, testRecording(), nil))
+		if _, err := generate(client, "second"); err == nil {
+			t.Errorf("GenerateContent() out of order got no error, want error")
+		}
+		tb.runCleanups()
+		// The mismatch, then both interactions unused.
+		if len(tb.errors) != 3 {
+			t.Errorf("got %d test errors, want 3: %q", len(tb.errors), tb.errors)
+		}
+	})
+
+	t.Run("Unordered", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		client := newReplayClient(t, NewReplayer(tb, testRecording(), &ReplayerConfig{Unordered: true}))
+		for _, tt := range []struct{ prompt, want string }{{"second", "2"}, {"first", "1"}} {
+			if got, err := generate(client, tt.prompt); err != nil || got != tt.want {
+				t.Errorf("GenerateContent(%q) = %q, %v, want %q", tt.prompt, got, err, tt.want)
+			}
+		}
+		tb.runCleanups()
+		if len(tb.errors) != 0 {
+			t.Errorf("got test errors %q, want none", tb.errors)
+		}
+	})
+
+	t.Run("CustomMatch", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		client := newReplayClient(t, NewReplayer(tb, testRecording(), &ReplayerConfig{Match: MatchMethodAndPath, AllowUnused: true}))
+		if got, err := generate(client, "other prompt"); err != nil || got != "1" {
+			t.Errorf("GenerateContent() = %q, %v, want %q", got, err, "1")
+		}
+		tb.runCleanups()
+		if len(tb.errors) != 0 {
+			t.Errorf("got test errors %q, want none", tb.errors)
+		}
+	})
+}
+
+func TestRecorderRedaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Session-Token", "server-secret")
+		w.Write([]byte("raw bytes"))
+	}))
+	defer ts.Close()
+
+	rec := NewRecorder(nil, &RecorderConfig{
+		RedactHeaders: []string{"X-Session-Token"},
+		Redact: func(i *Interaction) {
+			i.Response.Body = []byte("redacted body")
+		},
+	})
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/upload?key=api-key&alt=json", strings.NewReader("file contents"))
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := rec.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(got) != "raw bytes" {
+		t.Errorf("response body = %q, want %q", got, "raw bytes")
+	}
+
+	want := &Interaction{
+		Request: &Request{
+			Method:  http.MethodPost,
+			URL:     ts.URL + "/upload?alt=json&key=%7BREDACTED%7D",
+			Headers: map[string]string{"authorization": Redacted},
+			Body:    []byte("file contents"),
+		},
+		Response: &Response{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"x-session-token": Redacted, "content-type": "text/plain; charset=utf-8"},
+			Body:       []byte("redacted body"),
+		},
+	}
+	interactions := rec.Recording().Interactions
+	if len(interactions) != 1 {
+		t.Fatalf("recorded %d interactions, want 1", len(interactions))
+	}
+	delete(interactions[0].Response.Headers, "date")
+	if diff := cmp.Diff(want, interactions[0]); diff != "" {
+		t.Errorf("recorded interaction mismatch (-want +got):\n%s", diff)
+	}
+}
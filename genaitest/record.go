@@ -0,0 +1,267 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the values of redacted headers and query parameters.
+const Redacted = "{REDACTED}"
+
+// redactedHeaders are always redacted: they carry API keys and credentials.
+var redactedHeaders = []string{"authorization", "x-goog-api-key", "x-goog-user-project"}
+
+// ignoredHeaders are not recorded: they depend on the transport.
+var ignoredHeaders = []string{"accept-encoding", "content-encoding", "content-length", "transfer-encoding"}
+
+// RecorderConfig configures a [Recorder].
+type RecorderConfig struct {
+	// Optional. RedactHeaders are the names of headers whose values are
+	// replaced with [Redacted], in addition to the Authorization,
+	// X-Goog-Api-Key and X-Goog-User-Project headers. The "key" query
+	// parameter is always redacted.
+	RedactHeaders []string
+	// Optional. Redact is called with every interaction before it is added to
+	// the recording, to remove other sensitive data, for example from bodies.
+	Redact func(*Interaction)
+	// Optional. Sanitizer sanitizes the recording when it is saved by
+	// [Recorder.Save].
+	Sanitizer *Sanitizer
+}
+
+// Recorder is an [http.RoundTripper] that records the requests it sends and
+// the responses it receives. A Recorder is safe for concurrent use by multiple
+// goroutines.
+type Recorder struct {
+	transport http.RoundTripper
+	config    RecorderConfig
+
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+// NewRecorder returns a [Recorder] that sends the requests with the transport
+// of client, or [http.DefaultTransport] if client or its transport is nil.
+func NewRecorder(client *http.Client, config *RecorderConfig) *Recorder {
+	r := &Recorder{transport: http.DefaultTransport}
+	if client != nil && client.Transport != nil {
+		r.transport = client.Transport
+	}
+	if config != nil {
+		r.config = *config
+	}
+	return r
+}
+
+// HTTPClient returns an HTTP client that records with r, to be set as
+// [genai.ClientConfig.HTTPClient].
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip sends req and records it with its response. The response is
+// recorded when its body has been read to the end or closed.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := newRequest(req, body, r.config.RedactHeaders)
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{Request: recorded}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+
+	header := resp.Header.Clone()
+	status := resp.StatusCode
+	resp.Body = &recordingBody{
+		rc: resp.Body,
+		done: func(data []byte) {
+			response := newResponse(status, header, data, r.config.RedactHeaders)
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			interaction.Response = response
+			if r.config.Redact != nil {
+				r.config.Redact(interaction)
+			}
+		},
+	}
+	return resp, nil
+}
+
+// Recording returns the interactions recorded so far. Interactions whose
+// response body hasn't been read to the end or closed have no response.
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Recording{Interactions: slices.Clone(r.interactions)}
+}
+
+// Save writes the interactions recorded so far to path, sanitized by
+// [RecorderConfig.Sanitizer].
+func (r *Recorder) Save(path string) error {
+	rec := r.Recording()
+	rec.ReplayID = replayID(path)
+	if r.config.Sanitizer != nil {
+		if err := r.config.Sanitizer.Sanitize(rec); err != nil {
+			return fmt.Errorf("sanitizing recording: %w", err)
+		}
+	}
+	return rec.Save(path)
+}
+
+// recordingBody captures a response body as it is read and calls done with it
+// once, at the end of the body or when it is closed.
+type recordingBody struct {
+	rc   io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b.buf.Bytes()) })
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+	return b.rc.Close()
+}
+
+// newRequest converts req, whose body has been read into body, to a recorded
+// request.
+func newRequest(req *http.Request, body []byte, redact []string) *Request {
+	u := *req.URL
+	if q := u.Query(); q.Has("key") {
+		q.Set("key", Redacted)
+		u.RawQuery = q.Encode()
+	}
+	recorded := &Request{
+		Method:  req.Method,
+		URL:     u.String(),
+		Headers: headerMap(req.Header, redact),
+	}
+	recorded.BodySegments, recorded.Body = splitBody(body)
+	return recorded
+}
+
+func newResponse(status int, header http.Header, body []byte, redact []string) *Response {
+	resp := &Response{
+		StatusCode: status,
+		Headers:    headerMap(header, redact),
+	}
+	if isEventStream(header, body) {
+		resp.Stream = true
+		for _, event := range splitEvents(body) {
+			var segment map[string]any
+			if err := json.Unmarshal(event, &segment); err != nil {
+				// Not a stream of JSON events after all; keep the raw body.
+				resp.Stream = false
+				resp.BodySegments = nil
+				resp.Body = body
+				return resp
+			}
+			resp.BodySegments = append(resp.BodySegments, segment)
+		}
+		return resp
+	}
+	resp.BodySegments, resp.Body = splitBody(body)
+	return resp
+}
+
+// splitBody returns body as a JSON object segment, or as is when it isn't a
+// JSON object.
+func splitBody(body []byte) ([]map[string]any, []byte) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	var segment map[string]any
+	if err := json.Unmarshal(body, &segment); err == nil && segment != nil {
+		return []map[string]any{segment}, nil
+	}
+	return nil, body
+}
+
+func isEventStream(header http.Header, body []byte) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") || bytes.HasPrefix(body, []byte("data:"))
+}
+
+// splitEvents returns the data of the server-sent events of body. Events are
+// separated by blank lines.
+func splitEvents(body []byte) [][]byte {
+	var events [][]byte
+	body = bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n"))
+	for _, event := range bytes.Split(body, []byte("\n\n")) {
+		event = bytes.TrimSpace(event)
+		if data, ok := bytes.CutPrefix(event, []byte("data:")); ok {
+			events = append(events, bytes.TrimSpace(data))
+		}
+	}
+	return events
+}
+
+// headerMap converts header to lowercase names and comma-joined values,
+// dropping transport headers and redacting credentials.
+func headerMap(header http.Header, redact []string) map[string]string {
+	m := make(map[string]string, len(header))
+	for name, values := range header {
+		name = strings.ToLower(name)
+		switch {
+		case slices.Contains(ignoredHeaders, name):
+			continue
+		case slices.Contains(redactedHeaders, name) || slices.ContainsFunc(redact, func(r string) bool { return strings.EqualFold(r, name) }):
+			m[name] = Redacted
+		default:
+			m[name] = strings.Join(values, ",")
+		}
+	}
+	return m
+}
+
+// requestPath returns the path and canonical query of a recorded URL.
+func requestPath(rawURL string) (string, url.Values) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, nil
+	}
+	return u.Path, u.Query()
+}
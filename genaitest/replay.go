@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// MatchFunc reports whether a request received by a [Replayer] matches a
+// recorded request. Both requests are redacted the same way, and got.URL
+// has no scheme and host.
+type MatchFunc func(got, want *Request) bool
+
+// MatchMethodAndPath matches requests with the same method, URL path and
+// query.
+func MatchMethodAndPath(got, want *Request) bool {
+	gotPath, gotQuery := requestPath(got.URL)
+	wantPath, wantQuery := requestPath(want.URL)
+	return got.Method == want.Method && gotPath == wantPath && gotQuery.Encode() == wantQuery.Encode()
+}
+
+// MatchBody matches requests with the same body. JSON bodies are compared as
+// values, so the order of their fields doesn't matter.
+func MatchBody(got, want *Request) bool {
+	return reflect.DeepEqual(got.BodySegments, want.BodySegments) && bytes.Equal(got.Body, want.Body)
+}
+
+// DefaultMatch matches requests with the same method, URL path, query and
+// body. Headers are not compared, since they include the SDK version.
+func DefaultMatch(got, want *Request) bool {
+	return MatchMethodAndPath(got, want) && MatchBody(got, want)
+}
+
+// ReplayerConfig configures a [Replayer].
+type ReplayerConfig struct {
+	// Optional. Match reports whether a request matches a recorded one.
+	// Defaults to [DefaultMatch].
+	Match MatchFunc
+	// Optional. Unordered serves each request with the first unused
+	// interaction that matches it, instead of requiring the requests to come
+	// in the recorded order. Use it when the code under test sends requests
+	// concurrently.
+	Unordered bool
+	// Optional. AllowUnused doesn't fail the test when some interactions
+	// haven't been replayed when it ends.
+	AllowUnused bool
+	// Optional. RedactHeaders are the headers redacted when recording, if it
+	// was configured with [RecorderConfig.RedactHeaders]. They are redacted
+	// in the received requests before matching.
+	RedactHeaders []string
+	// Optional. Sanitizer is the sanitizer the recording was saved with. The
+	// received requests are sanitized before matching, and the placeholders
+	// of the recorded responses are served as synthetic values, which are
+	// mapped back to the placeholders when the client sends them again.
+	// Placeholders are numbered in the order of the requests, so with
+	// Unordered, requests sent concurrently may not match.
+	Sanitizer *Sanitizer
+}
+
+// Replayer is an HTTP server that replays a recording. A request that
+// doesn't match the next recorded one fails the test and gets a
+// 400 Bad Request response.
+type Replayer struct {
+	t         testing.TB
+	server    *httptest.Server
+	recording *Recording
+	config    ReplayerConfig
+
+	mu       sync.Mutex
+	used     []bool
+	next     int
+	sanitize *sanitizeState
+}
+
+// NewReplayer starts a server that replays recording for the duration of the
+// test t.
+func NewReplayer(t testing.TB, recording *Recording, config *ReplayerConfig) *Replayer {
+	t.Helper()
+	r := &Replayer{
+		t:         t,
+		recording: recording,
+		used:      make([]bool, len(recording.Interactions)),
+	}
+	if config != nil {
+		r.config = *config
+	}
+	if r.config.Match == nil {
+		r.config.Match = DefaultMatch
+	}
+	if r.config.Sanitizer != nil {
+		r.sanitize = r.config.Sanitizer.newState(nil)
+	}
+	r.server = httptest.NewServer(r)
+	t.Cleanup(func() {
+		r.server.Close()
+		if r.config.AllowUnused {
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, used := range r.used {
+			if !used {
+				want := recording.Interactions[i].Request
+				t.Errorf("recording %s: interaction %d (%s %s) was not replayed", recording.ReplayID, i, want.Method, want.URL)
+			}
+		}
+	})
+	return r
+}
+
+// URL returns the base URL of the server, to be set as
+// [genai.HTTPOptions.BaseURL].
+func (r *Replayer) URL() string {
+	return r.server.URL
+}
+
+// HTTPClient returns an HTTP client for the server, to be set as
+// [genai.ClientConfig.HTTPClient].
+func (r *Replayer) HTTPClient() *http.Client {
+	return r.server.Client()
+}
+
+// ServeHTTP serves req with the recorded response of the interaction it
+// matches.
+func (r *Replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	got := newRequest(req, body, r.config.RedactHeaders)
+
+	interaction, resp, err := r.match(got)
+	if err != nil {
+		r.t.Errorf("recording %s: %v", r.recording.ReplayID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+			"status":  "FAILED_PRECONDITION",
+		}})
+		return
+	}
+	r.writeResponse(w, interaction.Request, resp)
+}
+
+// match returns the interaction that serves got, marks it used and returns
+// its response as replayed.
+func (r *Replayer) match(got *Request) (*Interaction, *Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sanitize != nil {
+		if err := r.sanitize.request(got); err != nil {
+			return nil, nil, err
+		}
+	}
+	interaction, err := r.find(got)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp := interaction.Response
+	if resp == nil {
+		resp = &Response{}
+	}
+	if r.sanitize != nil {
+		if resp, err = r.sanitize.response(resp); err != nil {
+			return nil, nil, err
+		}
+	}
+	return interaction, resp, nil
+}
+
+// find returns the interaction that serves got and marks it used. The caller
+// must hold r.mu.
+func (r *Replayer) find(got *Request) (*Interaction, error) {
+	if !r.config.Unordered {
+		if r.next >= len(r.recording.Interactions) {
+			return nil, fmt.Errorf("unexpected request %s %s: all %d interactions were replayed", got.Method, got.URL, len(r.recording.Interactions))
+		}
+		interaction := r.recording.Interactions[r.next]
+		if !r.config.Match(got, interaction.Request) {
+			return nil, fmt.Errorf("request %s %s doesn't match interaction %d (%s %s)\ngot body:  %s\nwant body: %s",
+				got.Method, got.URL, r.next, interaction.Request.Method, interaction.Request.URL, bodyString(got), bodyString(interaction.Request))
+		}
+		r.used[r.next] = true
+		r.next++
+		return interaction, nil
+	}
+	for i, interaction := range r.recording.Interactions {
+		if !r.used[i] && r.config.Match(got, interaction.Request) {
+			r.used[i] = true
+			return interaction, nil
+		}
+	}
+	return nil, fmt.Errorf("request %s %s matches no unused interaction\ngot body: %s", got.Method, got.URL, bodyString(got))
+}
+
+func (r *Replayer) writeResponse(w http.ResponseWriter, req *Request, resp *Response) {
+	// Absolute URLs, such as the upload URL of a resumable upload, point to the
+	// API; point them to the server instead.
+	var origin string
+	if u, err := url.Parse(req.URL); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+	}
+	for name, value := range resp.Headers {
+		if origin != "" {
+			value = strings.ReplaceAll(value, origin, r.server.URL)
+		}
+		w.Header().Set(name, value)
+	}
+	status := resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	switch {
+	case resp.Stream:
+		for _, segment := range resp.BodySegments {
+			data, err := json.Marshal(segment)
+			if err != nil {
+				r.t.Errorf("recording %s: %v", r.recording.ReplayID, err)
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+	case len(resp.BodySegments) > 0:
+		json.NewEncoder(w).Encode(resp.BodySegments[0])
+	default:
+		w.Write(resp.Body)
+	}
+}
+
+func bodyString(req *Request) string {
+	if len(req.BodySegments) > 0 {
+		data, _ := json.Marshal(req.BodySegments)
+		return string(data)
+	}
+	return string(req.Body)
+}
@@ -0,0 +1,431 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Replacement is how a [Rule] replaces the text it matches.
+type Replacement int
+
+const (
+	// ReplaceRedacted replaces matches with [Redacted]. Use it for secrets,
+	// which are never needed in replay.
+	ReplaceRedacted Replacement = iota
+	// ReplaceNumbered replaces matches with placeholders numbered in order of
+	// appearance, such as "{EMAIL_1}". Equal values get the same placeholder
+	// within a recording. A [Replayer] serves the placeholders as synthetic
+	// values and maps them back when the client sends them again, so that
+	// volatile values such as resource IDs and timestamps round trip.
+	ReplaceNumbered
+	// ReplaceHash replaces base64 matches with a hash of their decoded
+	// content, such as "{MEDIA:5f2b6c1d9e0a4b37}". Requests sending the same
+	// content still match the recording.
+	ReplaceHash
+)
+
+// Rule is a sanitization rule: it replaces the text matched by Pattern in the
+// URLs, headers and JSON bodies of a recording.
+type Rule struct {
+	// Name names the placeholders of the rule. It must be uppercase letters,
+	// digits and underscores.
+	Name string
+	// Pattern matches the text to replace. If it has a parenthesized
+	// subexpression, only the text of the first one is replaced.
+	Pattern *regexp.Regexp
+	// Optional. Fields restricts the rule to the values of the JSON fields and
+	// headers with these names.
+	Fields []string
+	// Replacement is how matches are replaced.
+	Replacement Replacement
+	// Optional. Value returns the value replayed for the n-th placeholder of a
+	// [ReplaceNumbered] rule. It must return text that Pattern matches.
+	// Defaults to the lowercase name followed by n, such as "email-1".
+	Value func(n int) string
+}
+
+// replayEpoch is the time of the first timestamp served in replay.
+var replayEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// DefaultRules returns the rules of [DefaultSanitizer]. They redact API keys
+// and bearer and OAuth tokens, number project IDs, email addresses, RFC 3339
+// timestamps, resource IDs and upload IDs, and hash base64 media.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "API_KEY", Pattern: regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`)},
+		{Name: "BEARER_TOKEN", Pattern: regexp.MustCompile(`(?i)\bbearer\s+([^\s",]+)`)},
+		{Name: "ACCESS_TOKEN", Pattern: regexp.MustCompile(`\bya29\.[0-9A-Za-z_.-]+`)},
+		{
+			Name:        "PROJECT",
+			Pattern:     regexp.MustCompile(`\bprojects/([^/\s"{}?&#]+)`),
+			Replacement: ReplaceNumbered,
+		},
+		{
+			Name:        "EMAIL",
+			Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+			Replacement: ReplaceNumbered,
+			Value:       func(n int) string { return fmt.Sprintf("user%d@example.com", n) },
+		},
+		{
+			Name:        "TIMESTAMP",
+			Pattern:     regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})$`),
+			Replacement: ReplaceNumbered,
+			Value: func(n int) string {
+				return replayEpoch.Add(time.Duration(n) * time.Second).Format(time.RFC3339)
+			},
+		},
+		{
+			Name:        "ID",
+			Pattern:     regexp.MustCompile(`\b(?:batches|batchPredictionJobs|cachedContents|customJobs|documents|files|fileSearchStores|operations|ragCorpora|ragFiles|tunedModels|tuningJobs)/([0-9A-Za-z_-]+)`),
+			Replacement: ReplaceNumbered,
+		},
+		{
+			Name:        "UPLOAD_ID",
+			Pattern:     regexp.MustCompile(`[?&]upload_id=([^&\s"]+)`),
+			Replacement: ReplaceNumbered,
+		},
+		{
+			Name:        "MEDIA",
+			Pattern:     regexp.MustCompile(`^[A-Za-z0-9+/_-]{64,}={0,2}$`),
+			Fields:      []string{"data", "bytesBase64Encoded", "bytes_base64_encoded", "imageBytes", "image_bytes", "videoBytes", "video_bytes"},
+			Replacement: ReplaceHash,
+		},
+	}
+}
+
+// Sanitizer rewrites the secrets, personal data and volatile values of
+// recordings before they are checked in, following a list of rules. It is
+// deterministic: sanitizing the same recording twice gives the same result,
+// and sanitizing a sanitized recording leaves it unchanged.
+//
+// The raw bodies of requests and responses, such as uploaded files, are not
+// sanitized.
+type Sanitizer struct {
+	rules []Rule
+}
+
+// NewSanitizer returns a [Sanitizer] that applies rules in order. A Sanitizer
+// without rules leaves recordings unchanged.
+func NewSanitizer(rules ...Rule) *Sanitizer {
+	return &Sanitizer{rules: rules}
+}
+
+// DefaultSanitizer returns a [Sanitizer] with the [DefaultRules].
+func DefaultSanitizer() *Sanitizer {
+	return NewSanitizer(DefaultRules()...)
+}
+
+// Sanitize sanitizes rec in place.
+func (s *Sanitizer) Sanitize(rec *Recording) error {
+	var doc map[string]any
+	if err := convert(rec, &doc); err != nil {
+		return err
+	}
+	s.newState(doc).recording(doc)
+	var sanitized Recording
+	if err := convert(doc, &sanitized); err != nil {
+		return err
+	}
+	*rec = sanitized
+	return nil
+}
+
+// SanitizeJSON sanitizes a recording file saved by [Recording.Save], and keeps
+// the fields it doesn't know. If data needs no changes, it is returned as is.
+//
+// The snake_case replay files shared with the other SDKs are rejected: the
+// tests that replay them don't map placeholders back to values.
+func (s *Sanitizer) SanitizeJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc, orig map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing recording: %w", err)
+	}
+	if snakeCaseReplay(doc) {
+		return nil, fmt.Errorf("not a genaitest recording: snake_case replay files are not supported")
+	}
+	dec = json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.Decode(&orig)
+
+	s.newState(doc).recording(doc)
+	if reflect.DeepEqual(doc, orig) {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// placeholderPattern matches numbered placeholders, such as "{ID_1}", and
+// hash placeholders, such as "{MEDIA:5f2b6c1d9e0a4b37}".
+var placeholderPattern = regexp.MustCompile(`\{([A-Z][A-Z0-9_]*)(?:_(\d+)|:([0-9a-f]+))\}`)
+
+// sanitizeState is the state of the sanitization of a recording. A
+// [Replayer] keeps one for the whole replay, so that the requests it receives
+// get the same placeholders as the recorded ones.
+type sanitizeState struct {
+	rules  []Rule
+	counts map[string]int
+	// known maps a rule name and a value to its placeholder.
+	known map[string]string
+}
+
+// newState returns a state for sanitizing doc, numbering new placeholders
+// after those doc already has.
+func (s *Sanitizer) newState(doc any) *sanitizeState {
+	st := &sanitizeState{rules: s.rules, counts: map[string]int{}, known: map[string]string{}}
+	walkStrings(doc, "", func(v, _ string) string {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(v, -1) {
+			if n, err := strconv.Atoi(m[2]); err == nil {
+				st.counts[m[1]] = max(st.counts[m[1]], n)
+			}
+		}
+		return v
+	})
+	return st
+}
+
+// snakeCaseReplay reports whether doc is a replay file in the snake_case
+// format shared with the other SDKs.
+func snakeCaseReplay(doc map[string]any) bool {
+	if _, ok := doc["replay_id"]; ok {
+		return true
+	}
+	interactions, _ := doc["interactions"].([]any)
+	for _, interaction := range interactions {
+		interaction, _ := interaction.(map[string]any)
+		for _, key := range []string{"request", "response"} {
+			m, _ := interaction[key].(map[string]any)
+			for _, field := range []string{"body_segments", "status_code", "sdk_response_segments"} {
+				if _, ok := m[field]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// recording sanitizes the interactions of a recording file in order.
+func (st *sanitizeState) recording(doc map[string]any) {
+	interactions, _ := doc["interactions"].([]any)
+	for _, interaction := range interactions {
+		interaction, ok := interaction.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"request", "response"} {
+			if m, ok := interaction[key].(map[string]any); ok {
+				st.message(m)
+			}
+		}
+	}
+}
+
+// opaqueFields are the fields of recorded messages that are not sanitized.
+var opaqueFields = []string{"body", "method", "stream", "statusCode"}
+
+// message sanitizes a recorded request or response.
+func (st *sanitizeState) message(m map[string]any) {
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if !slices.Contains(opaqueFields, key) {
+			m[key] = walkStrings(m[key], key, st.sanitize)
+		}
+	}
+}
+
+// sanitize applies the rules to the value v of field.
+func (st *sanitizeState) sanitize(v, field string) string {
+	for _, rule := range st.rules {
+		if len(rule.Fields) > 0 && !slices.Contains(rule.Fields, field) {
+			continue
+		}
+		v = replaceMatches(rule.Pattern, v, func(match string) string {
+			return st.replace(rule, match)
+		})
+	}
+	return v
+}
+
+func (st *sanitizeState) replace(rule Rule, match string) string {
+	if p, ok := st.known[rule.Name+"\x00"+match]; ok {
+		return p
+	}
+	var p string
+	switch rule.Replacement {
+	case ReplaceNumbered:
+		st.counts[rule.Name]++
+		p = fmt.Sprintf("{%s_%d}", rule.Name, st.counts[rule.Name])
+	case ReplaceHash:
+		data, err := decodeBase64(match)
+		if err != nil {
+			return match
+		}
+		sum := sha256.Sum256(data)
+		p = fmt.Sprintf("{%s:%x}", rule.Name, sum[:8])
+	default:
+		return Redacted
+	}
+	st.known[rule.Name+"\x00"+match] = p
+	return p
+}
+
+// expand replaces the placeholders in the values of m with the values they
+// are replayed as, and remembers them so that they are mapped back.
+func (st *sanitizeState) expand(m map[string]any) {
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if slices.Contains(opaqueFields, key) {
+			continue
+		}
+		m[key] = walkStrings(m[key], key, func(v, _ string) string {
+			return placeholderPattern.ReplaceAllStringFunc(v, st.value)
+		})
+	}
+}
+
+// value returns the value placeholder p is replayed as.
+func (st *sanitizeState) value(p string) string {
+	m := placeholderPattern.FindStringSubmatch(p)
+	i := slices.IndexFunc(st.rules, func(r Rule) bool { return r.Name == m[1] })
+	if i < 0 {
+		return p
+	}
+	rule := st.rules[i]
+	var v string
+	switch n, err := strconv.Atoi(m[2]); {
+	case rule.Replacement == ReplaceHash && m[3] != "":
+		v = base64.StdEncoding.EncodeToString([]byte(p))
+	case rule.Replacement == ReplaceNumbered && err == nil:
+		if rule.Value != nil {
+			v = rule.Value(n)
+		} else {
+			v = strings.ToLower(strings.ReplaceAll(rule.Name, "_", "-")) + "-" + strconv.Itoa(n)
+		}
+		st.counts[rule.Name] = max(st.counts[rule.Name], n)
+	default:
+		return p
+	}
+	st.known[rule.Name+"\x00"+v] = p
+	return v
+}
+
+// walkStrings replaces the strings in v, a value decoded from JSON, with the
+// result of f. Maps are walked in key order, so that placeholders are
+// numbered deterministically, and f gets the name of the field or header the
+// string belongs to.
+func walkStrings(v any, field string, f func(v, field string) string) any {
+	switch v := v.(type) {
+	case string:
+		return f(v, field)
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			v[key] = walkStrings(v[key], key, f)
+		}
+	case []any:
+		for i := range v {
+			v[i] = walkStrings(v[i], field, f)
+		}
+	}
+	return v
+}
+
+// replaceMatches replaces the matches of re in s, or the text of their first
+// subexpression, with the result of f.
+func replaceMatches(re *regexp.Regexp, s string, f func(string) string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(f(s[start:end]))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if data, err := enc.DecodeString(s); err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 %q", s)
+}
+
+// convert converts in to out through JSON.
+func convert(in, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// request sanitizes a request received in replay in place.
+func (st *sanitizeState) request(req *Request) error {
+	var m map[string]any
+	if err := convert(req, &m); err != nil {
+		return err
+	}
+	st.message(m)
+	var sanitized Request
+	if err := convert(m, &sanitized); err != nil {
+		return err
+	}
+	*req = sanitized
+	return nil
+}
+
+// response returns a copy of a recorded response with its placeholders
+// replaced by the values they are replayed as.
+func (st *sanitizeState) response(resp *Response) (*Response, error) {
+	var m map[string]any
+	if err := convert(resp, &m); err != nil {
+		return nil, err
+	}
+	st.expand(m)
+	var replayed Response
+	if err := convert(m, &replayed); err != nil {
+		return nil, err
+	}
+	return &replayed, nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genaitest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+func TestSanitize(t *testing.T) {
+	media := bytes.Repeat([]byte{0, 1, 2, 3}, 32)
+	sum := sha256.Sum256(media)
+	apiKey := "AIza" + strings.Repeat("x", 35)
+	cache := func(name string) map[string]any {
+		return map[string]any{
+			"name":       name,
+			"createTime": "2025-06-01T10:00:00.123456Z",
+			"updateTime": "2025-06-01T10:00:00.123456Z",
+			"expireTime": "2025-06-01T11:00:00Z",
+		}
+	}
+	rec := &Recording{
+		ReplayID: "caches",
+		Interactions: []*Interaction{
+			{
+				Request: &Request{
+					Method:  http.MethodPost,
+					URL:     "https://us-central1-aiplatform.googleapis.com/v1beta1/projects/my-project/locations/us-central1/cachedContents",
+					Headers: map[string]string{"x-forwarded-authorization": "Bearer secret-token"},
+					BodySegments: []map[string]any{{
+						"model": "projects/my-project/locations/us-central1/publishers/google/models/gemini-2.0-flash",
+						"contents": []any{map[string]any{"parts": []any{
+							map[string]any{"inlineData": map[string]any{"data": base64.StdEncoding.EncodeToString(media), "mimeType": "image/png"}},
+							map[string]any{"text": "mail alice@example.org with key " + apiKey},
+						}}},
+					}},
+				},
+				Response: &Response{
+					StatusCode:   http.StatusOK,
+					BodySegments: []map[string]any{cache("projects/my-project/locations/us-central1/cachedContents/123")},
+				},
+			},
+			{
+				Request: &Request{
+					Method: http.MethodGet,
+					URL:    "https://us-central1-aiplatform.googleapis.com/v1beta1/projects/my-project/locations/us-central1/cachedContents/123",
+				},
+				Response: &Response{
+					StatusCode:   http.StatusOK,
+					BodySegments: []map[string]any{cache("projects/my-project/locations/us-central1/cachedContents/123")},
+				},
+			},
+		},
+	}
+
+	s := DefaultSanitizer()
+	if err := s.Sanitize(rec); err != nil {
+		t.Fatal(err)
+	}
+	sanitizedCache := map[string]any{
+		"name":       "projects/{PROJECT_1}/locations/us-central1/cachedContents/{ID_1}",
+		"createTime": "{TIMESTAMP_1}",
+		"updateTime": "{TIMESTAMP_1}",
+		"expireTime": "{TIMESTAMP_2}",
+	}
+	want := &Recording{
+		ReplayID: "caches",
+		Interactions: []*Interaction{
+			{
+				Request: &Request{
+					Method:  http.MethodPost,
+					URL:     "https://us-central1-aiplatform.googleapis.com/v1beta1/projects/{PROJECT_1}/locations/us-central1/cachedContents",
+					Headers: map[string]string{"x-forwarded-authorization": "Bearer " + Redacted},
+					BodySegments: []map[string]any{{
+						"model": "projects/{PROJECT_1}/locations/us-central1/publishers/google/models/gemini-2.0-flash",
+						"contents": []any{map[string]any{"parts": []any{
+							map[string]any{"inlineData": map[string]any{"data": fmt.Sprintf("{MEDIA:%x}", sum[:8]), "mimeType": "image/png"}},
+							map[string]any{"text": "mail {EMAIL_1} with key " + Redacted},
+						}}},
+					}},
+				},
+				Response: &Response{StatusCode: http.StatusOK, BodySegments: []map[string]any{sanitizedCache}},
+			},
+			{
+				Request: &Request{
+					Method: http.MethodGet,
+					URL:    "https://us-central1-aiplatform.googleapis.com/v1beta1/projects/{PROJECT_1}/locations/us-central1/cachedContents/{ID_1}",
+				},
+				Response: &Response{StatusCode: http.StatusOK, BodySegments: []map[string]any{sanitizedCache}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, rec); diff != "" {
+		t.Errorf("Sanitize() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Sanitizing again changes nothing.
+	path := filepath.Join(t.TempDir(), "caches.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.SanitizeJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("SanitizeJSON() of a sanitized recording changed it:\n%s", got)
+	}
+}
+
+func TestSanitizeJSON(t *testing.T) {
+	// A recording with a field genaitest doesn't know.
+	data := []byte(`{
+  "replayId": "tunings/get",
+  "interactions": [
+    {
+      "request": {
+        "method": "GET",
+        "url": "https://us-central1-aiplatform.googleapis.com/v1beta1/projects/123456/locations/us-central1/tuningJobs/789",
+        "headers": {"x-goog-request-reason": "run by owner@example.com"}
+      },
+      "response": {
+        "statusCode": 200,
+        "bodySegments": [{"name": "projects/123456/locations/us-central1/tuningJobs/789", "tokenCount": 12345678901234567}],
+        "sdkResponseSegments": [{"createTime": "2025-01-02T03:04:05Z"}]
+      }
+    }
+  ]
+}`)
+	got, err := DefaultSanitizer().SanitizeJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"replayId": "tunings/get"`,
+		`"url": "https://us-central1-aiplatform.googleapis.com/v1beta1/projects/{PROJECT_1}/locations/us-central1/tuningJobs/{ID_1}"`,
+		`"x-goog-request-reason": "run by {EMAIL_1}"`,
+		`"name": "projects/{PROJECT_1}/locations/us-central1/tuningJobs/{ID_1}"`,
+		`"tokenCount": 12345678901234567`,
+		`"createTime": "{TIMESTAMP_1}"`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("SanitizeJSON() = %s\nwant it to contain %s", got, want)
+		}
+	}
+
+	if _, err := DefaultSanitizer().SanitizeJSON([]byte("not json")); err == nil {
+		t.Errorf("SanitizeJSON() of invalid JSON got no error, want error")
+	}
+	// The snake_case replay files shared with the other SDKs are not replayed
+	// with the placeholders mapped back.
+	snake := []byte(`{"replay_id": "models/get", "interactions": [{"response": {"status_code": 200}}]}`)
+	if _, err := DefaultSanitizer().SanitizeJSON(snake); err == nil {
+		t.Errorf("SanitizeJSON() of a snake_case replay file got no error, want error")
+	}
+}
+
+func TestSanitizedRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "resources.json")
+
+	// exercise creates a cache and a file and gets them back by the names the
+	// API returned.
+	exercise := func(t *testing.T, client *genai.Client) (*genai.CachedContent, *genai.File) {
+		t.Helper()
+		cache, err := client.Caches.Create(ctx, testModel, &genai.CreateCachedContentConfig{
+			Contents: genai.Text("a long document"),
+			TTL:      10 * time.Minute,
+		})
+		if err != nil {
+			t.Fatalf("Caches.Create() failed unexpectedly: %v", err)
+		}
+		if cache, err = client.Caches.Get(ctx, cache.Name, nil); err != nil {
+			t.Fatalf("Caches.Get() failed unexpectedly: %v", err)
+		}
+		file, err := client.Files.Upload(ctx, strings.NewReader("contents"), &genai.UploadFileConfig{MIMEType: "text/plain"})
+		if err != nil {
+			t.Fatalf("Files.Upload() failed unexpectedly: %v", err)
+		}
+		if file, err = client.Files.Get(ctx, file.Name, nil); err != nil {
+			t.Fatalf("Files.Get() failed unexpectedly: %v", err)
+		}
+		return cache, file
+	}
+
+	t.Run("Record", func(t *testing.T) {
+		f := NewFakeServer(t)
+		client, err := NewClient(ctx, t, path, f.ClientConfig(), &Config{Mode: ModeRecord})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exercise(t, client)
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"cachedContents/fake-", "files/fake-", time.Now().UTC().Format("2006-01-02T")} {
+		if bytes.Contains(data, []byte(leak)) {
+			t.Errorf("recording contains %q:\n%s", leak, data)
+		}
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		client, err := NewClient(ctx, t, path, &genai.ClientConfig{Backend: genai.BackendGeminiAPI}, &Config{Mode: ModeReplay})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache, file := exercise(t, client)
+		if cache.Name != "cachedContents/id-1" || file.Name != "files/id-2" {
+			t.Errorf("replayed names = %q, %q, want %q, %q", cache.Name, file.Name, "cachedContents/id-1", "files/id-2")
+		}
+		if !cache.ExpireTime.After(cache.CreateTime) || cache.CreateTime.Before(replayEpoch) {
+			t.Errorf("replayed cache times = %v, %v, want synthetic times in order", cache.CreateTime, cache.ExpireTime)
+		}
+	})
+}
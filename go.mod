@@ -10,6 +10,7 @@ require (
 	github.com/eliben/go-sentencepiece v0.6.0
 	github.com/google/go-cmp v0.6.0
 	github.com/gorilla/websocket v1.5.3
+	golang.org/x/net v0.38.0
 )
 
 require (
@@ -19,7 +20,6 @@ require (
 	github.com/googleapis/enterprise-certificate-proxy v0.3.4 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
-	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
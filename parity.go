@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type parameterConverter func(*apiClient, map[string]any, map[string]any) (map[string]any, error)
+
+// ParityCase is a request whose conversion for the Gemini API and Vertex AI is
+// compared by [CheckParity]. Build one with a constructor such as
+// [GenerateContentParityCase].
+type ParityCase struct {
+	// Name identifies the case in the report.
+	Name string
+
+	method string
+	params map[string]any
+	mldev  parameterConverter
+	vertex parameterConverter
+	err    error
+}
+
+func newParityCase(name, method string, kwargs map[string]any, mldev, vertex parameterConverter) ParityCase {
+	c := ParityCase{Name: name, method: method, mldev: mldev, vertex: vertex}
+	c.err = deepMarshal(kwargs, &c.params)
+	return c
+}
+
+// GenerateContentParityCase returns a case for [Models.GenerateContent] and
+// [Models.GenerateContentStream].
+func GenerateContentParityCase(name, model string, contents []*Content, config *GenerateContentConfig) ParityCase {
+	return newParityCase(name, "models.generateContent", map[string]any{"model": model, "contents": contents, "config": config},
+		generateContentParametersToMldev, generateContentParametersToVertex)
+}
+
+// CountTokensParityCase returns a case for [Models.CountTokens].
+func CountTokensParityCase(name, model string, contents []*Content, config *CountTokensConfig) ParityCase {
+	return newParityCase(name, "models.countTokens", map[string]any{"model": model, "contents": contents, "config": config},
+		countTokensParametersToMldev, countTokensParametersToVertex)
+}
+
+// EmbedContentParityCase returns a case for [Models.EmbedContent].
+func EmbedContentParityCase(name, model string, contents []*Content, config *EmbedContentConfig) ParityCase {
+	return newParityCase(name, "models.embedContent", map[string]any{"model": model, "contents": contents, "config": config},
+		embedContentParametersToMldev, embedContentParametersToVertex)
+}
+
+// GenerateImagesParityCase returns a case for [Models.GenerateImages].
+func GenerateImagesParityCase(name, model, prompt string, config *GenerateImagesConfig) ParityCase {
+	return newParityCase(name, "models.generateImages", map[string]any{"model": model, "prompt": prompt, "config": config},
+		generateImagesParametersToMldev, generateImagesParametersToVertex)
+}
+
+// GenerateVideosParityCase returns a case for [Models.GenerateVideosFromSource].
+func GenerateVideosParityCase(name, model string, source *GenerateVideosSource, config *GenerateVideosConfig) ParityCase {
+	return newParityCase(name, "models.generateVideos", map[string]any{"model": model, "source": source, "config": config},
+		generateVideosParametersToMldev, generateVideosParametersToVertex)
+}
+
+// CreateCachedContentParityCase returns a case for [Caches.Create].
+func CreateCachedContentParityCase(name, model string, config *CreateCachedContentConfig) ParityCase {
+	return newParityCase(name, "caches.create", map[string]any{"model": model, "config": config},
+		createCachedContentParametersToMldev, createCachedContentParametersToVertex)
+}
+
+// BackendConversion is the conversion of a [ParityCase] for one backend.
+type BackendConversion struct {
+	Backend Backend
+	// Request is the body of the request sent to the backend. It is nil if
+	// the SDK rejects the request.
+	Request map[string]any
+	// Err is the error the SDK returns for the request instead of sending it,
+	// such as "labels parameter is not supported in Gemini API".
+	Err error
+	// Dropped are the JSON paths of the fields set in the request that are
+	// not sent to the backend, such as "config.labels" or
+	// "contents[0].parts[1].text", sorted.
+	Dropped []string
+}
+
+// OK reports whether the request is sent to the backend in full.
+func (c *BackendConversion) OK() bool {
+	return c.Err == nil && len(c.Dropped) == 0
+}
+
+func (c *BackendConversion) String() string {
+	switch {
+	case c.Err != nil:
+		return "rejected: " + c.Err.Error()
+	case len(c.Dropped) > 0:
+		return "dropped " + strings.Join(c.Dropped, ", ")
+	default:
+		return "ok"
+	}
+}
+
+// ParityResult is the result of [CheckParity] for one case.
+type ParityResult struct {
+	// Name is the name of the case.
+	Name string
+	// Method is the API method of the case, such as "models.generateContent".
+	Method    string
+	GeminiAPI *BackendConversion
+	VertexAI  *BackendConversion
+}
+
+// OK reports whether the request is sent in full to both backends.
+func (r *ParityResult) OK() bool {
+	return r.GeminiAPI.OK() && r.VertexAI.OK()
+}
+
+// ParityReport is the report of [CheckParity].
+type ParityReport struct {
+	Results []*ParityResult
+}
+
+// OK reports whether all the requests are sent in full to both backends.
+func (r *ParityReport) OK() bool {
+	for _, result := range r.Results {
+		if !result.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report with a line per case and backend.
+func (r *ParityReport) String() string {
+	var b strings.Builder
+	for _, result := range r.Results {
+		fmt.Fprintf(&b, "%s (%s)\n", result.Name, result.Method)
+		fmt.Fprintf(&b, "\tGemini API: %s\n", result.GeminiAPI)
+		fmt.Fprintf(&b, "\tVertex AI: %s\n", result.VertexAI)
+	}
+	return b.String()
+}
+
+// CheckParity converts the requests of cases for both the Gemini API and
+// Vertex AI, without sending them, and reports the requests that the SDK
+// rejects for a backend and the fields it silently drops. Use it to check
+// that prompts and configs written for one backend work on the other before
+// switching.
+//
+// A rejected request reports the first unsupported field only. A field is
+// dropped when the request converted without it is the same. Fields that only
+// configure the client, such as HTTPOptions, are ignored.
+func CheckParity(cases ...ParityCase) *ParityReport {
+	report := &ParityReport{}
+	for _, c := range cases {
+		report.Results = append(report.Results, &ParityResult{
+			Name:      c.Name,
+			Method:    c.method,
+			GeminiAPI: c.convert(BackendGeminiAPI),
+			VertexAI:  c.convert(BackendVertexAI),
+		})
+	}
+	return report
+}
+
+// convert converts the case for backend.
+func (c *ParityCase) convert(backend Backend) *BackendConversion {
+	result := &BackendConversion{Backend: backend}
+	if c.err != nil {
+		result.Err = c.err
+		return result
+	}
+	var params map[string]any
+	if err := deepMarshal(c.params, &params); err != nil {
+		result.Err = err
+		return result
+	}
+	if config, ok := params["config"].(map[string]any); ok {
+		delete(config, "httpOptions")
+	}
+
+	ac := &apiClient{clientConfig: &ClientConfig{Backend: backend, Project: "project", Location: "us-central1"}}
+	converter := c.mldev
+	if backend == BackendVertexAI {
+		converter = c.vertex
+	}
+	convert := func() (map[string]any, error) {
+		// Convert a copy, since converters may modify their input.
+		var p map[string]any
+		if err := deepMarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return converter(ac, p, nil)
+	}
+	body, err := convert()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	p := &parityProbe{convert: convert, body: body}
+	p.dropped(params, "", &result.Dropped)
+	slices.Sort(result.Dropped)
+
+	result.Request = body
+	delete(body, "_url")
+	delete(body, "_query")
+	return result
+}
+
+// parityProbe finds the fields of a request that are dropped, by converting
+// the request without each of them.
+type parityProbe struct {
+	convert func() (map[string]any, error)
+	// body is the request converted with all the fields.
+	body map[string]any
+}
+
+// sent reports whether removing the field key of m changes the request.
+func (p *parityProbe) sent(m map[string]any, key string) bool {
+	value := m[key]
+	delete(m, key)
+	defer func() { m[key] = value }()
+	body, err := p.convert()
+	return err != nil || !reflect.DeepEqual(body, p.body)
+}
+
+// dropped appends to out the paths of the fields of v that are not sent. The
+// fields of a dropped map are not listed.
+func (p *parityProbe) dropped(v any, path string, out *[]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			if v[key] == nil {
+				continue
+			}
+			if !p.sent(v, key) {
+				*out = append(*out, joinParityPath(path, key))
+				continue
+			}
+			p.dropped(v[key], joinParityPath(path, key), out)
+		}
+	case []any:
+		for i, value := range v {
+			p.dropped(value, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+func joinParityPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
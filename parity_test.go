@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckParity(t *testing.T) {
+	contents := []*Content{
+		{Role: RoleUser, Parts: []*Part{
+			{Text: "describe"},
+			{InlineData: &Blob{MIMEType: "image/png", Data: []byte("png")}},
+			{FileData: &FileData{FileURI: "gs://bucket/video.mp4", MIMEType: "video/mp4"}, VideoMetadata: &VideoMetadata{FPS: Ptr(1.0)}},
+		}},
+		{Role: RoleModel, Parts: []*Part{{FunctionCall: &FunctionCall{Name: "lookup", Args: map[string]any{"id": 1.0}}, ThoughtSignature: []byte("sig")}}},
+		{Role: RoleUser, Parts: []*Part{{FunctionResponse: &FunctionResponse{Name: "lookup", Response: map[string]any{"found": true}}}}},
+	}
+	tests := []struct {
+		name           string
+		c              ParityCase
+		wantGeminiAPI  string
+		wantVertexAI   string
+		wantGeminiBody bool
+	}{
+		{
+			name: "portable",
+			c: GenerateContentParityCase("portable", "gemini-2.0-flash", contents, &GenerateContentConfig{
+				HTTPOptions:       &HTTPOptions{Headers: http.Header{"X-Test": []string{"1"}}},
+				SystemInstruction: NewContentFromText("be brief", RoleUser),
+				Temperature:       Ptr[float32](0.5),
+				ResponseMIMEType:  "application/json",
+				ResponseSchema:    &Schema{Type: TypeObject, Properties: map[string]*Schema{"answer": {Type: TypeString}}},
+				ThinkingConfig:    &ThinkingConfig{ThinkingBudget: Ptr[int32](128)},
+				Tools:             []*Tool{{FunctionDeclarations: []*FunctionDeclaration{{Name: "lookup", Parameters: &Schema{Type: TypeObject}}}}},
+				SafetySettings:    []*SafetySetting{{Category: HarmCategoryHarassment, Threshold: HarmBlockThresholdBlockNone}},
+			}),
+			wantGeminiAPI:  "ok",
+			wantVertexAI:   "ok",
+			wantGeminiBody: true,
+		},
+		{
+			name:          "rejected by Gemini API",
+			c:             GenerateContentParityCase("labels", "gemini-2.0-flash", Text("hi"), &GenerateContentConfig{Labels: map[string]string{"team": "a"}}),
+			wantGeminiAPI: "rejected: labels parameter is not supported in Gemini API",
+			wantVertexAI:  "ok",
+		},
+		{
+			name: "rejected by Vertex AI",
+			c: GenerateContentParityCase("behavior", "gemini-2.0-flash", Text("hi"), &GenerateContentConfig{
+				Tools: []*Tool{{FunctionDeclarations: []*FunctionDeclaration{{Name: "f", Behavior: BehaviorNonBlocking}}}},
+			}),
+			wantGeminiAPI:  "ok",
+			wantVertexAI:   "rejected: behavior parameter is not supported in Vertex AI",
+			wantGeminiBody: true,
+		},
+		{
+			name: "dropped by Vertex AI",
+			c: EmbedContentParityCase("embed", "text-embedding-004", []*Content{
+				{Parts: []*Part{{Text: "first"}, {Text: "second"}}},
+			}, &EmbedContentConfig{OutputDimensionality: Ptr[int32](8)}),
+			wantGeminiAPI:  "ok",
+			wantVertexAI:   "dropped contents[0].parts[1].text",
+			wantGeminiBody: true,
+		},
+		{
+			name:          "caches",
+			c:             CreateCachedContentParityCase("cache", "gemini-2.0-flash", &CreateCachedContentConfig{Contents: Text("doc"), KmsKeyName: "key"}),
+			wantGeminiAPI: "rejected: kmsKeyName parameter is not supported in Gemini API",
+			wantVertexAI:  "ok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := CheckParity(tt.c)
+			if len(report.Results) != 1 {
+				t.Fatalf("CheckParity() returned %d results, want 1", len(report.Results))
+			}
+			result := report.Results[0]
+			if got := result.GeminiAPI.String(); got != tt.wantGeminiAPI {
+				t.Errorf("Gemini API conversion = %q, want %q", got, tt.wantGeminiAPI)
+			}
+			if got := result.VertexAI.String(); got != tt.wantVertexAI {
+				t.Errorf("Vertex AI conversion = %q, want %q", got, tt.wantVertexAI)
+			}
+			if got := result.GeminiAPI.Request != nil; got != tt.wantGeminiBody {
+				t.Errorf("Gemini API request present = %v, want %v", got, tt.wantGeminiBody)
+			}
+			wantOK := tt.wantGeminiAPI == "ok" && tt.wantVertexAI == "ok"
+			if report.OK() != wantOK {
+				t.Errorf("OK() = %v, want %v", report.OK(), wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckParityReport(t *testing.T) {
+	report := CheckParity(
+		CountTokensParityCase("count", "gemini-2.0-flash", Text("hi"), &CountTokensConfig{SystemInstruction: NewContentFromText("x", RoleUser)}),
+		GenerateVideosParityCase("video", "veo-2.0-generate-001", &GenerateVideosSource{Prompt: "a cat"}, &GenerateVideosConfig{NumberOfVideos: 1}),
+	)
+	want := strings.Join([]string{
+		"count (models.countTokens)",
+		"\tGemini API: rejected: systemInstruction parameter is not supported in Gemini API",
+		"\tVertex AI: ok",
+		"video (models.generateVideos)",
+		"\tGemini API: ok",
+		"\tVertex AI: ok",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, report.String()); diff != "" {
+		t.Errorf("String() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The request body has no URL parameters.
+	body := report.Results[1].GeminiAPI.Request
+	if _, ok := body["_url"]; ok {
+		t.Errorf("Request has _url: %v", body)
+	}
+	if diff := cmp.Diff([]map[string]any{{"prompt": "a cat"}}, body["instances"]); diff != "" {
+		t.Errorf("Request instances mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckParityConcurrent(t *testing.T) {
+	c := EmbedContentParityCase("embed", "text-embedding-004", []*Content{
+		{Parts: []*Part{{Text: "first"}, {Text: "second"}}},
+	}, nil)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := CheckParity(c).Results[0].VertexAI.String(); got != "dropped contents[0].parts[1].text" {
+				t.Errorf("Vertex AI conversion = %q, want %q", got, "dropped contents[0].parts[1].text")
+			}
+		}()
+	}
+	wg.Wait()
+}
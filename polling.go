@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultPollInterval    = 1 * time.Second
+	defaultMaxPollInterval = 30 * time.Second
+	defaultPollMultiplier  = 1.5
+)
+
+// pollBackoff computes the delay between successive polls of a long-running
+// resource. Zero values are replaced by the package defaults.
+type pollBackoff struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	multiplier  float64
+}
+
+func newPollBackoff(interval, maxInterval time.Duration, multiplier float64) *pollBackoff {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxPollInterval
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	if multiplier < 1 {
+		multiplier = defaultPollMultiplier
+	}
+	return &pollBackoff{interval: interval, maxInterval: maxInterval, multiplier: multiplier}
+}
+
+// next returns the delay to wait before the upcoming poll and grows the
+// interval for the one after it.
+func (b *pollBackoff) next() time.Duration {
+	d := b.interval
+	b.interval = min(time.Duration(float64(b.interval)*b.multiplier), b.maxInterval)
+	return d
+}
+
+// sleepContext waits for d, returning early with the context error if ctx is
+// done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PoolPolicy selects the client of a [Pool] that a request is sent to first.
+type PoolPolicy int
+
+const (
+	// PoolPolicyRoundRobin sends the requests to the clients in turn.
+	PoolPolicyRoundRobin PoolPolicy = iota
+	// PoolPolicyLeastLatency sends the requests to the client with the lowest
+	// average latency. Clients that haven't served a request yet are tried
+	// first.
+	PoolPolicyLeastLatency
+	// PoolPolicyQuotaAware sends the requests to the client that was sent the
+	// fewest requests in the last minute, spreading the load across the quotas
+	// of the projects and API keys.
+	PoolPolicyQuotaAware
+)
+
+// The Stringer interface for PoolPolicy.
+func (p PoolPolicy) String() string {
+	switch p {
+	case PoolPolicyRoundRobin:
+		return "PoolPolicyRoundRobin"
+	case PoolPolicyLeastLatency:
+		return "PoolPolicyLeastLatency"
+	case PoolPolicyQuotaAware:
+		return "PoolPolicyQuotaAware"
+	default:
+		return fmt.Sprintf("PoolPolicy(%d)", int(p))
+	}
+}
+
+// defaultPoolCooldown is the default of [PoolConfig.Cooldown].
+const defaultPoolCooldown = 30 * time.Second
+
+// PoolConfig holds optional parameters for [NewPool].
+type PoolConfig struct {
+	// Optional. Policy selects the client a request is sent to first. Defaults
+	// to [PoolPolicyRoundRobin].
+	Policy PoolPolicy
+	// Optional. MaxAttempts is the maximum number of clients a request is sent
+	// to. Defaults to the number of clients.
+	MaxAttempts int
+	// Optional. Cooldown is how long a client that failed is tried only after
+	// the others. A client that is rate limited is tried after the others for
+	// the retry delay returned by the server instead, if any. Defaults to 30
+	// seconds.
+	Cooldown time.Duration
+}
+
+// Pool routes the requests of its Models and Chats to several clients, such
+// as clients of different regions, projects or API keys, and fails over to
+// the next client when a request fails with a 429 or 5xx status code or a
+// transport error. Other errors, such as an invalid argument, are returned
+// without trying the other clients.
+//
+// Models and Chats have the same methods as the ones of a [Client]:
+//
+//	pool, err := genai.NewPool(ctx, []*genai.ClientConfig{
+//		{Backend: genai.BackendVertexAI, Project: "project", Location: "us-central1"},
+//		{Backend: genai.BackendVertexAI, Project: "project", Location: "europe-west4"},
+//	}, &genai.PoolConfig{Policy: genai.PoolPolicyLeastLatency})
+//	...
+//	result, err := pool.Models.GenerateContent(ctx, "gemini-2.5-flash", genai.Text("Hi"), nil)
+//
+// A streamed request fails over only until the response starts: errors while
+// iterating are returned as is. Resources are bound to a project, region or
+// API key, so requests that name one, such as a CachedContent of
+// [GenerateContentConfig] or the name of a tuned model, should use the client
+// that created it, from Clients.
+type Pool struct {
+	// Models routes the requests to the clients of the pool.
+	Models *Models
+	// Chats creates chats whose messages are routed to the clients of the
+	// pool, so that consecutive messages of a chat may be served by different
+	// clients.
+	Chats *Chats
+	// Clients are the clients of the pool, in the order of the configs passed
+	// to [NewPool]. Use them for the services bound to a project, region or
+	// API key, such as Files, Caches, Batches and Operations.
+	Clients []*Client
+
+	pool *clientPool
+}
+
+// NewPool creates a pool of clients, one per config in configs. Each config
+// is resolved as by [NewClient]. All the clients must use the same backend,
+// since requests are converted for the backend before a client is selected.
+func NewPool(ctx context.Context, configs []*ClientConfig, config *PoolConfig) (*Pool, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("NewPool: at least one client config is required")
+	}
+	if config == nil {
+		config = &PoolConfig{}
+	}
+	p := &clientPool{config: *config}
+	if p.config.MaxAttempts <= 0 {
+		p.config.MaxAttempts = len(configs)
+	}
+	if p.config.Cooldown <= 0 {
+		p.config.Cooldown = defaultPoolCooldown
+	}
+
+	pool := &Pool{pool: p}
+	for i, cc := range configs {
+		client, err := NewClient(ctx, cc)
+		if err != nil {
+			return nil, fmt.Errorf("NewPool: client %d: %w", i, err)
+		}
+		if backend := client.clientConfig.Backend; i > 0 && backend != pool.Clients[0].clientConfig.Backend {
+			return nil, fmt.Errorf("NewPool: client %d uses %v, but client 0 uses %v: all the clients of a pool must use the same backend", i, backend, pool.Clients[0].clientConfig.Backend)
+		}
+		pool.Clients = append(pool.Clients, client)
+		p.members = append(p.members, &poolMember{ac: client.Models.apiClient})
+	}
+
+	// The requests are converted with the config of the first client, and
+	// sent with the config of the selected one.
+	ac := &apiClient{clientConfig: pool.Clients[0].Models.apiClient.clientConfig, pool: p}
+	pool.Models = &Models{apiClient: ac}
+	pool.Chats = &Chats{apiClient: ac}
+	return pool, nil
+}
+
+// PoolClientStats are the statistics of a client of a [Pool].
+type PoolClientStats struct {
+	// Requests is the number of requests sent to the client.
+	Requests int
+	// Failures is the number of requests that failed with a 429 or 5xx status
+	// code or a transport error.
+	Failures int
+	// Latency is the moving average of the latency of the successful requests,
+	// up to the response headers for streamed requests.
+	Latency time.Duration
+}
+
+// Stats returns the statistics of the clients, in the order of Clients.
+func (p *Pool) Stats() []PoolClientStats {
+	p.pool.mu.Lock()
+	defer p.pool.mu.Unlock()
+	stats := make([]PoolClientStats, len(p.pool.members))
+	for i, m := range p.pool.members {
+		stats[i] = PoolClientStats{Requests: m.requests, Failures: m.failures, Latency: m.latency}
+	}
+	return stats
+}
+
+// clientPool selects the clients that the requests of a [Pool] are sent to.
+type clientPool struct {
+	config PoolConfig
+
+	mu      sync.Mutex
+	members []*poolMember
+	// next is the client the next request is sent to first, for round-robin.
+	next int
+}
+
+type poolMember struct {
+	ac       *apiClient
+	requests int
+	failures int
+	latency  time.Duration
+	// recent are the times of the requests of the last minute, tracked for
+	// PoolPolicyQuotaAware only.
+	recent []time.Time
+	// cooldownUntil is the time until which the client is tried last.
+	cooldownUntil time.Time
+}
+
+// do calls send with the clients selected for a request, in order, until it
+// succeeds or fails with an error that is not worth trying another client
+// for. It returns the last error.
+func (p *clientPool) do(ctx context.Context, send func(*apiClient) error) error {
+	var err error
+	for _, m := range p.order() {
+		start := p.started(m)
+		err = send(m.ac)
+		retry := err != nil && retryable(ctx, err)
+		p.finished(m, start, err, retry)
+		if !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// order returns the clients to send a request to, in order: the clients in
+// cooldown last, and the others sorted by the policy.
+func (p *clientPool) order() []*poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+
+	// Start from the round-robin order so that ties are spread too.
+	members := make([]*poolMember, 0, len(p.members))
+	members = append(members, p.members[p.next:]...)
+	members = append(members, p.members[:p.next]...)
+	p.next = (p.next + 1) % len(p.members)
+
+	switch p.config.Policy {
+	case PoolPolicyLeastLatency:
+		slices.SortStableFunc(members, func(a, b *poolMember) int {
+			return cmp.Compare(a.latency, b.latency)
+		})
+	case PoolPolicyQuotaAware:
+		for _, m := range members {
+			m.pruneRecent(now)
+		}
+		slices.SortStableFunc(members, func(a, b *poolMember) int {
+			return len(a.recent) - len(b.recent)
+		})
+	}
+	slices.SortStableFunc(members, func(a, b *poolMember) int {
+		aReady, bReady := !a.cooldownUntil.After(now), !b.cooldownUntil.After(now)
+		switch {
+		case aReady && bReady:
+			return 0
+		case aReady:
+			return -1
+		case bReady:
+			return 1
+		default:
+			return a.cooldownUntil.Compare(b.cooldownUntil)
+		}
+	})
+	return members[:min(len(members), p.config.MaxAttempts)]
+}
+
+func (p *clientPool) started(m *poolMember) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	m.requests++
+	if p.config.Policy == PoolPolicyQuotaAware {
+		m.pruneRecent(now)
+		m.recent = append(m.recent, now)
+	}
+	return now
+}
+
+// pruneRecent removes the requests older than a minute from recent.
+func (m *poolMember) pruneRecent(now time.Time) {
+	m.recent = slices.DeleteFunc(m.recent, func(t time.Time) bool {
+		return now.Sub(t) >= time.Minute
+	})
+}
+
+// finished records the result of a request sent at start. A client whose
+// request is retried on another one is put in cooldown.
+func (p *clientPool) finished(m *poolMember, start time.Time, err error, retry bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	switch {
+	case err == nil:
+		latency := now.Sub(start)
+		if m.latency == 0 {
+			m.latency = latency
+		} else {
+			// An exponential moving average, so that the latency follows the
+			// recent requests.
+			m.latency += (latency - m.latency) / 5
+		}
+	case retry:
+		m.failures++
+		cooldown := p.config.Cooldown
+		if delay, ok := retryDelay(err); ok {
+			cooldown = delay
+		}
+		m.cooldownUntil = now.Add(cooldown)
+	}
+}
+
+// retryable reports whether a request that failed with err is worth sending
+// to another client: it was rate limited, the server failed or it couldn't be
+// sent. Requests canceled by ctx are not retried.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryDelay returns the delay of the google.rpc.RetryInfo details of a rate
+// limit error.
+func retryDelay(err error) (time.Duration, bool) {
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+	for _, detail := range apiErr.Details {
+		if t, _ := detail["@type"].(string); !strings.HasSuffix(t, "google.rpc.RetryInfo") {
+			continue
+		}
+		s, _ := detail["retryDelay"].(string)
+		if delay, err := time.ParseDuration(s); err == nil && delay > 0 {
+			return delay, true
+		}
+	}
+	return 0, false
+}
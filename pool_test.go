@@ -0,0 +1,304 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// poolServer is a Gemini API server that answers generateContent requests
+// with its name, or fails with status.
+type poolServer struct {
+	name  string
+	delay time.Duration
+
+	mu       sync.Mutex
+	status   int
+	body     string
+	requests int
+}
+
+func (s *poolServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	status, body := s.status, s.body
+	s.mu.Unlock()
+	time.Sleep(s.delay)
+	if status != 0 {
+		if body == "" {
+			body = fmt.Sprintf(`{"error": {"code": %d, "message": "failed"}}`, status)
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+		return
+	}
+	resp := fmt.Sprintf(`{"candidates": [{"content": {"role": "model", "parts": [{"text": %q}]}, "finishReason": "STOP"}]}`, s.name)
+	if strings.HasSuffix(r.URL.Path, ":streamGenerateContent") {
+		fmt.Fprintf(w, "data: %s\n\n", resp)
+		return
+	}
+	fmt.Fprint(w, resp)
+}
+
+func (s *poolServer) fail(status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.body = status, body
+}
+
+func (s *poolServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func newTestPool(t *testing.T, config *PoolConfig, servers ...*poolServer) *Pool {
+	t.Helper()
+	var configs []*ClientConfig
+	for _, s := range servers {
+		ts := httptest.NewServer(s)
+		t.Cleanup(ts.Close)
+		configs = append(configs, &ClientConfig{
+			Backend:     BackendGeminiAPI,
+			APIKey:      "key-" + s.name,
+			HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+			HTTPClient:  ts.Client(),
+		})
+	}
+	pool, err := NewPool(context.Background(), configs, config)
+	if err != nil {
+		t.Fatalf("NewPool() unexpected error: %v", err)
+	}
+	return pool
+}
+
+func generateFromPool(t *testing.T, pool *Pool) string {
+	t.Helper()
+	result, err := pool.Models.GenerateContent(context.Background(), "gemini-2.0-flash", Text("hi"), nil)
+	if err != nil {
+		t.Fatalf("GenerateContent() unexpected error: %v", err)
+	}
+	return result.Text()
+}
+
+func TestPoolFailover(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "server error", status: http.StatusServiceUnavailable},
+		{name: "rate limited", status: http.StatusTooManyRequests},
+		{
+			name:   "rate limited with retry delay",
+			status: http.StatusTooManyRequests,
+			body:   `{"error": {"code": 429, "message": "quota", "details": [{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "3600s"}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := &poolServer{name: "a"}, &poolServer{name: "b"}
+			a.fail(tt.status, tt.body)
+			pool := newTestPool(t, nil, a, b)
+
+			if got := generateFromPool(t, pool); got != "b" {
+				t.Errorf("GenerateContent() served by %q, want b", got)
+			}
+			// The failed client is in cooldown, so it is tried last.
+			for range 3 {
+				if got := generateFromPool(t, pool); got != "b" {
+					t.Errorf("GenerateContent() served by %q, want b", got)
+				}
+			}
+			if a.count() != 1 || b.count() != 4 {
+				t.Errorf("requests = %d, %d, want 1, 4", a.count(), b.count())
+			}
+			stats := pool.Stats()
+			if stats[0].Failures != 1 || stats[1].Failures != 0 || stats[1].Requests != 4 {
+				t.Errorf("Stats() = %+v, want 1 failure of a and 4 requests to b", stats)
+			}
+		})
+	}
+}
+
+func TestPoolErrors(t *testing.T) {
+	t.Run("not retryable", func(t *testing.T) {
+		a, b := &poolServer{name: "a"}, &poolServer{name: "b"}
+		a.fail(http.StatusBadRequest, "")
+		pool := newTestPool(t, nil, a, b)
+		_, err := pool.Models.GenerateContent(context.Background(), "gemini-2.0-flash", Text("hi"), nil)
+		var apiErr APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+			t.Fatalf("GenerateContent() error = %v, want a 400 APIError", err)
+		}
+		if b.count() != 0 {
+			t.Errorf("b received %d requests, want 0", b.count())
+		}
+		if stats := pool.Stats(); stats[0].Failures != 0 {
+			t.Errorf("Stats() = %+v, want no failures", stats)
+		}
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		a, b := &poolServer{name: "a"}, &poolServer{name: "b"}
+		a.fail(http.StatusInternalServerError, "")
+		b.fail(http.StatusServiceUnavailable, "")
+		pool := newTestPool(t, nil, a, b)
+		_, err := pool.Models.GenerateContent(context.Background(), "gemini-2.0-flash", Text("hi"), nil)
+		var apiErr APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusServiceUnavailable {
+			t.Fatalf("GenerateContent() error = %v, want the 503 APIError of the last client", err)
+		}
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		a, b := &poolServer{name: "a"}, &poolServer{name: "b"}
+		a.fail(http.StatusInternalServerError, "")
+		pool := newTestPool(t, &PoolConfig{MaxAttempts: 1}, a, b)
+		if _, err := pool.Models.GenerateContent(context.Background(), "gemini-2.0-flash", Text("hi"), nil); err == nil {
+			t.Fatal("GenerateContent() succeeded, want error")
+		}
+		if b.count() != 0 {
+			t.Errorf("b received %d requests, want 0", b.count())
+		}
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		b := &poolServer{name: "b"}
+		pool := newTestPool(t, nil, &poolServer{name: "a"}, b)
+		// Point the first client to a port nothing listens on.
+		pool.Clients[0].Models.apiClient.clientConfig.HTTPOptions.BaseURL = "http://127.0.0.1:1"
+		if got := generateFromPool(t, pool); got != "b" {
+			t.Errorf("GenerateContent() served by %q, want b", got)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		a, b := &poolServer{name: "a", delay: 50 * time.Millisecond}, &poolServer{name: "b"}
+		pool := newTestPool(t, nil, a, b)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := pool.Models.GenerateContent(ctx, "gemini-2.0-flash", Text("hi"), nil); err == nil {
+			t.Fatal("GenerateContent() succeeded, want error")
+		}
+		if b.count() != 0 {
+			t.Errorf("b received %d requests, want 0", b.count())
+		}
+	})
+}
+
+func TestPoolPolicies(t *testing.T) {
+	tests := []struct {
+		policy PoolPolicy
+		want   []string
+	}{
+		{policy: PoolPolicyRoundRobin, want: []string{"a", "b", "a", "b"}},
+		// a is slower, so it serves only the first request.
+		{policy: PoolPolicyLeastLatency, want: []string{"a", "b", "b", "b"}},
+		{policy: PoolPolicyQuotaAware, want: []string{"a", "b", "a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			a, b := &poolServer{name: "a", delay: 20 * time.Millisecond}, &poolServer{name: "b"}
+			pool := newTestPool(t, &PoolConfig{Policy: tt.policy}, a, b)
+			var got []string
+			for range tt.want {
+				got = append(got, generateFromPool(t, pool))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("requests served by %v, want %v", got, tt.want)
+			}
+			// Recent requests are only tracked for the quota-aware policy.
+			for i, m := range pool.pool.members {
+				if tt.policy != PoolPolicyQuotaAware && len(m.recent) != 0 {
+					t.Errorf("client %d tracks %d recent requests, want 0", i, len(m.recent))
+				}
+			}
+		})
+	}
+}
+
+func TestPoolQuotaAwareSkipsRateLimitedClients(t *testing.T) {
+	a, b, c := &poolServer{name: "a"}, &poolServer{name: "b"}, &poolServer{name: "c"}
+	pool := newTestPool(t, &PoolConfig{Policy: PoolPolicyQuotaAware}, a, b, c)
+	for range 3 {
+		generateFromPool(t, pool)
+	}
+	// b is rate limited once, then the requests are spread across a and c.
+	b.fail(http.StatusTooManyRequests, "")
+	for range 4 {
+		if got := generateFromPool(t, pool); got == "b" {
+			t.Errorf("GenerateContent() served by b, want a or c")
+		}
+	}
+	stats := pool.Stats()
+	if got := []int{stats[0].Requests, stats[1].Requests, stats[2].Requests}; fmt.Sprint(got) != "[3 2 3]" {
+		t.Errorf("requests = %v, want [3 2 3]", got)
+	}
+}
+
+func TestPoolChatAndStream(t *testing.T) {
+	ctx := context.Background()
+	a, b := &poolServer{name: "a"}, &poolServer{name: "b"}
+	a.fail(http.StatusServiceUnavailable, "")
+	pool := newTestPool(t, nil, a, b)
+
+	chat, err := pool.Chats.Create(ctx, "gemini-2.0-flash", nil, nil)
+	if err != nil {
+		t.Fatalf("Chats.Create() unexpected error: %v", err)
+	}
+	result, err := chat.SendMessage(ctx, Part{Text: "hi"})
+	if err != nil {
+		t.Fatalf("SendMessage() unexpected error: %v", err)
+	}
+	if result.Text() != "b" || len(chat.History(true)) != 2 {
+		t.Errorf("SendMessage() = %q with %d turns, want b with 2 turns", result.Text(), len(chat.History(true)))
+	}
+
+	a.fail(0, "")
+	b.fail(http.StatusServiceUnavailable, "")
+	var texts []string
+	for chunk, err := range pool.Models.GenerateContentStream(ctx, "gemini-2.0-flash", Text("hi"), nil) {
+		if err != nil {
+			t.Fatalf("GenerateContentStream() unexpected error: %v", err)
+		}
+		texts = append(texts, chunk.Text())
+	}
+	if strings.Join(texts, "") != "a" {
+		t.Errorf("GenerateContentStream() = %v, want [a]", texts)
+	}
+}
+
+func TestNewPoolErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewPool(ctx, nil, nil); err == nil {
+		t.Error("NewPool() with no configs succeeded, want error")
+	}
+	_, err := NewPool(ctx, []*ClientConfig{
+		{Backend: BackendGeminiAPI, APIKey: "key"},
+		{Backend: BackendVertexAI, APIKey: "key"},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "same backend") {
+		t.Errorf("NewPool() with mixed backends error = %v, want a same backend error", err)
+	}
+}
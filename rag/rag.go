@@ -0,0 +1,199 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rag answers questions with [genai.Models.GenerateContent] grounded
+// on passages found by a [Retriever].
+//
+// A [Pipeline] retrieves the passages relevant to a question, numbers them in
+// the prompt, asks the model to cite them with markers such as [1], and maps
+// the markers of the answer back to the passages.
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"google.golang.org/genai"
+)
+
+// DefaultTopK is the number of passages retrieved by default.
+const DefaultTopK = 5
+
+// DefaultTemplate is the prompt used when Pipeline.Template is nil.
+var DefaultTemplate = template.Must(template.New("rag").Parse(`Answer the question using only the numbered sources below. After each sentence that uses a source, cite it with its number in square brackets, for example [1] or [1][3]. If the sources do not contain the answer, say so.
+
+{{range .Passages}}[{{.Number}}]{{if .Source}} ({{.Source}}){{end}}
+{{.Text}}
+
+{{end}}Question: {{.Question}}`))
+
+// Generator generates content. [genai.Models] implements it.
+type Generator interface {
+	GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+}
+
+// Pipeline answers questions from retrieved passages.
+type Pipeline struct {
+	// Required. Finds the passages given to the model.
+	Retriever Retriever
+	// Required. Generates the answer.
+	Generator Generator
+	// Required. The model generating the answer.
+	Model string
+	// Optional. Number of passages retrieved. Defaults to [DefaultTopK].
+	TopK int
+	// Optional. The prompt, executed with a [PromptData]. Defaults to
+	// [DefaultTemplate].
+	Template *template.Template
+	// Optional. Passed to GenerateContent.
+	GenerateConfig *genai.GenerateContentConfig
+}
+
+// PromptData is the data the prompt template is executed with.
+type PromptData struct {
+	Question string
+	Passages []PromptPassage
+}
+
+// PromptPassage is a passage as shown in the prompt.
+type PromptPassage struct {
+	*Passage
+	// Number is the citation number of the passage, starting at 1.
+	Number int
+}
+
+// Answer is the result of [Pipeline.Answer].
+type Answer struct {
+	// Text is the generated answer.
+	Text string
+	// Passages are the passages given to the model, in citation number order.
+	Passages []*Passage
+	// Citations link spans of Text to the passages they cite.
+	Citations []Citation
+	// Response is the raw model response.
+	Response *genai.GenerateContentResponse
+}
+
+// Citation links a span of the answer to the passages it is based on.
+type Citation struct {
+	// Start is the byte offset of the cited span in Answer.Text.
+	Start int
+	// End is the byte offset just after the cited span, before the citation
+	// markers.
+	End int
+	// Passages are the cited passages.
+	Passages []*Passage
+}
+
+// Answer retrieves the passages relevant to question and generates an answer
+// citing them.
+func (p *Pipeline) Answer(ctx context.Context, question string) (*Answer, error) {
+	k := p.TopK
+	if k <= 0 {
+		k = DefaultTopK
+	}
+	passages, err := p.Retriever.Retrieve(ctx, question, k)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving passages: %w", err)
+	}
+	prompt, err := p.Prompt(question, passages)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Generator.GenerateContent(ctx, p.Model, genai.Text(prompt), p.GenerateConfig)
+	if err != nil {
+		return nil, err
+	}
+	text := resp.Text()
+	return &Answer{
+		Text:      text,
+		Passages:  passages,
+		Citations: ParseCitations(text, passages),
+		Response:  resp,
+	}, nil
+}
+
+// Prompt renders the prompt for question and passages.
+func (p *Pipeline) Prompt(question string, passages []*Passage) (string, error) {
+	tmpl := p.Template
+	if tmpl == nil {
+		tmpl = DefaultTemplate
+	}
+	data := PromptData{Question: question, Passages: make([]PromptPassage, len(passages))}
+	for i, passage := range passages {
+		data.Passages[i] = PromptPassage{Passage: passage, Number: i + 1}
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("executing prompt template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// citationMarkers matches runs of markers such as "[1]", "[1][3]" or "[1, 2]".
+var citationMarkers = regexp.MustCompile(`\s*(?:\[\d+(?:\s*,\s*\d+)*\])+`)
+
+var citationNumber = regexp.MustCompile(`\d+`)
+
+// ParseCitations finds the citation markers of text, numbered from 1 in the
+// order of passages. Each run of markers cites the text between the previous
+// sentence boundary or marker and the run. Markers with numbers out of range are
+// ignored.
+func ParseCitations(text string, passages []*Passage) []Citation {
+	var citations []Citation
+	spanStart := 0
+	for _, loc := range citationMarkers.FindAllStringIndex(text, -1) {
+		var cited []*Passage
+		seen := map[int]bool{}
+		for _, n := range citationNumber.FindAllString(text[loc[0]:loc[1]], -1) {
+			i, err := strconv.Atoi(n)
+			if err != nil || i < 1 || i > len(passages) || seen[i] {
+				continue
+			}
+			seen[i] = true
+			cited = append(cited, passages[i-1])
+		}
+		start := max(spanStart, sentenceStart(text, loc[0]))
+		start, end := trimSpan(text, start, loc[0])
+		if len(cited) > 0 && start < end {
+			citations = append(citations, Citation{Start: start, End: end, Passages: cited})
+		}
+		spanStart = loc[1]
+	}
+	return citations
+}
+
+// sentenceStart returns the offset of the start of the sentence containing the
+// byte before end.
+func sentenceStart(text string, end int) int {
+	s := strings.TrimRight(text[:end], " \t\n.!?")
+	if i := strings.LastIndexAny(s, ".!?\n"); i >= 0 {
+		return i + 1
+	}
+	return 0
+}
+
+func trimSpan(text string, start, end int) (int, int) {
+	for start < end && strings.ContainsRune(" \t\n", rune(text[start])) {
+		start++
+	}
+	for end > start && strings.ContainsRune(" \t\n", rune(text[end-1])) {
+		end--
+	}
+	return start, end
+}
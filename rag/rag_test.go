@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+var vocabulary = []string{"paris", "france", "berlin", "germany", "capital"}
+
+// bagOfWords embeds a text as the counts of the vocabulary words it contains.
+type bagOfWords struct {
+	taskTypes []string
+}
+
+func (b *bagOfWords) EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error) {
+	b.taskTypes = append(b.taskTypes, config.TaskType)
+	resp := &genai.EmbedContentResponse{}
+	for _, c := range contents {
+		v := make([]float32, len(vocabulary))
+		for _, w := range strings.Fields(strings.ToLower(c.Parts[0].Text)) {
+			for i, word := range vocabulary {
+				if strings.Trim(w, ".?") == word {
+					v[i]++
+				}
+			}
+		}
+		resp.Embeddings = append(resp.Embeddings, &genai.ContentEmbedding{Values: v})
+	}
+	return resp, nil
+}
+
+type fakeGenerator struct {
+	prompt string
+	answer string
+}
+
+func (g *fakeGenerator) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	g.prompt = contents[0].Parts[0].Text
+	return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: genai.NewContentFromText(g.answer, genai.RoleModel)}}}, nil
+}
+
+func TestPipelineAnswer(t *testing.T) {
+	ctx := context.Background()
+	embedder := &bagOfWords{}
+	retriever := NewIndexRetriever(embedder, "gemini-embedding-001", nil)
+	err := retriever.Add(ctx, []*Passage{
+		{ID: "fr", Text: "Paris is the capital of France.", Source: "fr.txt"},
+		{ID: "de", Text: "Berlin is the capital of Germany.", Source: "de.txt", Metadata: map[string]string{"lang": "en"}},
+	})
+	if err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+
+	gen := &fakeGenerator{answer: "The capital of France is Paris [1]. Germany's is Berlin [2]. Both are capitals [1][2]."}
+	p := &Pipeline{Retriever: retriever, Generator: gen, Model: "gemini-2.5-flash", TopK: 2}
+	answer, err := p.Answer(ctx, "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("Answer() unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"RETRIEVAL_DOCUMENT", "RETRIEVAL_QUERY"}, embedder.taskTypes); diff != "" {
+		t.Errorf("task types mismatch (-want +got):\n%s", diff)
+	}
+	if answer.Passages[0].ID != "fr" || answer.Passages[1].Metadata["lang"] != "en" {
+		t.Errorf("Answer() passages = %+v, want fr first and de metadata kept", answer.Passages)
+	}
+	if !strings.Contains(gen.prompt, "[1] (fr.txt)\nParis is the capital of France.") ||
+		!strings.Contains(gen.prompt, "Question: What is the capital of France?") {
+		t.Errorf("prompt does not contain the numbered passages and the question:\n%s", gen.prompt)
+	}
+
+	type citation struct {
+		Text string
+		IDs  []string
+	}
+	var got []citation
+	for _, c := range answer.Citations {
+		var ids []string
+		for _, p := range c.Passages {
+			ids = append(ids, p.ID)
+		}
+		got = append(got, citation{answer.Text[c.Start:c.End], ids})
+	}
+	want := []citation{
+		{"The capital of France is Paris", []string{"fr"}},
+		{"Germany's is Berlin", []string{"de"}},
+		{"Both are capitals", []string{"fr", "de"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Answer() citations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseCitations(t *testing.T) {
+	passages := []*Passage{{ID: "a"}, {ID: "b"}}
+	text := "First claim [1, 2] and second claim [3].\nNo citation here."
+	got := ParseCitations(text, passages)
+	if len(got) != 1 {
+		t.Fatalf("ParseCitations() = %d citations, want 1", len(got))
+	}
+	if span := text[got[0].Start:got[0].End]; span != "First claim" || len(got[0].Passages) != 2 {
+		t.Errorf("ParseCitations() = %q citing %d passages, want %q citing 2", span, len(got[0].Passages), "First claim")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rag
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"google.golang.org/genai"
+	"google.golang.org/genai/embeddings"
+)
+
+// Passage is a piece of source text that can be retrieved.
+type Passage struct {
+	// ID identifies the passage in its retriever.
+	ID string `json:"id"`
+	// Text is the content of the passage.
+	Text string `json:"text"`
+	// Source describes where the passage comes from, such as a URI or a title.
+	// It is shown to the model next to the passage.
+	Source string `json:"source,omitempty"`
+	// Metadata is free-form data kept with the passage.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Score is the relevance of the passage to the query, set by
+	// [Retriever.Retrieve]. Higher is more relevant.
+	Score float64 `json:"score,omitempty"`
+}
+
+// Retriever finds the passages relevant to a query.
+type Retriever interface {
+	// Retrieve returns at most k passages relevant to query, most relevant
+	// first.
+	Retrieve(ctx context.Context, query string, k int) ([]*Passage, error)
+}
+
+const (
+	metadataText   = "rag.text"
+	metadataSource = "rag.source"
+)
+
+// IndexRetriever is a [Retriever] that embeds passages with a model and
+// searches them in an in-memory [embeddings.Index].
+type IndexRetriever struct {
+	embedder embeddings.ContentEmbedder
+	model    string
+	config   embeddings.Config
+	// Index holds the embedded passages. It can be saved and loaded to avoid
+	// embedding the passages again.
+	Index *embeddings.Index
+}
+
+// NewIndexRetriever returns an empty IndexRetriever that embeds with model.
+// config is used for every embedding request; its TaskType is overridden with
+// RETRIEVAL_DOCUMENT for passages and RETRIEVAL_QUERY for queries.
+func NewIndexRetriever(e embeddings.ContentEmbedder, model string, config *embeddings.Config) *IndexRetriever {
+	r := &IndexRetriever{embedder: e, model: model, Index: &embeddings.Index{}}
+	if config != nil {
+		r.config = *config
+	}
+	return r
+}
+
+func (r *IndexRetriever) embedConfig(taskType string) *embeddings.Config {
+	c := r.config
+	ec := genai.EmbedContentConfig{}
+	if c.EmbedConfig != nil {
+		ec = *c.EmbedConfig
+	}
+	ec.TaskType = taskType
+	c.EmbedConfig = &ec
+	return &c
+}
+
+// Add embeds the passages and stores them in the index, replacing passages
+// with the same ID.
+func (r *IndexRetriever) Add(ctx context.Context, passages []*Passage) error {
+	texts := make([]string, len(passages))
+	for i, p := range passages {
+		if p == nil || p.ID == "" {
+			return fmt.Errorf("passage %d has no ID", i)
+		}
+		texts[i] = p.Text
+	}
+	vectors, err := embeddings.EmbedTexts(ctx, r.embedder, r.model, texts, r.embedConfig("RETRIEVAL_DOCUMENT"))
+	if err != nil {
+		return err
+	}
+	for i, p := range passages {
+		metadata := maps.Clone(p.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[metadataText] = p.Text
+		metadata[metadataSource] = p.Source
+		if err := r.Index.Add(embeddings.Entry{ID: p.ID, Vector: vectors[i], Metadata: metadata}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Retrieve embeds query and returns the k most similar passages.
+func (r *IndexRetriever) Retrieve(ctx context.Context, query string, k int) ([]*Passage, error) {
+	vectors, err := embeddings.EmbedTexts(ctx, r.embedder, r.model, []string{query}, r.embedConfig("RETRIEVAL_QUERY"))
+	if err != nil {
+		return nil, err
+	}
+	matches, err := r.Index.Search(vectors[0], k)
+	if err != nil {
+		return nil, err
+	}
+	passages := make([]*Passage, len(matches))
+	for i, m := range matches {
+		metadata := maps.Clone(m.Metadata)
+		p := &Passage{ID: m.ID, Text: metadata[metadataText], Source: metadata[metadataSource], Score: m.Score}
+		delete(metadata, metadataText)
+		delete(metadata, metadataSource)
+		if len(metadata) > 0 {
+			p.Metadata = metadata
+		}
+		passages[i] = p
+	}
+	return passages, nil
+}
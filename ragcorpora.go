@@ -0,0 +1,629 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RAGCorpusState is the state of a [RAGCorpus].
+type RAGCorpusState string
+
+const (
+	// RAGCorpusStateUnspecified means the state is unknown.
+	RAGCorpusStateUnspecified RAGCorpusState = "UNKNOWN"
+	// RAGCorpusStateInitialized means the corpus was created but is not
+	// ready yet.
+	RAGCorpusStateInitialized RAGCorpusState = "INITIALIZED"
+	// RAGCorpusStateActive means the corpus is ready for imports and retrieval.
+	RAGCorpusStateActive RAGCorpusState = "ACTIVE"
+	// RAGCorpusStateError means the corpus could not be provisioned.
+	RAGCorpusStateError RAGCorpusState = "ERROR"
+)
+
+// RAGFileState is the state of a [RAGFile].
+type RAGFileState string
+
+const (
+	// RAGFileStateUnspecified means the state is unknown.
+	RAGFileStateUnspecified RAGFileState = "STATE_UNSPECIFIED"
+	// RAGFileStateActive means the file is indexed and ready for retrieval.
+	RAGFileStateActive RAGFileState = "ACTIVE"
+	// RAGFileStateError means the file could not be processed.
+	RAGFileStateError RAGFileState = "ERROR"
+)
+
+// A Vertex AI RAG Engine corpus, a collection of [RAGFile]s that a
+// [VertexRAGStore] retrieves from. It is the Vertex AI counterpart of
+// [FileSearchStore]. This data type is not supported in Gemini API.
+type RAGCorpus struct {
+	// The resource name of the RAGCorpus. Example:
+	// `projects/my-project/locations/us-central1/ragCorpora/123`
+	Name string `json:"name,omitempty"`
+	// Optional. The human-readable display name for the RAGCorpus.
+	DisplayName string `json:"displayName,omitempty"`
+	// Optional. The description of the RAGCorpus.
+	Description string `json:"description,omitempty"`
+	// The state of the RAGCorpus.
+	State RAGCorpusState `json:"state,omitempty"`
+	// Details of the error when State is RAGCorpusStateError.
+	ErrorStatus string `json:"errorStatus,omitempty"`
+	// The Timestamp of when the RAGCorpus was created.
+	CreateTime time.Time `json:"createTime,omitempty"`
+	// The Timestamp of when the RAGCorpus was last updated.
+	UpdateTime time.Time `json:"updateTime,omitempty"`
+}
+
+func (c *RAGCorpus) UnmarshalJSON(data []byte) error {
+	type Alias RAGCorpus
+	aux := &struct {
+		CreateTime   *time.Time `json:"createTime,omitempty"`
+		UpdateTime   *time.Time `json:"updateTime,omitempty"`
+		CorpusStatus *struct {
+			State       RAGCorpusState `json:"state,omitempty"`
+			ErrorStatus string         `json:"errorStatus,omitempty"`
+		} `json:"corpusStatus,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.CreateTime != nil {
+		c.CreateTime = *aux.CreateTime
+	}
+	if aux.UpdateTime != nil {
+		c.UpdateTime = *aux.UpdateTime
+	}
+	if aux.CorpusStatus != nil {
+		c.State = aux.CorpusStatus.State
+		c.ErrorStatus = aux.CorpusStatus.ErrorStatus
+	}
+	return nil
+}
+
+// A file of a [RAGCorpus]. It is the Vertex AI counterpart of [Document]. This
+// data type is not supported in Gemini API.
+type RAGFile struct {
+	// The resource name of the RAGFile. Example:
+	// `projects/my-project/locations/us-central1/ragCorpora/123/ragFiles/456`
+	Name string `json:"name,omitempty"`
+	// Optional. The human-readable display name for the RAGFile.
+	DisplayName string `json:"displayName,omitempty"`
+	// Optional. The description of the RAGFile.
+	Description string `json:"description,omitempty"`
+	// The Cloud Storage URIs the RAGFile was imported from.
+	GCSURIs []string `json:"gcsUris,omitempty"`
+	// The state of the RAGFile.
+	State RAGFileState `json:"state,omitempty"`
+	// Details of the error when State is RAGFileStateError.
+	ErrorStatus string `json:"errorStatus,omitempty"`
+	// The Timestamp of when the RAGFile was created.
+	CreateTime time.Time `json:"createTime,omitempty"`
+	// The Timestamp of when the RAGFile was last updated.
+	UpdateTime time.Time `json:"updateTime,omitempty"`
+}
+
+func (f *RAGFile) UnmarshalJSON(data []byte) error {
+	type Alias RAGFile
+	aux := &struct {
+		CreateTime *time.Time `json:"createTime,omitempty"`
+		UpdateTime *time.Time `json:"updateTime,omitempty"`
+		GCSSource  *struct {
+			URIs []string `json:"uris,omitempty"`
+		} `json:"gcsSource,omitempty"`
+		FileStatus *struct {
+			State       RAGFileState `json:"state,omitempty"`
+			ErrorStatus string       `json:"errorStatus,omitempty"`
+		} `json:"fileStatus,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(f),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.CreateTime != nil {
+		f.CreateTime = *aux.CreateTime
+	}
+	if aux.UpdateTime != nil {
+		f.UpdateTime = *aux.UpdateTime
+	}
+	if aux.GCSSource != nil {
+		f.GCSURIs = aux.GCSSource.URIs
+	}
+	if aux.FileStatus != nil {
+		f.State = aux.FileStatus.State
+		f.ErrorStatus = aux.FileStatus.ErrorStatus
+	}
+	return nil
+}
+
+// Optional parameters for creating a RAG corpus.
+type CreateRAGCorpusConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. The human-readable display name for the RAG corpus.
+	DisplayName string `json:"displayName,omitempty"`
+	// Optional. The description of the RAG corpus.
+	Description string `json:"description,omitempty"`
+	// Optional. Initial delay between two polls of the creation operation.
+	// Defaults to 1 second.
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+	// Optional. Upper bound of the delay between two polls of the creation
+	// operation. Defaults to 30 seconds.
+	MaxPollInterval time.Duration `json:"maxPollInterval,omitempty"`
+}
+
+// Optional parameters for getting a RAG corpus.
+type GetRAGCorpusConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+}
+
+// Optional parameters for deleting a RAG corpus.
+type DeleteRAGCorpusConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. If true, any RAGFiles in this RAG corpus will also be deleted.
+	// Otherwise, the request will only succeed if the RAG corpus has no
+	// RAGFiles.
+	Force *bool `json:"force,omitempty"`
+}
+
+// Optional parameters for listing RAG corpora.
+type ListRAGCorporaConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. PageSize specifies the maximum number of RAG corpora to return per
+	// API call. If zero, the server will use a default value.
+	PageSize int32 `json:"pageSize,omitempty"`
+	// Optional. PageToken represents a token used for pagination in API responses. It's
+	// an opaque string that should be passed to subsequent requests to retrieve the next
+	// page of results. An empty PageToken typically indicates that there are no further
+	// pages available.
+	PageToken string `json:"pageToken,omitempty"`
+}
+
+// Config for rag_corpora.list return value.
+type ListRAGCorporaResponse struct {
+	// Optional. Used to retain the full HTTP response.
+	SDKHTTPResponse *HTTPResponse `json:"sdkHttpResponse,omitempty"`
+
+	NextPageToken string `json:"nextPageToken,omitempty"`
+	// The returned RAG corpora.
+	RAGCorpora []*RAGCorpus `json:"ragCorpora,omitempty"`
+}
+
+// Optional parameters for importing files into a RAG corpus.
+type ImportRAGFilesConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. The size in tokens of the chunks the files are split into.
+	ChunkSize int32 `json:"chunkSize,omitempty"`
+	// Optional. The number of tokens shared by two adjacent chunks.
+	ChunkOverlap int32 `json:"chunkOverlap,omitempty"`
+	// Optional. The maximum number of embedding requests per minute issued by
+	// the import.
+	MaxEmbeddingRequestsPerMin int32 `json:"maxEmbeddingRequestsPerMin,omitempty"`
+}
+
+// Response for ImportFiles to import Cloud Storage files into a RAG corpus.
+type ImportRAGFilesResponse struct {
+	// The number of files imported.
+	ImportedRAGFilesCount int64 `json:"importedRagFilesCount,omitempty,string"`
+	// The number of files that could not be imported.
+	FailedRAGFilesCount int64 `json:"failedRagFilesCount,omitempty,string"`
+	// The number of files skipped because they were already imported.
+	SkippedRAGFilesCount int64 `json:"skippedRagFilesCount,omitempty,string"`
+}
+
+// Long-running operation for importing files into a RAG corpus.
+type ImportRAGFilesOperation struct {
+	// The server-assigned name, which is only unique within the same service that originally
+	// returns it. If you use the default HTTP mapping, the `name` should be a resource
+	// name ending with `operations/{unique_id}`.
+	Name string `json:"name,omitempty"`
+	// Optional. Service-specific metadata associated with the operation. It typically contains
+	// progress information and common metadata such as create time. Some services might
+	// not provide such metadata. Any method that returns a long-running operation should
+	// document the metadata type, if any.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// If the value is `false`, it means the operation is still in progress. If `true`,
+	// the operation is completed, and either `error` or `response` is available.
+	Done bool `json:"done,omitempty"`
+	// Optional. The error result of the operation in case of failure or cancellation.
+	Error map[string]any `json:"error,omitempty"`
+	// Optional. The result of the ImportFiles operation, available when the operation is
+	// done.
+	Response *ImportRAGFilesResponse `json:"response,omitempty"`
+}
+
+// Optional parameters for getting a RAG file.
+type GetRAGFileConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+}
+
+// Optional parameters for deleting a RAG file.
+type DeleteRAGFileConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+}
+
+// Optional parameters for listing RAG files.
+type ListRAGFilesConfig struct {
+	// Optional. Used to override HTTP request options.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. PageSize specifies the maximum number of RAG files to return per
+	// API call. If zero, the server will use a default value.
+	PageSize int32 `json:"pageSize,omitempty"`
+	// Optional. PageToken represents a token used for pagination in API responses. It's
+	// an opaque string that should be passed to subsequent requests to retrieve the next
+	// page of results. An empty PageToken typically indicates that there are no further
+	// pages available.
+	PageToken string `json:"pageToken,omitempty"`
+}
+
+// Config for rag_files.list return value.
+type ListRAGFilesResponse struct {
+	// Optional. Used to retain the full HTTP response.
+	SDKHTTPResponse *HTTPResponse `json:"sdkHttpResponse,omitempty"`
+
+	NextPageToken string `json:"nextPageToken,omitempty"`
+	// The returned RAG files.
+	RAGFiles []*RAGFile `json:"ragFiles,omitempty"`
+}
+
+// RAGCorpora provides methods for managing Vertex AI RAG Engine corpora, the
+// Vertex AI counterpart of [FileSearchStores]. Corpora are referenced from
+// [VertexRAGStore] in a [Retrieval] tool.
+// You don't need to initiate this struct. Create a client instance via NewClient, and
+// then access RAGCorpora through client.RAGCorpora field.
+type RAGCorpora struct {
+	apiClient *apiClient
+	// Files provides access to the files of RAG corpora.
+	Files *RAGFiles
+}
+
+// RAGFiles provides methods for managing the files of Vertex AI RAG Engine
+// corpora, the Vertex AI counterpart of [Documents].
+// You don't need to initiate this struct. Create a client instance via NewClient, and
+// then access RAGFiles through client.RAGCorpora.Files field.
+type RAGFiles struct {
+	apiClient *apiClient
+}
+
+func ragVertexOnly(ac *apiClient, method string) error {
+	if ac.clientConfig.Backend != BackendVertexAI {
+		return fmt.Errorf("method %s is only supported in the Vertex AI client. You can choose to use Vertex AI by setting ClientConfig.Backend to BackendVertexAI.", method)
+	}
+	return nil
+}
+
+// ragRequest sends a RAG Engine request and decodes the response into out,
+// which may be nil.
+func ragRequest(ctx context.Context, ac *apiClient, method, path string, query url.Values, body map[string]any, httpOptions *HTTPOptions, out any) error {
+	if httpOptions == nil {
+		httpOptions = &HTTPOptions{}
+	}
+	if httpOptions.Headers == nil {
+		httpOptions.Headers = http.Header{}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	responseMap, err := sendRequest(ctx, ac, path, method, body, httpOptions)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	b, err := json.Marshal(responseMap)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func pageQuery(pageSize int32, pageToken string) url.Values {
+	query := url.Values{}
+	if pageSize > 0 {
+		query.Set("pageSize", strconv.Itoa(int(pageSize)))
+	}
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+	return query
+}
+
+// ragOperation is a long-running operation returned by the RAG Engine.
+type ragOperation struct {
+	Name     string         `json:"name,omitempty"`
+	Done     bool           `json:"done,omitempty"`
+	Error    map[string]any `json:"error,omitempty"`
+	Response map[string]any `json:"response,omitempty"`
+}
+
+// waitRAGOperation polls op until it is done and returns its response.
+func waitRAGOperation(ctx context.Context, ac *apiClient, op *ragOperation, backoff *pollBackoff, httpOptions *HTTPOptions) (map[string]any, error) {
+	for !op.Done {
+		if err := sleepContext(ctx, backoff.next()); err != nil {
+			return nil, err
+		}
+		next := new(ragOperation)
+		if err := ragRequest(ctx, ac, http.MethodGet, op.Name, nil, nil, httpOptions, next); err != nil {
+			return nil, err
+		}
+		op = next
+	}
+	if op.Error != nil {
+		return nil, fmt.Errorf("operation %s failed: %v", op.Name, op.Error["message"])
+	}
+	return op.Response, nil
+}
+
+// Create creates a RAG corpus and waits until it is provisioned.
+func (m RAGCorpora) Create(ctx context.Context, config *CreateRAGCorpusConfig) (*RAGCorpus, error) {
+	if err := ragVertexOnly(m.apiClient, "Create"); err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &CreateRAGCorpusConfig{}
+	}
+	body := map[string]any{}
+	if config.DisplayName != "" {
+		body["displayName"] = config.DisplayName
+	}
+	if config.Description != "" {
+		body["description"] = config.Description
+	}
+	op := new(ragOperation)
+	if err := ragRequest(ctx, m.apiClient, http.MethodPost, "ragCorpora", nil, body, config.HTTPOptions, op); err != nil {
+		return nil, err
+	}
+	backoff := newPollBackoff(config.PollInterval, config.MaxPollInterval, 0)
+	response, err := waitRAGOperation(ctx, m.apiClient, op, backoff, config.HTTPOptions)
+	if err != nil {
+		return nil, err
+	}
+	corpus := new(RAGCorpus)
+	if err := mapToStruct(response, corpus); err != nil {
+		return nil, err
+	}
+	return corpus, nil
+}
+
+// Get gets a RAG corpus.
+func (m RAGCorpora) Get(ctx context.Context, name string, config *GetRAGCorpusConfig) (*RAGCorpus, error) {
+	if err := ragVertexOnly(m.apiClient, "Get"); err != nil {
+		return nil, err
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	corpus := new(RAGCorpus)
+	if err := ragRequest(ctx, m.apiClient, http.MethodGet, name, nil, nil, httpOptions, corpus); err != nil {
+		return nil, err
+	}
+	return corpus, nil
+}
+
+// Delete deletes a RAG corpus. Deletion completes asynchronously.
+func (m RAGCorpora) Delete(ctx context.Context, name string, config *DeleteRAGCorpusConfig) error {
+	if err := ragVertexOnly(m.apiClient, "Delete"); err != nil {
+		return err
+	}
+	var httpOptions *HTTPOptions
+	query := url.Values{}
+	if config != nil {
+		httpOptions = config.HTTPOptions
+		if config.Force != nil {
+			query.Set("force", strconv.FormatBool(*config.Force))
+		}
+	}
+	return ragRequest(ctx, m.apiClient, http.MethodDelete, name, query, nil, httpOptions, nil)
+}
+
+func (m RAGCorpora) list(ctx context.Context, config *ListRAGCorporaConfig) (*ListRAGCorporaResponse, error) {
+	if err := ragVertexOnly(m.apiClient, "List"); err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &ListRAGCorporaConfig{}
+	}
+	response := new(ListRAGCorporaResponse)
+	if err := ragRequest(ctx, m.apiClient, http.MethodGet, "ragCorpora", pageQuery(config.PageSize, config.PageToken), nil, config.HTTPOptions, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// List retrieves a paginated list of RAG corpora.
+func (m RAGCorpora) List(ctx context.Context, config *ListRAGCorporaConfig) (Page[RAGCorpus], error) {
+	listFunc := func(ctx context.Context, config map[string]any) ([]*RAGCorpus, string, *HTTPResponse, error) {
+		var c ListRAGCorporaConfig
+		if err := mapToStruct(config, &c); err != nil {
+			return nil, "", nil, err
+		}
+		resp, err := m.list(ctx, &c)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return resp.RAGCorpora, resp.NextPageToken, resp.SDKHTTPResponse, nil
+	}
+	c := make(map[string]any)
+	deepMarshal(config, &c)
+	return newPage(ctx, "ragCorpora", c, listFunc)
+}
+
+// All retrieves all RAG corpora.
+//
+// This method handles pagination internally, making multiple API calls as needed
+// to fetch all entries. It returns an iterator that yields each RAG corpus one
+// by one.
+func (m RAGCorpora) All(ctx context.Context) iter.Seq2[*RAGCorpus, error] {
+	p, err := m.List(ctx, nil)
+	if err != nil {
+		return yieldErrorAndEndIterator[RAGCorpus](err)
+	}
+	return p.all(ctx)
+}
+
+// ImportFiles imports Cloud Storage files into a RAG corpus and returns the
+// long-running operation. gcsURIs are `gs://` URIs of files or of folders whose
+// files are all imported. Poll the operation with [RAGCorpora.GetImportFilesOperation].
+func (m RAGCorpora) ImportFiles(ctx context.Context, ragCorpusName string, gcsURIs []string, config *ImportRAGFilesConfig) (*ImportRAGFilesOperation, error) {
+	if err := ragVertexOnly(m.apiClient, "ImportFiles"); err != nil {
+		return nil, err
+	}
+	if len(gcsURIs) == 0 {
+		return nil, fmt.Errorf("gcsURIs is required")
+	}
+	if config == nil {
+		config = &ImportRAGFilesConfig{}
+	}
+	importConfig := map[string]any{
+		"gcsSource": map[string]any{"uris": gcsURIs},
+	}
+	if config.ChunkSize > 0 || config.ChunkOverlap > 0 {
+		chunking := map[string]any{}
+		if config.ChunkSize > 0 {
+			chunking["chunkSize"] = config.ChunkSize
+		}
+		if config.ChunkOverlap > 0 {
+			chunking["chunkOverlap"] = config.ChunkOverlap
+		}
+		importConfig["ragFileTransformationConfig"] = map[string]any{
+			"ragFileChunkingConfig": map[string]any{"fixedLengthChunking": chunking},
+		}
+	}
+	if config.MaxEmbeddingRequestsPerMin > 0 {
+		importConfig["maxEmbeddingRequestsPerMin"] = config.MaxEmbeddingRequestsPerMin
+	}
+	op := new(ImportRAGFilesOperation)
+	body := map[string]any{"importRagFilesConfig": importConfig}
+	if err := ragRequest(ctx, m.apiClient, http.MethodPost, ragCorpusName+"/ragFiles:import", nil, body, config.HTTPOptions, op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// GetImportFilesOperation gets the latest state of an operation returned by
+// [RAGCorpora.ImportFiles].
+func (m RAGCorpora) GetImportFilesOperation(ctx context.Context, operation *ImportRAGFilesOperation, config *GetOperationConfig) (*ImportRAGFilesOperation, error) {
+	if err := ragVertexOnly(m.apiClient, "GetImportFilesOperation"); err != nil {
+		return nil, err
+	}
+	if operation == nil || operation.Name == "" {
+		return nil, fmt.Errorf("operation name is required")
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	op := new(ImportRAGFilesOperation)
+	if err := ragRequest(ctx, m.apiClient, http.MethodGet, operation.Name, nil, nil, httpOptions, op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// Get gets a RAG file.
+func (m RAGFiles) Get(ctx context.Context, name string, config *GetRAGFileConfig) (*RAGFile, error) {
+	if err := ragVertexOnly(m.apiClient, "Get"); err != nil {
+		return nil, err
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	file := new(RAGFile)
+	if err := ragRequest(ctx, m.apiClient, http.MethodGet, name, nil, nil, httpOptions, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Delete deletes a RAG file. Deletion completes asynchronously.
+func (m RAGFiles) Delete(ctx context.Context, name string, config *DeleteRAGFileConfig) error {
+	if err := ragVertexOnly(m.apiClient, "Delete"); err != nil {
+		return err
+	}
+	var httpOptions *HTTPOptions
+	if config != nil {
+		httpOptions = config.HTTPOptions
+	}
+	return ragRequest(ctx, m.apiClient, http.MethodDelete, name, nil, nil, httpOptions, nil)
+}
+
+func (m RAGFiles) list(ctx context.Context, parent string, config *ListRAGFilesConfig) (*ListRAGFilesResponse, error) {
+	if err := ragVertexOnly(m.apiClient, "List"); err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &ListRAGFilesConfig{}
+	}
+	response := new(ListRAGFilesResponse)
+	if err := ragRequest(ctx, m.apiClient, http.MethodGet, parent+"/ragFiles", pageQuery(config.PageSize, config.PageToken), nil, config.HTTPOptions, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// List retrieves a paginated list of the files of a RAG corpus.
+func (m RAGFiles) List(ctx context.Context, parent string, config *ListRAGFilesConfig) (Page[RAGFile], error) {
+	listFunc := func(ctx context.Context, config map[string]any) ([]*RAGFile, string, *HTTPResponse, error) {
+		var c ListRAGFilesConfig
+		if err := mapToStruct(config, &c); err != nil {
+			return nil, "", nil, err
+		}
+		resp, err := m.list(ctx, parent, &c)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return resp.RAGFiles, resp.NextPageToken, resp.SDKHTTPResponse, nil
+	}
+	c := make(map[string]any)
+	deepMarshal(config, &c)
+	return newPage(ctx, "ragFiles", c, listFunc)
+}
+
+// All retrieves all files of a RAG corpus.
+//
+// This method handles pagination internally, making multiple API calls as needed
+// to fetch all entries. It returns an iterator that yields each RAG file one by
+// one.
+func (m RAGFiles) All(ctx context.Context, parent string) iter.Seq2[*RAGFile, error] {
+	p, err := m.List(ctx, parent, nil)
+	if err != nil {
+		return yieldErrorAndEndIterator[RAGFile](err)
+	}
+	return p.all(ctx)
+}
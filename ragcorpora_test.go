@@ -0,0 +1,250 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testRAGParent = "/v1beta1/projects/test-project/locations/us-central1"
+
+func newTestRAGClient(t *testing.T, ts *httptest.Server) *Client {
+	t.Helper()
+	client, err := NewClient(context.Background(), &ClientConfig{
+		Backend:     BackendVertexAI,
+		Project:     "test-project",
+		Location:    "us-central1",
+		HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+		HTTPClient:  ts.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestRAGCorporaCreate(t *testing.T) {
+	corpusName := "projects/test-project/locations/us-central1/ragCorpora/123"
+	opName := corpusName + "/operations/456"
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == testRAGParent+"/ragCorpora":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+			want := map[string]any{"displayName": "docs", "description": "product docs"}
+			if diff := cmp.Diff(want, body); diff != "" {
+				t.Errorf("request body mismatch (-want +got):\n%s", diff)
+			}
+			w.Write([]byte(`{"name": "` + opName + `"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta1/"+opName:
+			polls++
+			if polls < 2 {
+				w.Write([]byte(`{"name": "` + opName + `"}`))
+				return
+			}
+			w.Write([]byte(`{"name": "` + opName + `", "done": true, "response": {
+				"name": "` + corpusName + `", "displayName": "docs", "description": "product docs",
+				"corpusStatus": {"state": "ACTIVE"}, "createTime": "2025-01-02T03:04:05Z"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	client := newTestRAGClient(t, ts)
+
+	got, err := client.RAGCorpora.Create(context.Background(), &CreateRAGCorpusConfig{
+		DisplayName:  "docs",
+		Description:  "product docs",
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Create() failed unexpectedly: %v", err)
+	}
+	want := &RAGCorpus{
+		Name:        corpusName,
+		DisplayName: "docs",
+		Description: "product docs",
+		State:       RAGCorpusStateActive,
+		CreateTime:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Create() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRAGCorporaCreateOperationError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "projects/p/locations/l/operations/1", "done": true, "error": {"code": 7, "message": "permission denied"}}`))
+	}))
+	defer ts.Close()
+	client := newTestRAGClient(t, ts)
+
+	_, err := client.RAGCorpora.Create(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Create() error = %v, want error containing %q", err, "permission denied")
+	}
+}
+
+func TestRAGCorporaAll(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != testRAGParent+"/ragCorpora" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("pageToken") == "" {
+			w.Write([]byte(`{"ragCorpora": [{"name": "c1"}], "nextPageToken": "next"}`))
+			return
+		}
+		w.Write([]byte(`{"ragCorpora": [{"name": "c2", "corpusStatus": {"state": "ERROR", "errorStatus": "quota"}}]}`))
+	}))
+	defer ts.Close()
+	client := newTestRAGClient(t, ts)
+
+	var got []*RAGCorpus
+	for corpus, err := range client.RAGCorpora.All(context.Background()) {
+		if err != nil {
+			t.Fatalf("All() failed unexpectedly: %v", err)
+		}
+		got = append(got, corpus)
+	}
+	want := []*RAGCorpus{
+		{Name: "c1"},
+		{Name: "c2", State: RAGCorpusStateError, ErrorStatus: "quota"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("All() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRAGCorporaImportFiles(t *testing.T) {
+	corpusName := "projects/test-project/locations/us-central1/ragCorpora/123"
+	opName := corpusName + "/operations/789"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1beta1/"+corpusName+"/ragFiles:import":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+			want := map[string]any{"importRagFilesConfig": map[string]any{
+				"gcsSource": map[string]any{"uris": []any{"gs://bucket/docs/"}},
+				"ragFileTransformationConfig": map[string]any{
+					"ragFileChunkingConfig": map[string]any{
+						"fixedLengthChunking": map[string]any{"chunkSize": float64(512), "chunkOverlap": float64(64)},
+					},
+				},
+			}}
+			if diff := cmp.Diff(want, body); diff != "" {
+				t.Errorf("request body mismatch (-want +got):\n%s", diff)
+			}
+			w.Write([]byte(`{"name": "` + opName + `"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta1/"+opName:
+			w.Write([]byte(`{"name": "` + opName + `", "done": true, "response": {"importedRagFilesCount": "3", "skippedRagFilesCount": "1"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	client := newTestRAGClient(t, ts)
+	ctx := context.Background()
+
+	op, err := client.RAGCorpora.ImportFiles(ctx, corpusName, []string{"gs://bucket/docs/"}, &ImportRAGFilesConfig{ChunkSize: 512, ChunkOverlap: 64})
+	if err != nil {
+		t.Fatalf("ImportFiles() failed unexpectedly: %v", err)
+	}
+	if diff := cmp.Diff(&ImportRAGFilesOperation{Name: opName}, op); diff != "" {
+		t.Errorf("ImportFiles() mismatch (-want +got):\n%s", diff)
+	}
+	got, err := client.RAGCorpora.GetImportFilesOperation(ctx, op, nil)
+	if err != nil {
+		t.Fatalf("GetImportFilesOperation() failed unexpectedly: %v", err)
+	}
+	want := &ImportRAGFilesOperation{
+		Name:     opName,
+		Done:     true,
+		Response: &ImportRAGFilesResponse{ImportedRAGFilesCount: 3, SkippedRAGFilesCount: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetImportFilesOperation() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRAGFiles(t *testing.T) {
+	corpusName := "projects/test-project/locations/us-central1/ragCorpora/123"
+	fileName := corpusName + "/ragFiles/1"
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta1/"+corpusName+"/ragFiles":
+			w.Write([]byte(`{"ragFiles": [{"name": "` + fileName + `", "displayName": "a.pdf",
+				"gcsSource": {"uris": ["gs://bucket/docs/a.pdf"]}, "fileStatus": {"state": "ACTIVE"}}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1beta1/"+fileName:
+			deleted = append(deleted, fileName)
+			w.Write([]byte(`{"name": "` + fileName + `/operations/1"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	client := newTestRAGClient(t, ts)
+	ctx := context.Background()
+
+	var got []*RAGFile
+	for file, err := range client.RAGCorpora.Files.All(ctx, corpusName) {
+		if err != nil {
+			t.Fatalf("All() failed unexpectedly: %v", err)
+		}
+		got = append(got, file)
+	}
+	want := []*RAGFile{{
+		Name:        fileName,
+		DisplayName: "a.pdf",
+		GCSURIs:     []string{"gs://bucket/docs/a.pdf"},
+		State:       RAGFileStateActive,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("All() mismatch (-want +got):\n%s", diff)
+	}
+	if err := client.RAGCorpora.Files.Delete(ctx, fileName, nil); err != nil {
+		t.Fatalf("Delete() failed unexpectedly: %v", err)
+	}
+	if diff := cmp.Diff([]string{fileName}, deleted); diff != "" {
+		t.Errorf("deleted files mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRAGCorporaGeminiAPI(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	_, err := client.RAGCorpora.Get(context.Background(), "projects/p/locations/l/ragCorpora/1", nil)
+	if err == nil || !strings.Contains(err.Error(), "only supported in the Vertex AI client") {
+		t.Errorf("Get() error = %v, want Vertex-only error", err)
+	}
+}
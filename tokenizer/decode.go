@@ -0,0 +1,190 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	sentencepiece "github.com/eliben/go-sentencepiece"
+	"google.golang.org/genai"
+)
+
+// Decode returns the text represented by tokenIDs, such as the TokenIDs of a
+// [genai.TokensInfo] returned by [LocalTokenizer.ComputeTokens].
+func (tok *LocalTokenizer) Decode(tokenIDs []int64) (string, error) {
+	vocabularySize := tok.processor.ModelInfo().VocabularySize
+	ids := make([]int, len(tokenIDs))
+	for i, id := range tokenIDs {
+		if id < 0 || id >= int64(vocabularySize) {
+			return "", fmt.Errorf("token ID %d out of range [0, %d)", id, vocabularySize)
+		}
+		ids[i] = int(id)
+	}
+	return tok.processor.Decode(ids), nil
+}
+
+// TruncateToTokens returns the longest prefix of text that has at most
+// maxTokens tokens, and its number of tokens. The prefix ends on a token
+// boundary and never splits a UTF-8 encoded character, so it can have fewer
+// than maxTokens tokens when a character spans several byte tokens.
+func (tok *LocalTokenizer) TruncateToTokens(text string, maxTokens int) (string, int) {
+	if maxTokens <= 0 {
+		return "", 0
+	}
+	tokens := tok.processor.Encode(text)
+	if len(tokens) <= maxTokens {
+		return text, len(tokens)
+	}
+	ends := tokenEnds(text, tokens)
+	for n := maxTokens; n > 0; n-- {
+		end := ends[n-1]
+		for end > 0 && end < len(text) && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		// Encoding a prefix can merge differently than the whole text, so
+		// check the count of the prefix itself.
+		if count := len(tok.processor.Encode(text[:end])); end > 0 && count <= maxTokens {
+			return text[:end], count
+		}
+	}
+	return "", 0
+}
+
+// tokenEnds returns the byte offset in text of the end of each token.
+func tokenEnds(text string, tokens []sentencepiece.Token) []int {
+	ends := make([]int, len(tokens))
+	offset := 0
+	for i, t := range tokens {
+		if len(t.Text) == 6 && strings.HasPrefix(t.Text, "<0x") && strings.HasSuffix(t.Text, ">") {
+			offset++
+		} else {
+			offset += len(strings.ReplaceAll(t.Text, "▁", " "))
+		}
+		ends[i] = min(offset, len(text))
+	}
+	return ends
+}
+
+// Counter counts the tokens of a text received in chunks, such as the text of
+// the responses of [genai.Models.GenerateContentStream]. Chunks can split words
+// and characters anywhere; the running total is the count of the text
+// received so far.
+//
+// A Counter is safe for concurrent use by multiple goroutines.
+type Counter struct {
+	tok *LocalTokenizer
+
+	mu        sync.Mutex
+	committed int
+	tail      string
+}
+
+// NewCounter returns a [Counter] that counts tokens with tok.
+func (tok *LocalTokenizer) NewCounter() *Counter {
+	return &Counter{tok: tok}
+}
+
+// maxCounterTail is the length in bytes beyond which the text a [Counter]
+// tokenizes again is cut at a token boundary.
+const maxCounterTail = 1024
+
+// Add appends text to the counted text and returns the total number of tokens.
+//
+// Only the text after the last word boundary is tokenized again when more
+// text is added, so adding chunks costs time proportional to their length.
+// For text without spaces, such as Chinese or Japanese, that text is cut at a
+// token boundary once it is longer than 1 KiB, so the total can differ
+// slightly from the count of the whole text.
+func (c *Counter) Add(text string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tail += text
+	// Tokens start with the space before a word, so the text before such a
+	// space is not affected by what follows.
+	if cut := lastWordBoundary(c.tail); cut > 0 {
+		c.committed += c.tok.countText(c.tail[:cut])
+		c.tail = c.tail[cut:]
+	}
+	if len(c.tail) > maxCounterTail {
+		c.cutTail()
+	}
+	return c.committed + c.tok.countText(c.tail)
+}
+
+// cutTail commits the tokens of the tail up to a token boundary, keeping at
+// least half of maxCounterTail, since the last tokens may still merge with
+// the text that follows.
+func (c *Counter) cutTail() {
+	ends := tokenEnds(c.tail, c.tok.processor.Encode(c.tail))
+	limit := len(c.tail) - maxCounterTail/2
+	for i := len(ends) - 1; i >= 0; i-- {
+		end := ends[i]
+		if end <= 0 || end > limit || !utf8.RuneStart(c.tail[end]) {
+			continue
+		}
+		c.committed += c.tok.countText(c.tail[:end])
+		c.tail = c.tail[end:]
+		return
+	}
+}
+
+// AddResponse adds the text of the first candidate of resp, excluding
+// thoughts, and returns the total number of tokens.
+func (c *Counter) AddResponse(resp *genai.GenerateContentResponse) int {
+	var sb strings.Builder
+	if resp != nil && len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			if part != nil && !part.Thought {
+				sb.WriteString(part.Text)
+			}
+		}
+	}
+	return c.Add(sb.String())
+}
+
+// Total returns the number of tokens of the text added so far.
+func (c *Counter) Total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.committed + c.tok.countText(c.tail)
+}
+
+// Reset discards the text added so far.
+func (c *Counter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed = 0
+	c.tail = ""
+}
+
+// lastWordBoundary returns the offset of the last space of s that follows a
+// non-space character and precedes one, or 0 if there is none.
+func lastWordBoundary(s string) int {
+	for i := len(s) - 2; i > 0; i-- {
+		if s[i] != ' ' {
+			continue
+		}
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[i+1:])
+		if !unicode.IsSpace(before) && !unicode.IsSpace(after) {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	sentencepiece "github.com/eliben/go-sentencepiece"
+	"google.golang.org/genai"
+)
+
+// Piece types of the SentencePiece model proto.
+const (
+	pieceNormal  = 1
+	pieceUnknown = 2
+	pieceByte    = 6
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	return appendVarint(appendVarint(b, uint64(field)<<3), v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendVarint(b, uint64(field)<<3|2)
+	return append(appendVarint(b, uint64(len(v))), v...)
+}
+
+// newTestLocalTokenizer returns a tokenizer with a tiny BPE model with byte
+// fallback. It encodes "hello world" as "hello", "▁w", "or", "ld", and
+// characters outside a-z as byte tokens.
+func newTestLocalTokenizer(t *testing.T) *LocalTokenizer {
+	t.Helper()
+	piece := func(text string, score float32, typ uint64) []byte {
+		p := appendBytesField(nil, 1, []byte(text))
+		p = appendVarint(p, 2<<3|5)
+		p = binary.LittleEndian.AppendUint32(p, math.Float32bits(score))
+		return appendVarintField(p, 3, typ)
+	}
+	var model []byte
+	model = appendBytesField(model, 1, piece("<unk>", 0, pieceUnknown))
+	for i := range 256 {
+		model = appendBytesField(model, 1, piece(fmt.Sprintf("<0x%02X>", i), 0, pieceByte))
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		model = appendBytesField(model, 1, piece(string(c), -100, pieceNormal))
+	}
+	merges := []string{"▁", "he", "ll", "llo", "hello", "▁w", "or", "ld"}
+	for i, m := range merges {
+		model = appendBytesField(model, 1, piece(m, -float32(i), pieceNormal))
+	}
+	// The processor sizes its merge buffer after the longest piece, which is
+	// long in real models.
+	model = appendBytesField(model, 1, piece(strings.Repeat("#", 64), -200, pieceNormal))
+	trainerSpec := appendVarintField(nil, 3, 2)              // model_type: BPE
+	trainerSpec = appendVarintField(trainerSpec, 35, 1)      // byte_fallback: true
+	normalizerSpec := appendVarintField(nil, 3, 0)           // add_dummy_prefix: false
+	normalizerSpec = appendVarintField(normalizerSpec, 4, 0) // remove_extra_whitespaces: false
+	model = appendBytesField(model, 2, trainerSpec)
+	model = appendBytesField(model, 3, normalizerSpec)
+
+	processor, err := sentencepiece.NewProcessor(bytes.NewReader(model))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &LocalTokenizer{processor: processor}
+}
+
+func TestNewTestLocalTokenizerFixture(t *testing.T) {
+	tok := newTestLocalTokenizer(t)
+	result, err := tok.ComputeTokens([]*genai.Content{genai.NewContentFromText("hello world", genai.RoleUser)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, token := range result.TokensInfo[0].Tokens {
+		got = append(got, string(token))
+	}
+	if want := []string{"hello", "▁w", "or", "ld"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tok := newTestLocalTokenizer(t)
+	for _, text := range []string{"hello world", "héllo wörld ✓", ""} {
+		result, err := tok.ComputeTokens([]*genai.Content{genai.NewContentFromText(text, genai.RoleUser)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, info := range result.TokensInfo {
+			ids = append(ids, info.TokenIDs...)
+		}
+		got, err := tok.Decode(ids)
+		if err != nil {
+			t.Fatalf("Decode() failed unexpectedly: %v", err)
+		}
+		if got != text {
+			t.Errorf("Decode(ComputeTokens(%q)) = %q", text, got)
+		}
+	}
+
+	if _, err := tok.Decode([]int64{-1}); err == nil {
+		t.Errorf("Decode() with negative ID got no error, want error")
+	}
+	if _, err := tok.Decode([]int64{1 << 40}); err == nil {
+		t.Errorf("Decode() with large ID got no error, want error")
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	tok := newTestLocalTokenizer(t)
+	tests := []struct {
+		text      string
+		maxTokens int
+		want      string
+		wantCount int
+	}{
+		{"hello world", 10, "hello world", 4},
+		{"hello world", 4, "hello world", 4},
+		{"hello world", 2, "hello w", 2},
+		{"hello world", 1, "hello", 1},
+		{"hello world", 0, "", 0},
+		// "é" is two byte tokens, which are kept or dropped together.
+		{"héllo", 2, "h", 1},
+		{"héllo", 3, "hé", 3},
+		// "✓" is three byte tokens.
+		{"✓✓", 5, "✓", 3},
+		{"✓", 2, "", 0},
+	}
+	for _, tt := range tests {
+		got, gotCount := tok.TruncateToTokens(tt.text, tt.maxTokens)
+		if got != tt.want || gotCount != tt.wantCount {
+			t.Errorf("TruncateToTokens(%q, %d) = %q, %d, want %q, %d", tt.text, tt.maxTokens, got, gotCount, tt.want, tt.wantCount)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("TruncateToTokens(%q, %d) = %q is not valid UTF-8", tt.text, tt.maxTokens, got)
+		}
+	}
+}
+
+func TestCounter(t *testing.T) {
+	tok := newTestLocalTokenizer(t)
+	text := "hello world héllo  wörld hello ✓ world"
+	want := tok.countText(text)
+
+	// Every split of the text into two chunks, including splits inside words
+	// and multi-byte characters.
+	for i := range len(text) + 1 {
+		c := tok.NewCounter()
+		c.Add(text[:i])
+		if got := c.Add(text[i:]); got != want {
+			t.Errorf("Add(%q) then Add(%q) = %d, want %d", text[:i], text[i:], got, want)
+		}
+	}
+
+	// One byte at a time.
+	c := tok.NewCounter()
+	for i := range len(text) {
+		c.Add(text[i : i+1])
+	}
+	if got := c.Total(); got != want {
+		t.Errorf("Total() after byte chunks = %d, want %d", got, want)
+	}
+
+	c.Reset()
+	resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content: genai.NewContentFromParts([]*genai.Part{
+			{Text: "thinking", Thought: true},
+			genai.NewPartFromText("hello world"),
+		}, genai.RoleModel),
+	}}}
+	if got := c.AddResponse(resp); got != 4 {
+		t.Errorf("AddResponse() = %d, want 4", got)
+	}
+}
+
+func TestCounterWithoutSpaces(t *testing.T) {
+	tok := newTestLocalTokenizer(t)
+	text := strings.Repeat("日本語のテキストには空白がありません。", 100)
+	want := tok.countText(text)
+
+	c := tok.NewCounter()
+	for _, r := range text {
+		c.Add(string(r))
+	}
+	if len(c.tail) > maxCounterTail+utf8.UTFMax {
+		t.Errorf("Counter tail is %d bytes, want at most %d", len(c.tail), maxCounterTail+utf8.UTFMax)
+	}
+	// Cutting at token boundaries can change how the tokens merge, slightly.
+	if got := c.Total(); got < want*99/100 || got > want*101/100 {
+		t.Errorf("Total() = %d, want about %d", got, want)
+	}
+}
+
+func TestLocalTokenizerConcurrentUse(t *testing.T) {
+	tok := newTestLocalTokenizer(t)
+	texts := []string{"hello world", "héllo wörld", "✓ hello"}
+	want := make([]int, len(texts))
+	for i, text := range texts {
+		want[i] = tok.countText(text)
+	}
+	counter := tok.NewCounter()
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				text := texts[(g+i)%len(texts)]
+				got, err := tok.CountTokens([]*genai.Content{genai.NewContentFromText(text, genai.RoleUser)}, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if int(got.TotalTokens) != want[(g+i)%len(texts)] {
+					t.Errorf("CountTokens(%q) = %d, want %d", text, got.TotalTokens, want[(g+i)%len(texts)])
+				}
+				if truncated, _ := tok.TruncateToTokens(text, 2); !strings.HasPrefix(text, truncated) {
+					t.Errorf("TruncateToTokens(%q) = %q, not a prefix", text, truncated)
+				}
+				counter.Add("hello ")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := counter.Total(), tok.countText(strings.Repeat("hello ", 8*50)); got != want {
+		t.Errorf("Counter.Total() = %d, want %d", got, want)
+	}
+}
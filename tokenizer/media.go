@@ -0,0 +1,300 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// Token rates used to estimate non-text parts, as documented for Gemini
+// models.
+const (
+	// ImageTileTokens is the number of tokens of an image tile.
+	ImageTileTokens = 258
+	// ImageTileSize is the side in pixels of the tiles larger images are cut
+	// into. Images whose sides are both at most 384 pixels count as one tile.
+	ImageTileSize = 768
+	// VideoTokensPerSecond is the number of tokens per second of video, at the
+	// default rate of one frame per second and including the audio track.
+	VideoTokensPerSecond = 263
+	// AudioTokensPerSecond is the number of tokens per second of audio.
+	AudioTokensPerSecond = 32
+	// DocumentPageTokens is the number of tokens of a PDF page.
+	DocumentPageTokens = 258
+)
+
+const smallImageSize = 384
+
+// TokenCountDetails is the result of [LocalTokenizer.CountTokensDetailed].
+type TokenCountDetails struct {
+	// TotalTokens is the total number of tokens, including the estimates for
+	// non-text parts.
+	TotalTokens int32
+	// PromptTokensDetails breaks TotalTokens down per modality, in the order
+	// text, image, video, audio, document. Modalities without tokens are
+	// omitted.
+	PromptTokensDetails []*genai.ModalityTokenCount
+	// Unestimated describes the parts that could not be estimated, for example
+	// a video whose duration is unknown. They are not counted.
+	Unestimated []string
+}
+
+var modalityOrder = []genai.MediaModality{
+	genai.MediaModalityText,
+	genai.MediaModalityImage,
+	genai.MediaModalityVideo,
+	genai.MediaModalityAudio,
+	genai.MediaModalityDocument,
+}
+
+// CountTokensDetailed counts tokens like [LocalTokenizer.CountTokens] and
+// breaks the count down per modality.
+//
+// Text, function calls and responses, tools and the response schema are
+// tokenized. Inline data and file data parts are estimated from their MIME
+// type using the documented rates:
+//
+//   - Images count [ImageTileTokens] per [ImageTileSize] tile. The dimensions of
+//     inline PNG, JPEG and GIF images are decoded; other images count as one
+//     tile.
+//   - Videos count [VideoTokensPerSecond] and audio [AudioTokensPerSecond]. The
+//     duration is taken from the part's VideoMetadata offsets when both are
+//     set, or read from inline WAV and MP4 data.
+//   - PDFs count [DocumentPageTokens] per page, for inline data whose pages can
+//     be counted.
+//   - Inline text/* data is tokenized as text.
+//
+// Parts that cannot be estimated, such as file data whose duration is unknown,
+// are listed in Unestimated and not counted. The estimates may differ from the
+// count returned by the API.
+func (tok *LocalTokenizer) CountTokensDetailed(contents []*genai.Content, config *genai.CountTokensConfig) (*TokenCountDetails, error) {
+	counts := make(map[genai.MediaModality]int)
+	details := &TokenCountDetails{}
+
+	allContents := contents
+	if config != nil && config.SystemInstruction != nil {
+		allContents = append(append([]*genai.Content{}, contents...), config.SystemInstruction)
+	}
+	for _, text := range tok.texts(contents, config) {
+		counts[genai.MediaModalityText] += tok.countText(text)
+	}
+	for _, content := range allContents {
+		if content == nil {
+			continue
+		}
+		for _, part := range content.Parts {
+			if part == nil {
+				continue
+			}
+			modality, n, err := tok.estimatePart(part)
+			if err != nil {
+				details.Unestimated = append(details.Unestimated, err.Error())
+				continue
+			}
+			if modality != "" {
+				counts[modality] += n
+			}
+		}
+	}
+
+	total := 0
+	for _, modality := range modalityOrder {
+		if n := counts[modality]; n > 0 {
+			details.PromptTokensDetails = append(details.PromptTokensDetails, &genai.ModalityTokenCount{Modality: modality, TokenCount: int32(n)})
+			total += n
+		}
+	}
+	details.TotalTokens = int32(total)
+	return details, nil
+}
+
+func (tok *LocalTokenizer) countText(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(tok.processor.Encode(text))
+}
+
+// estimatePart returns the modality and estimated tokens of a non-text part. It
+// returns an empty modality for parts without media.
+func (tok *LocalTokenizer) estimatePart(part *genai.Part) (genai.MediaModality, int, error) {
+	var mimeType, name string
+	var data []byte
+	switch {
+	case part.InlineData != nil:
+		mimeType, data, name = part.InlineData.MIMEType, part.InlineData.Data, part.InlineData.DisplayName
+	case part.FileData != nil:
+		mimeType, name = part.FileData.MIMEType, part.FileData.FileURI
+	default:
+		return "", 0, nil
+	}
+	if name == "" {
+		name = "inline data"
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: invalid MIME type %q", name, mimeType)
+	}
+	kind, _, _ := strings.Cut(mediaType, "/")
+
+	switch {
+	case kind == "image":
+		return genai.MediaModalityImage, imageTokens(data), nil
+	case kind == "video" || kind == "audio":
+		d, ok := mediaDuration(mediaType, data, part.VideoMetadata)
+		if !ok {
+			return "", 0, fmt.Errorf("%s (%s): unknown duration", name, mediaType)
+		}
+		seconds := int(math.Ceil(d.Seconds()))
+		if kind == "video" {
+			return genai.MediaModalityVideo, seconds * VideoTokensPerSecond, nil
+		}
+		return genai.MediaModalityAudio, seconds * AudioTokensPerSecond, nil
+	case mediaType == "application/pdf":
+		pages := pdfPageCount(data)
+		if pages == 0 {
+			return "", 0, fmt.Errorf("%s (%s): unknown page count", name, mediaType)
+		}
+		return genai.MediaModalityDocument, pages * DocumentPageTokens, nil
+	case kind == "text" && part.InlineData != nil:
+		return genai.MediaModalityText, tok.countText(string(data)), nil
+	}
+	return "", 0, fmt.Errorf("%s (%s): unsupported MIME type", name, mediaType)
+}
+
+// imageTokens estimates the tokens of an image; data may be nil or in an
+// undecodable format, in which case the image counts as one tile.
+func imageTokens(data []byte) int {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (cfg.Width <= smallImageSize && cfg.Height <= smallImageSize) {
+		return ImageTileTokens
+	}
+	tiles := ceilDiv(cfg.Width, ImageTileSize) * ceilDiv(cfg.Height, ImageTileSize)
+	return tiles * ImageTileTokens
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// mediaDuration returns the duration of a video or audio part.
+func mediaDuration(mediaType string, data []byte, vm *genai.VideoMetadata) (time.Duration, bool) {
+	var start time.Duration
+	if vm != nil {
+		if vm.EndOffset > vm.StartOffset {
+			return vm.EndOffset - vm.StartOffset, true
+		}
+		start = vm.StartOffset
+	}
+	var d time.Duration
+	var ok bool
+	switch mediaType {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		d, ok = wavDuration(data)
+	case "video/mp4", "audio/mp4", "audio/m4a", "audio/x-m4a", "video/quicktime":
+		d, ok = mp4Duration(data)
+	}
+	if !ok || d <= start {
+		return 0, false
+	}
+	return d - start, true
+}
+
+// wavDuration reads the duration of a RIFF WAVE file from its fmt and data
+// chunks.
+func wavDuration(data []byte) (time.Duration, bool) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, false
+	}
+	var byteRate, dataSize uint32
+	for off := 12; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := binary.LittleEndian.Uint32(data[off+4 : off+8])
+		body := off + 8
+		switch id {
+		case "fmt ":
+			if body+12 > len(data) {
+				return 0, false
+			}
+			byteRate = binary.LittleEndian.Uint32(data[body+8 : body+12])
+		case "data":
+			dataSize = size
+		}
+		if id == "data" {
+			break
+		}
+		off = body + int(size) + int(size%2)
+	}
+	if byteRate == 0 || dataSize == 0 {
+		return 0, false
+	}
+	return time.Duration(float64(dataSize) / float64(byteRate) * float64(time.Second)), true
+}
+
+// mp4Duration reads the duration of an ISO base media file from its movie
+// header box.
+func mp4Duration(data []byte) (time.Duration, bool) {
+	i := bytes.Index(data, []byte("mvhd"))
+	if i < 0 || i+8 > len(data) {
+		return 0, false
+	}
+	box := data[i+4:]
+	var timescale uint32
+	var duration uint64
+	switch box[0] {
+	case 0:
+		if len(box) < 20 {
+			return 0, false
+		}
+		timescale = binary.BigEndian.Uint32(box[12:16])
+		duration = uint64(binary.BigEndian.Uint32(box[16:20]))
+	case 1:
+		if len(box) < 32 {
+			return 0, false
+		}
+		timescale = binary.BigEndian.Uint32(box[20:24])
+		duration = binary.BigEndian.Uint64(box[24:32])
+	default:
+		return 0, false
+	}
+	if timescale == 0 || duration == 0 {
+		return 0, false
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), true
+}
+
+var pdfPage = regexp.MustCompile(`/Type\s*/Page\b`)
+
+// pdfPageCount counts the page objects of a PDF. It returns 0 when the pages
+// cannot be found, for example in compressed object streams.
+func pdfPageCount(data []byte) int {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return 0
+	}
+	return len(pdfPage.FindAll(data, -1))
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// wavBytes returns a mono 16-bit 8 kHz WAV file lasting d.
+func wavBytes(d time.Duration) []byte {
+	const byteRate = 16000
+	dataSize := uint32(d.Seconds() * byteRate)
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+dataSize))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(8000), uint32(byteRate), uint16(2), uint16(16)} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, dataSize)
+	b.Write(make([]byte, dataSize))
+	return b.Bytes()
+}
+
+// mp4Bytes returns the start of an MP4 file with a version 0 movie header
+// lasting d.
+func mp4Bytes(d time.Duration) []byte {
+	const timescale = 1000
+	var b bytes.Buffer
+	b.Write([]byte{0, 0, 0, 24})
+	b.WriteString("ftypisom")
+	b.Write(make([]byte, 12))
+	b.Write([]byte{0, 0, 0, 108})
+	b.WriteString("moov")
+	b.Write([]byte{0, 0, 0, 100})
+	b.WriteString("mvhd")
+	binary.Write(&b, binary.BigEndian, []uint32{0, 0, 0, timescale, uint32(d.Milliseconds())})
+	b.Write(make([]byte, 80))
+	return b.Bytes()
+}
+
+func TestCountTokensDetailedMedia(t *testing.T) {
+	// Without text the tokenizer model is not used.
+	tok := &LocalTokenizer{}
+	pdf := []byte("%PDF-1.4\n1 0 obj << /Type /Pages /Kids [2 0 R 3 0 R] >>\n2 0 obj << /Type /Page >>\n3 0 obj << /Type/Page >>\n")
+
+	tests := []struct {
+		name  string
+		parts []*genai.Part
+		want  *TokenCountDetails
+	}{
+		{
+			name:  "SmallImage",
+			parts: []*genai.Part{genai.NewPartFromBytes(pngBytes(t, 300, 200), "image/png")},
+			want: &TokenCountDetails{
+				TotalTokens:         258,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityImage, TokenCount: 258}},
+			},
+		},
+		{
+			name:  "LargeImageTiles",
+			parts: []*genai.Part{genai.NewPartFromBytes(pngBytes(t, 1000, 800), "image/png")},
+			want: &TokenCountDetails{
+				TotalTokens:         4 * 258,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityImage, TokenCount: 4 * 258}},
+			},
+		},
+		{
+			name:  "ImageFileData",
+			parts: []*genai.Part{genai.NewPartFromURI("gs://bucket/a.webp", "image/webp")},
+			want: &TokenCountDetails{
+				TotalTokens:         258,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityImage, TokenCount: 258}},
+			},
+		},
+		{
+			name:  "WAVAudio",
+			parts: []*genai.Part{genai.NewPartFromBytes(wavBytes(2500*time.Millisecond), "audio/wav")},
+			want: &TokenCountDetails{
+				TotalTokens:         3 * 32,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityAudio, TokenCount: 3 * 32}},
+			},
+		},
+		{
+			name: "MP4VideoWithStartOffset",
+			parts: []*genai.Part{{
+				InlineData:    &genai.Blob{Data: mp4Bytes(10 * time.Second), MIMEType: "video/mp4"},
+				VideoMetadata: &genai.VideoMetadata{StartOffset: 4 * time.Second},
+			}},
+			want: &TokenCountDetails{
+				TotalTokens:         6 * 263,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityVideo, TokenCount: 6 * 263}},
+			},
+		},
+		{
+			name: "VideoFileDataWithOffsets",
+			parts: []*genai.Part{{
+				FileData:      &genai.FileData{FileURI: "gs://bucket/v.mp4", MIMEType: "video/mp4"},
+				VideoMetadata: &genai.VideoMetadata{StartOffset: time.Second, EndOffset: 3 * time.Second},
+			}},
+			want: &TokenCountDetails{
+				TotalTokens:         2 * 263,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityVideo, TokenCount: 2 * 263}},
+			},
+		},
+		{
+			name:  "PDF",
+			parts: []*genai.Part{genai.NewPartFromBytes(pdf, "application/pdf")},
+			want: &TokenCountDetails{
+				TotalTokens:         2 * 258,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityDocument, TokenCount: 2 * 258}},
+			},
+		},
+		{
+			name: "Unestimated",
+			parts: []*genai.Part{
+				genai.NewPartFromURI("gs://bucket/v.mp4", "video/mp4"),
+				genai.NewPartFromURI("gs://bucket/d.pdf", "application/pdf"),
+				genai.NewPartFromBytes([]byte{1}, "application/zip"),
+				genai.NewPartFromBytes(pngBytes(t, 10, 10), "image/png"),
+			},
+			want: &TokenCountDetails{
+				TotalTokens:         258,
+				PromptTokensDetails: []*genai.ModalityTokenCount{{Modality: genai.MediaModalityImage, TokenCount: 258}},
+				Unestimated: []string{
+					"gs://bucket/v.mp4 (video/mp4): unknown duration",
+					"gs://bucket/d.pdf (application/pdf): unknown page count",
+					"inline data (application/zip): unsupported MIME type",
+				},
+			},
+		},
+		{
+			name: "ModalityOrder",
+			parts: []*genai.Part{
+				genai.NewPartFromBytes(wavBytes(time.Second), "audio/wav"),
+				genai.NewPartFromBytes(pngBytes(t, 10, 10), "image/png"),
+			},
+			want: &TokenCountDetails{
+				TotalTokens: 258 + 32,
+				PromptTokensDetails: []*genai.ModalityTokenCount{
+					{Modality: genai.MediaModalityImage, TokenCount: 258},
+					{Modality: genai.MediaModalityAudio, TokenCount: 32},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tok.CountTokensDetailed([]*genai.Content{genai.NewContentFromParts(tt.parts, genai.RoleUser)}, nil)
+			if err != nil {
+				t.Fatalf("CountTokensDetailed() failed unexpectedly: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("CountTokensDetailed() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCountTokensDetailedSystemInstructionMedia(t *testing.T) {
+	tok := &LocalTokenizer{}
+	config := &genai.CountTokensConfig{
+		SystemInstruction: genai.NewContentFromParts([]*genai.Part{genai.NewPartFromBytes(pngBytes(t, 10, 10), "image/png")}, genai.RoleUser),
+	}
+	got, err := tok.CountTokens(nil, config)
+	if err != nil {
+		t.Fatalf("CountTokens() failed unexpectedly: %v", err)
+	}
+	if got.TotalTokens != 258 {
+		t.Errorf("CountTokens() = %d, want 258", got.TotalTokens)
+	}
+}
+
+func TestMediaDurationParsers(t *testing.T) {
+	if d, ok := wavDuration(wavBytes(1500 * time.Millisecond)); !ok || d != 1500*time.Millisecond {
+		t.Errorf("wavDuration() = %v, %v, want 1.5s, true", d, ok)
+	}
+	if _, ok := wavDuration([]byte("RIFF....WAVE")); ok {
+		t.Errorf("wavDuration() of truncated file ok, want not ok")
+	}
+	if d, ok := mp4Duration(mp4Bytes(42 * time.Second)); !ok || d != 42*time.Second {
+		t.Errorf("mp4Duration() = %v, %v, want 42s, true", d, ok)
+	}
+	if _, ok := mp4Duration([]byte("not an mp4")); ok {
+		t.Errorf("mp4Duration() of invalid file ok, want not ok")
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheDirEnv is the environment variable overriding [DefaultCacheDir].
+const cacheDirEnv = "GOOGLE_GENAI_TOKENIZER_CACHE_DIR"
+
+// defaultDownloadTimeout bounds the download of a tokenizer model.
+const defaultDownloadTimeout = 5 * time.Minute
+
+// DefaultCacheDir returns the directory tokenizer models are cached in when
+// [WithCacheDir] is not used: the value of the GOOGLE_GENAI_TOKENIZER_CACHE_DIR
+// environment variable if set, or vertexai_tokenizer_model in [os.TempDir].
+func DefaultCacheDir() string {
+	if dir := os.Getenv(cacheDirEnv); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "vertexai_tokenizer_model")
+}
+
+// An Option configures how the tokenizer model is loaded.
+type Option func(*options)
+
+type options struct {
+	source     func() (io.ReadCloser, error)
+	cacheDir   string
+	httpClient *http.Client
+	timeout    time.Duration
+	noDownload bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		cacheDir:   DefaultCacheDir(),
+		httpClient: http.DefaultClient,
+		timeout:    defaultDownloadTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithModelFile loads the tokenizer model from the file at path instead of the
+// cache or the network.
+func WithModelFile(path string) Option {
+	return func(o *options) {
+		o.source = func() (io.ReadCloser, error) { return os.Open(path) }
+	}
+}
+
+// WithModelFS loads the tokenizer model from the file name of fsys, such as an
+// [embed.FS], instead of the cache or the network.
+func WithModelFS(fsys fs.FS, name string) Option {
+	return func(o *options) {
+		o.source = func() (io.ReadCloser, error) { return fsys.Open(name) }
+	}
+}
+
+// WithModelReader loads the tokenizer model from r instead of the cache or the
+// network.
+func WithModelReader(r io.Reader) Option {
+	return func(o *options) {
+		o.source = func() (io.ReadCloser, error) { return io.NopCloser(r), nil }
+	}
+}
+
+// WithCacheDir caches downloaded tokenizer models in dir instead of
+// [DefaultCacheDir].
+func WithCacheDir(dir string) Option {
+	return func(o *options) {
+		o.cacheDir = dir
+	}
+}
+
+// WithHTTPClient downloads tokenizer models with client instead of
+// [http.DefaultClient].
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithDownloadTimeout bounds the download of a tokenizer model. The default is
+// 5 minutes; zero or a negative value disables the timeout.
+func WithDownloadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithoutDownload makes loading fail if the tokenizer model is not in the
+// cache, instead of downloading it.
+func WithoutDownload() Option {
+	return func(o *options) {
+		o.noDownload = true
+	}
+}
+
+// ErrModelNotCached is returned when the tokenizer model is not in the cache
+// and [WithoutDownload] is used.
+var ErrModelNotCached = errors.New("tokenizer model is not cached and downloads are disabled")
+
+// CacheModel makes sure the tokenizer model of modelName is in the cache,
+// downloading it if needed, and returns the path of the cached file. It is
+// meant to pre-seed the cache, for example when building a container image
+// that later runs without network access. Model source options such as
+// [WithModelFile] copy the model into the cache instead of downloading it.
+func CacheModel(ctx context.Context, modelName string, opts ...Option) (string, error) {
+	tokenizerName, err := getLocalTokenizerName(modelName)
+	if err != nil {
+		return "", err
+	}
+	config, ok := tokenizers[tokenizerName]
+	if !ok {
+		return "", fmt.Errorf("model %s is not supported", modelName)
+	}
+	o := newOptions(opts)
+	data, err := o.loadModel(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	path := o.cachePath(config)
+	if o.source != nil {
+		if err := writeCacheFile(path, data); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// cachePath returns the path of the cache file of the model: a file named after
+// the hash of the model URL in the cache directory.
+func (o *options) cachePath(config tokenizerConfig) string {
+	return filepath.Join(o.cacheDir, hashString([]byte(config.modelURL)))
+}
+
+// loadModel loads the model data of config and checks its hash.
+//
+// Without a model source option, the cache file is used if its data has the
+// expected hash. Otherwise the model is downloaded and written to the cache.
+func (o *options) loadModel(ctx context.Context, config tokenizerConfig) ([]byte, error) {
+	if o.source != nil {
+		r, err := o.source()
+		if err != nil {
+			return nil, fmt.Errorf("opening model: %w", err)
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading model: %w", err)
+		}
+		if hashString(data) != config.modelHash {
+			return nil, fmt.Errorf("model hash mismatch")
+		}
+		return data, nil
+	}
+
+	cachePath := o.cachePath(config)
+	cacheData, err := os.ReadFile(cachePath)
+	if err == nil && hashString(cacheData) == config.modelHash {
+		return cacheData, nil
+	}
+	if o.noDownload {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotCached, cachePath)
+	}
+
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	data, err := downloadModelFile(ctx, o.httpClient, config.modelURL)
+	if err != nil {
+		return nil, fmt.Errorf("loading cache and downloading model: %w", err)
+	}
+	if hashString(data) != config.modelHash {
+		return nil, fmt.Errorf("downloaded model hash mismatch")
+	}
+	if err := writeCacheFile(cachePath, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// downloadModelFile downloads a file from the given URL.
+func downloadModelFile(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// writeCacheFile writes data to path through a temporary file, so concurrent
+// readers never see a partial model.
+func writeCacheFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0770); err != nil {
+		return fmt.Errorf("creating cache dir: %w", err)
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0660)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	return nil
+}
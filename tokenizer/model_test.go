@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package tokenizer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// newFakeModelServer serves model at /model and counts the downloads.
+func newFakeModelServer(t *testing.T, model []byte) (*httptest.Server, tokenizerConfig, *atomic.Int32) {
+	t.Helper()
+	var downloads atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/model" {
+			http.NotFound(w, r)
+			return
+		}
+		downloads.Add(1)
+		w.Write(model)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, tokenizerConfig{modelURL: ts.URL + "/model", modelHash: hashString(model)}, &downloads
+}
+
+func TestLoadModelSources(t *testing.T) {
+	ctx := context.Background()
+	model := []byte("fake sentencepiece model")
+	config := tokenizerConfig{modelURL: "http://invalid.test/model", modelHash: hashString(model)}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokenizer.model")
+	if err := os.WriteFile(path, model, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantErrMsg string
+	}{
+		{name: "File", opts: []Option{WithModelFile(path)}},
+		{name: "FS", opts: []Option{WithModelFS(fstest.MapFS{"m/tok.model": {Data: model}}, "m/tok.model")}},
+		{name: "Reader", opts: []Option{WithModelReader(bytes.NewReader(model))}},
+		{name: "MissingFile", opts: []Option{WithModelFile(filepath.Join(dir, "missing"))}, wantErrMsg: "opening model"},
+		{name: "HashMismatch", opts: []Option{WithModelReader(strings.NewReader("other model"))}, wantErrMsg: "model hash mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The cache directory is empty and downloads fail, so the model
+			// can only come from the source option.
+			opts := append([]Option{WithCacheDir(t.TempDir()), WithoutDownload()}, tt.opts...)
+			got, err := newOptions(opts).loadModel(ctx, config)
+			if tt.wantErrMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("loadModel() error = %v, want error containing %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadModel() failed unexpectedly: %v", err)
+			}
+			if !bytes.Equal(got, model) {
+				t.Errorf("loadModel() = %q, want %q", got, model)
+			}
+		})
+	}
+}
+
+func TestLoadModelCache(t *testing.T) {
+	ctx := context.Background()
+	model := []byte("fake sentencepiece model")
+	ts, config, downloads := newFakeModelServer(t, model)
+	cacheDir := t.TempDir()
+	opts := []Option{WithCacheDir(cacheDir), WithHTTPClient(ts.Client())}
+
+	// Offline with an empty cache.
+	_, err := newOptions(append(opts, WithoutDownload())).loadModel(ctx, config)
+	if !errors.Is(err, ErrModelNotCached) {
+		t.Errorf("loadModel() error = %v, want %v", err, ErrModelNotCached)
+	}
+
+	for i := range 2 {
+		got, err := newOptions(opts).loadModel(ctx, config)
+		if err != nil {
+			t.Fatalf("loadModel() #%d failed unexpectedly: %v", i, err)
+		}
+		if !bytes.Equal(got, model) {
+			t.Errorf("loadModel() #%d = %q, want %q", i, got, model)
+		}
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("got %d downloads, want 1", n)
+	}
+
+	// The cached model is found offline.
+	if _, err := newOptions(append(opts, WithoutDownload())).loadModel(ctx, config); err != nil {
+		t.Errorf("loadModel() offline failed unexpectedly: %v", err)
+	}
+
+	// A corrupted cache file is downloaded again.
+	if err := os.WriteFile(newOptions(opts).cachePath(config), []byte{0, 1, 2}, 0o660); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newOptions(opts).loadModel(ctx, config); err != nil {
+		t.Errorf("loadModel() after corruption failed unexpectedly: %v", err)
+	}
+	if n := downloads.Load(); n != 2 {
+		t.Errorf("got %d downloads, want 2", n)
+	}
+}
+
+func TestLoadModelDownloadErrors(t *testing.T) {
+	ctx := context.Background()
+	model := []byte("fake sentencepiece model")
+	ts, config, _ := newFakeModelServer(t, model)
+
+	t.Run("HashMismatch", func(t *testing.T) {
+		bad := tokenizerConfig{modelURL: config.modelURL, modelHash: hashString([]byte("other"))}
+		_, err := newOptions([]Option{WithCacheDir(t.TempDir()), WithHTTPClient(ts.Client())}).loadModel(ctx, bad)
+		if err == nil || !strings.Contains(err.Error(), "downloaded model hash mismatch") {
+			t.Errorf("loadModel() error = %v, want hash mismatch", err)
+		}
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		missing := tokenizerConfig{modelURL: ts.URL + "/missing", modelHash: config.modelHash}
+		_, err := newOptions([]Option{WithCacheDir(t.TempDir()), WithHTTPClient(ts.Client())}).loadModel(ctx, missing)
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("loadModel() error = %v, want 404 error", err)
+		}
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		block := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-block:
+			case <-r.Context().Done():
+			}
+		}))
+		defer slow.Close()
+		defer close(block)
+		c := tokenizerConfig{modelURL: slow.URL, modelHash: config.modelHash}
+		opts := []Option{WithCacheDir(t.TempDir()), WithHTTPClient(slow.Client()), WithDownloadTimeout(10 * time.Millisecond)}
+		_, err := newOptions(opts).loadModel(ctx, c)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("loadModel() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+func TestDefaultCacheDir(t *testing.T) {
+	t.Setenv(cacheDirEnv, "")
+	if got, want := DefaultCacheDir(), filepath.Join(os.TempDir(), "vertexai_tokenizer_model"); got != want {
+		t.Errorf("DefaultCacheDir() = %q, want %q", got, want)
+	}
+	dir := t.TempDir()
+	t.Setenv(cacheDirEnv, dir)
+	if got := DefaultCacheDir(); got != dir {
+		t.Errorf("DefaultCacheDir() = %q, want %q", got, dir)
+	}
+}
+
+func TestCacheModelFromFile(t *testing.T) {
+	// The real gemma2 model cannot be fetched offline, so only check that an
+	// invalid model file is rejected before anything is cached.
+	cacheDir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "tokenizer.model")
+	if err := os.WriteFile(path, []byte("not the model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := CacheModel(context.Background(), "gemini-1.5-flash", WithCacheDir(cacheDir), WithModelFile(path))
+	if err == nil || !strings.Contains(err.Error(), "model hash mismatch") {
+		t.Errorf("CacheModel() error = %v, want hash mismatch", err)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Errorf("cache dir has %d entries, want 0", len(entries))
+	}
+
+	if _, err := CacheModel(context.Background(), "gemini-0.92"); err == nil {
+		t.Errorf("CacheModel() with unsupported model got no error, want error")
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenizer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// Names of the tokenizers models can be mapped to with [RegisterModel].
+const (
+	// TokenizerGemma2 is the tokenizer of Gemini 1.0 and 1.5 models.
+	TokenizerGemma2 = "gemma2"
+	// TokenizerGemma3 is the tokenizer of Gemini 2.0 and later models.
+	TokenizerGemma3 = "gemma3"
+)
+
+var (
+	registeredModelsMu sync.RWMutex
+	// registeredModels maps model names registered at runtime to their
+	// tokenizer names. They take precedence over the built-in mappings.
+	registeredModels = make(map[string]string)
+)
+
+// RegisterModel maps modelName to the tokenizer tokenizerName, one of
+// [TokenizerGemma2] and [TokenizerGemma3], so that [NewLocalTokenizer] accepts
+// models the SDK doesn't know about yet, such as tuned models. Registered
+// mappings take precedence over the built-in ones. It is safe to call
+// RegisterModel concurrently with the other functions of the package.
+func RegisterModel(modelName, tokenizerName string) error {
+	if _, ok := tokenizers[tokenizerName]; !ok {
+		return fmt.Errorf("unknown tokenizer %q", tokenizerName)
+	}
+	if modelName == "" {
+		return fmt.Errorf("model name is required")
+	}
+	registeredModelsMu.Lock()
+	defer registeredModelsMu.Unlock()
+	registeredModels[modelName] = tokenizerName
+	return nil
+}
+
+// RegisterModelInfo registers the model returned by [genai.Models.Get]. A tuned
+// model is mapped to the tokenizer of its base model; other models are mapped
+// by their name or version. It returns the tokenizer name the model was mapped
+// to.
+func RegisterModelInfo(model *genai.Model) (string, error) {
+	if model == nil || model.Name == "" {
+		return "", fmt.Errorf("model name is required")
+	}
+	var candidates []string
+	if model.TunedModelInfo != nil && model.TunedModelInfo.BaseModel != "" {
+		candidates = append(candidates, model.TunedModelInfo.BaseModel)
+	}
+	candidates = append(candidates, model.Name, model.Version)
+	for _, name := range candidates {
+		if name == "" {
+			continue
+		}
+		if tokenizerName, err := ResolveTokenizer(name); err == nil {
+			return tokenizerName, RegisterModel(model.Name, tokenizerName)
+		}
+	}
+	return "", fmt.Errorf("cannot determine the tokenizer of model %s", model.Name)
+}
+
+// ModelGetter gets the metadata of a model. [genai.Models] implements it.
+type ModelGetter interface {
+	Get(ctx context.Context, model string, config *genai.GetModelConfig) (*genai.Model, error)
+}
+
+// NewLocalTokenizerForModel is like [NewLocalTokenizerContext], but if the
+// tokenizer of modelName cannot be resolved from its name, it gets the model
+// with models and registers it with [RegisterModelInfo] first. This supports
+// tuned models and new versions of the models the SDK knows about.
+func NewLocalTokenizerForModel(ctx context.Context, models ModelGetter, modelName string, opts ...Option) (*LocalTokenizer, error) {
+	if _, err := ResolveTokenizer(modelName); err != nil {
+		model, err := models.Get(ctx, modelName, nil)
+		if err != nil {
+			return nil, fmt.Errorf("getting model %s: %w", modelName, err)
+		}
+		tokenizerName, err := RegisterModelInfo(model)
+		if err != nil {
+			return nil, err
+		}
+		// The API returns canonical names, such as "tunedModels/x" for "x".
+		if err := RegisterModel(modelName, tokenizerName); err != nil {
+			return nil, err
+		}
+	}
+	return NewLocalTokenizerContext(ctx, modelName, opts...)
+}
+
+// modelFamily matches the family and version of model names such as
+// "gemini-2.5-flash-preview-05-20", "gemini-live-2.5-flash" or "gemma-3-27b-it".
+var modelFamily = regexp.MustCompile(`^(gemini|gemma)-(?:live-)?(\d+)(?:\.\d+)?(?:-|$)`)
+
+// latestAlias matches the aliases of the latest models, such as
+// "gemini-flash-latest".
+var latestAlias = regexp.MustCompile(`^gemini-[a-z-]+-latest$`)
+
+// ResolveTokenizer returns the name of the tokenizer of modelName.
+//
+// Models registered with [RegisterModel] are looked up first, then the models
+// known to the SDK. Other names are resolved by their model family and
+// version, ignoring resource prefixes such as "models/" or
+// "publishers/google/models/" and suffixes such as "-001", "-preview-06-05"
+// or "-exp-03-25": Gemini 1.x models use [TokenizerGemma2], later Gemini
+// models use [TokenizerGemma3], and Gemma 2 and Gemma 3 models use the
+// tokenizer of their generation.
+func ResolveTokenizer(modelName string) (string, error) {
+	registeredModelsMu.RLock()
+	tokenizerName, ok := registeredModels[modelName]
+	if !ok {
+		tokenizerName, ok = registeredModels[baseModelName(modelName)]
+	}
+	registeredModelsMu.RUnlock()
+	if ok {
+		return tokenizerName, nil
+	}
+
+	name := baseModelName(modelName)
+	if tokenizerName, ok := geminiModelsToLocalTokenizerNames[name]; ok {
+		return tokenizerName, nil
+	}
+	if tokenizerName, ok := geminiStableModelsToLocalTokenizerNames[name]; ok {
+		return tokenizerName, nil
+	}
+	if latestAlias.MatchString(name) {
+		return TokenizerGemma3, nil
+	}
+	if m := modelFamily.FindStringSubmatch(name); m != nil {
+		major, _ := strconv.Atoi(m[2])
+		switch {
+		case m[1] == "gemini" && major == 1:
+			return TokenizerGemma2, nil
+		case m[1] == "gemini" && major >= 2:
+			return TokenizerGemma3, nil
+		case m[1] == "gemma" && major == 2:
+			return TokenizerGemma2, nil
+		case m[1] == "gemma" && major == 3:
+			return TokenizerGemma3, nil
+		}
+	}
+	return "", fmt.Errorf("model %s is not supported; map it to a tokenizer with RegisterModel", modelName)
+}
+
+// baseModelName strips the resource path and version tag of a model name, so
+// that "publishers/google/models/gemini-2.0-flash@001" becomes
+// "gemini-2.0-flash".
+func baseModelName(modelName string) string {
+	name := modelName
+	if i := strings.LastIndex(name, "/models/"); i >= 0 {
+		name = name[i+len("/models/"):]
+	}
+	name = strings.TrimPrefix(name, "models/")
+	name, _, _ = strings.Cut(name, "@")
+	return strings.ToLower(name)
+}
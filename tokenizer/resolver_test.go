@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package tokenizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+// unregisterModels removes the models registered by a test.
+func unregisterModels(t *testing.T, names ...string) {
+	t.Cleanup(func() {
+		registeredModelsMu.Lock()
+		defer registeredModelsMu.Unlock()
+		for _, name := range names {
+			delete(registeredModels, name)
+		}
+	})
+}
+
+func TestResolveTokenizer(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gemini-1.5-flash", TokenizerGemma2},
+		{"gemini-2.0-flash-001", TokenizerGemma3},
+		{"models/gemini-2.5-pro", TokenizerGemma3},
+		{"publishers/google/models/gemini-1.5-pro-002", TokenizerGemma2},
+		{"projects/p/locations/us-central1/publishers/google/models/gemini-2.0-flash@001", TokenizerGemma3},
+		{"gemini-1.0-pro-vision-001", TokenizerGemma2},
+		{"gemini-2.5-flash-preview-09-2025", TokenizerGemma3},
+		{"gemini-2.0-flash-exp", TokenizerGemma3},
+		{"gemini-2.0-flash-thinking-exp-01-21", TokenizerGemma3},
+		{"gemini-3-flash", TokenizerGemma3},
+		{"gemini-3.5-pro-preview", TokenizerGemma3},
+		{"gemini-live-2.5-flash-preview", TokenizerGemma3},
+		{"Gemini-2.5-Flash", TokenizerGemma3},
+		{"gemini-flash-latest", TokenizerGemma3},
+		{"gemma-2-9b-it", TokenizerGemma2},
+		{"gemma-3-27b-it", TokenizerGemma3},
+	}
+	for _, tt := range tests {
+		got, err := ResolveTokenizer(tt.model)
+		if err != nil {
+			t.Errorf("ResolveTokenizer(%q) failed unexpectedly: %v", tt.model, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveTokenizer(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+
+	for _, model := range []string{"gemini-0.92", "gemini-pro", "gemini-2x-flash", "gemma-7b", "tunedModels/my-model", "text-embedding-004", ""} {
+		if got, err := ResolveTokenizer(model); err == nil {
+			t.Errorf("ResolveTokenizer(%q) = %q, want error", model, got)
+		}
+	}
+}
+
+func TestRegisterModel(t *testing.T) {
+	unregisterModels(t, "my-model", "gemini-2.0-flash")
+
+	if _, err := ResolveTokenizer("my-model"); err == nil {
+		t.Fatalf("ResolveTokenizer() of unregistered model got no error, want error")
+	}
+	if err := RegisterModel("my-model", TokenizerGemma2); err != nil {
+		t.Fatalf("RegisterModel() failed unexpectedly: %v", err)
+	}
+	for _, model := range []string{"my-model", "models/my-model"} {
+		if got, err := ResolveTokenizer(model); err != nil || got != TokenizerGemma2 {
+			t.Errorf("ResolveTokenizer(%q) = %q, %v, want %q", model, got, err, TokenizerGemma2)
+		}
+	}
+
+	// Registered mappings take precedence over the built-in ones.
+	if err := RegisterModel("gemini-2.0-flash", TokenizerGemma2); err != nil {
+		t.Fatalf("RegisterModel() failed unexpectedly: %v", err)
+	}
+	if got, _ := ResolveTokenizer("gemini-2.0-flash"); got != TokenizerGemma2 {
+		t.Errorf("ResolveTokenizer() = %q, want %q", got, TokenizerGemma2)
+	}
+
+	if err := RegisterModel("other-model", "gemma9"); err == nil {
+		t.Errorf("RegisterModel() with unknown tokenizer got no error, want error")
+	}
+	if err := RegisterModel("", TokenizerGemma3); err == nil {
+		t.Errorf("RegisterModel() without model name got no error, want error")
+	}
+}
+
+func TestRegisterModelInfo(t *testing.T) {
+	unregisterModels(t, "tunedModels/support-bot", "models/experimental-model")
+
+	got, err := RegisterModelInfo(&genai.Model{
+		Name:           "tunedModels/support-bot",
+		TunedModelInfo: &genai.TunedModelInfo{BaseModel: "models/gemini-1.5-flash-001-tuning"},
+	})
+	if err != nil || got != TokenizerGemma2 {
+		t.Errorf("RegisterModelInfo() of tuned model = %q, %v, want %q", got, err, TokenizerGemma2)
+	}
+	if got, _ := ResolveTokenizer("tunedModels/support-bot"); got != TokenizerGemma2 {
+		t.Errorf("ResolveTokenizer() of registered tuned model = %q, want %q", got, TokenizerGemma2)
+	}
+
+	got, err = RegisterModelInfo(&genai.Model{Name: "models/experimental-model", Version: "gemini-3.0-ultra"})
+	if err != nil || got != TokenizerGemma3 {
+		t.Errorf("RegisterModelInfo() by version = %q, %v, want %q", got, err, TokenizerGemma3)
+	}
+
+	if _, err := RegisterModelInfo(&genai.Model{Name: "models/unknown", Version: "001"}); err == nil {
+		t.Errorf("RegisterModelInfo() of unknown model got no error, want error")
+	}
+	if _, err := RegisterModelInfo(nil); err == nil {
+		t.Errorf("RegisterModelInfo(nil) got no error, want error")
+	}
+}
+
+type fakeModelGetter struct {
+	models map[string]*genai.Model
+	gets   int
+}
+
+func (f *fakeModelGetter) Get(ctx context.Context, model string, config *genai.GetModelConfig) (*genai.Model, error) {
+	f.gets++
+	if m, ok := f.models[model]; ok {
+		return m, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestNewLocalTokenizerForModel(t *testing.T) {
+	unregisterModels(t, "support-bot", "tunedModels/support-bot")
+	getter := &fakeModelGetter{models: map[string]*genai.Model{
+		"support-bot": {
+			Name:           "tunedModels/support-bot",
+			TunedModelInfo: &genai.TunedModelInfo{BaseModel: "models/gemini-2.0-flash-001"},
+		},
+	}}
+	// The model is not cached, so loading fails after the tokenizer has been
+	// resolved.
+	opts := []Option{WithCacheDir(t.TempDir()), WithoutDownload()}
+
+	_, err := NewLocalTokenizerForModel(context.Background(), getter, "support-bot", opts...)
+	if !errors.Is(err, ErrModelNotCached) {
+		t.Errorf("NewLocalTokenizerForModel() error = %v, want %v", err, ErrModelNotCached)
+	}
+	if got, _ := ResolveTokenizer("support-bot"); got != TokenizerGemma3 {
+		t.Errorf("ResolveTokenizer() after NewLocalTokenizerForModel() = %q, want %q", got, TokenizerGemma3)
+	}
+
+	// Models that resolve by name are not looked up.
+	getter.gets = 0
+	NewLocalTokenizerForModel(context.Background(), getter, "gemini-2.5-flash", opts...)
+	if getter.gets != 0 {
+		t.Errorf("NewLocalTokenizerForModel() of known model called Get %d times, want 0", getter.gets)
+	}
+
+	if _, err := NewLocalTokenizerForModel(context.Background(), getter, "missing-model", opts...); err == nil {
+		t.Errorf("NewLocalTokenizerForModel() of missing model got no error, want error")
+	}
+}
@@ -15,17 +15,32 @@
 // Package tokenizer provides local token counting for Gemini models. This
 // tokenizer downloads its model from the web, but otherwise doesn't require
 // an API call for every [CountTokens] invocation.
+//
+// # Supported models
+//
+// The tokenizer of a model is resolved from its name by [ResolveTokenizer],
+// which recognizes new versions, preview and experimental releases of the
+// Gemini and Gemma families. Other models, such as tuned models, can be mapped
+// with [RegisterModel], or from their metadata with [RegisterModelInfo] and
+// [NewLocalTokenizerForModel].
+//
+// # Offline use
+//
+// Downloaded models are cached in [DefaultCacheDir], which can be moved with
+// the GOOGLE_GENAI_TOKENIZER_CACHE_DIR environment variable or [WithCacheDir].
+// To use the tokenizer without network access, for example in a container
+// image or a sandboxed CI, either pre-seed the cache when the image is built by
+// running a program that calls [CacheModel] for each model, or ship the model
+// file and load it with [WithModelFile], [WithModelFS] or [WithModelReader].
+// [WithoutDownload] turns a missing model into an error instead of a download.
 package tokenizer
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
 	"sync"
 
 	sentencepiece "github.com/eliben/go-sentencepiece"
@@ -84,26 +99,12 @@ var tokenizers = map[string]tokenizerConfig{
 
 // getLocalTokenizerName returns the tokenizer name for the given model name
 func getLocalTokenizerName(modelName string) (string, error) {
-	if tokenizerName, ok := geminiModelsToLocalTokenizerNames[modelName]; ok {
-		return tokenizerName, nil
-	}
-	if tokenizerName, ok := geminiStableModelsToLocalTokenizerNames[modelName]; ok {
-		return tokenizerName, nil
-	}
-
-	// Build list of supported models for error message
-	var supportedModels []string
-	for model := range geminiModelsToLocalTokenizerNames {
-		supportedModels = append(supportedModels, model)
-	}
-	for model := range geminiStableModelsToLocalTokenizerNames {
-		supportedModels = append(supportedModels, model)
-	}
-
-	return "", fmt.Errorf("model %s is not supported. Supported models: %v", modelName, supportedModels)
+	return ResolveTokenizer(modelName)
 }
 
 // LocalTokenizer is a local tokenizer for text.
+//
+// A LocalTokenizer is safe for concurrent use by multiple goroutines.
 type LocalTokenizer struct {
 	processor *sentencepiece.Processor
 }
@@ -111,15 +112,23 @@ type LocalTokenizer struct {
 var experimentalWarningLocalTokenizer sync.Once
 
 // NewLocalTokenizer creates a new [LocalTokenizer] from a model name; the model name is the same
-// as you would pass to a [genai.Client.GenerativeModel].
-func NewLocalTokenizer(modelName string) (*LocalTokenizer, error) {
+// as you would pass to a [genai.Client.GenerativeModel]. By default the
+// tokenizer model is loaded from the cache, or downloaded if it is not cached;
+// opts select other sources.
+func NewLocalTokenizer(modelName string, opts ...Option) (*LocalTokenizer, error) {
+	return NewLocalTokenizerContext(context.Background(), modelName, opts...)
+}
+
+// NewLocalTokenizerContext is like [NewLocalTokenizer], but ctx bounds the
+// download of the tokenizer model.
+func NewLocalTokenizerContext(ctx context.Context, modelName string, opts ...Option) (*LocalTokenizer, error) {
 	experimentalWarningLocalTokenizer.Do(func() {
-		fmt.Println("Warning: The SDK's local tokenizer implementation is experimental and may change in the future. It only supports text based tokenization.")
+		fmt.Println("Warning: The SDK's local tokenizer implementation is experimental and may change in the future. Non-text parts are estimated from documented token rates.")
 	})
 
 	tokenizerName, err := getLocalTokenizerName(modelName)
 	if err != nil {
-		return nil, fmt.Errorf("model %s is not supported", modelName)
+		return nil, err
 	}
 
 	config, ok := tokenizers[tokenizerName]
@@ -127,7 +136,7 @@ func NewLocalTokenizer(modelName string) (*LocalTokenizer, error) {
 		return nil, fmt.Errorf("model %s is not supported", modelName)
 	}
 
-	data, err := loadModelData(config.modelURL, config.modelHash)
+	data, err := newOptions(opts).loadModel(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("loading model: %w", err)
 	}
@@ -141,8 +150,19 @@ func NewLocalTokenizer(modelName string) (*LocalTokenizer, error) {
 }
 
 // CountTokens counts tokens in the given contents with optional configuration,
-// similar to the Python LocalLocalTokenizer.count_tokens method.
+// similar to the Python LocalLocalTokenizer.count_tokens method. Non-text parts
+// such as images, video, audio and PDFs are estimated as described in
+// [LocalTokenizer.CountTokensDetailed].
 func (tok *LocalTokenizer) CountTokens(contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResult, error) {
+	details, err := tok.CountTokensDetailed(contents, config)
+	if err != nil {
+		return nil, err
+	}
+	return &genai.CountTokensResult{TotalTokens: details.TotalTokens}, nil
+}
+
+// texts returns the texts of contents and config that are tokenized.
+func (tok *LocalTokenizer) texts(contents []*genai.Content, config *genai.CountTokensConfig) []string {
 	textAccumulator := newTextsAccumulator()
 
 	// Add main contents
@@ -162,19 +182,7 @@ func (tok *LocalTokenizer) CountTokens(contents []*genai.Content, config *genai.
 			textAccumulator.addSchema(config.GenerationConfig.ResponseSchema)
 		}
 	}
-
-	// Encode all accumulated texts and sum token counts
-	texts := textAccumulator.getTexts()
-	totalTokens := 0
-
-	for _, text := range texts {
-		if text != "" {
-			tokens := tok.processor.Encode(text)
-			totalTokens += len(tokens)
-		}
-	}
-
-	return &genai.CountTokensResult{TotalTokens: int32(totalTokens)}, nil
+	return textAccumulator.getTexts()
 }
 
 // ComputeTokens computes detailed token information for the given contents,
@@ -188,28 +196,35 @@ func (tok *LocalTokenizer) ComputeTokens(contents []*genai.Content) (*genai.Comp
 		}
 
 		for _, part := range content.Parts {
-			if part.Text != "" {
-				tokens := tok.processor.Encode(part.Text)
-
-				tokenIDs := make([]int64, len(tokens))
-				tokenBytes := make([][]byte, len(tokens))
-
-				for i, token := range tokens {
-					tokenIDs[i] = int64(token.ID)
-					tokenBytes[i] = []byte(token.Text)
-				}
-
-				role := "user" // Default role
-				if content.Role != "" {
-					role = content.Role
+			if part == nil {
+				continue
+			}
+			// Function calls and responses are tokenized like in CountTokens.
+			textAccumulator := newTextsAccumulator()
+			textAccumulator.addContent(&genai.Content{Parts: []*genai.Part{part}})
+
+			var tokenIDs []int64
+			var tokenBytes [][]byte
+			for _, text := range textAccumulator.getTexts() {
+				for _, token := range tok.processor.Encode(text) {
+					tokenIDs = append(tokenIDs, int64(token.ID))
+					tokenBytes = append(tokenBytes, []byte(token.Text))
 				}
+			}
+			if len(tokenIDs) == 0 {
+				continue
+			}
 
-				tokensInfo = append(tokensInfo, &genai.TokensInfo{
-					TokenIDs: tokenIDs,
-					Tokens:   tokenBytes,
-					Role:     role,
-				})
+			role := "user" // Default role
+			if content.Role != "" {
+				role = content.Role
 			}
+
+			tokensInfo = append(tokensInfo, &genai.TokensInfo{
+				TokenIDs: tokenIDs,
+				Tokens:   tokenBytes,
+				Role:     role,
+			})
 		}
 	}
 
@@ -359,61 +374,8 @@ func (ta *textsAccumulator) traverseAny(value any) {
 	}
 }
 
-// downloadModelFile downloads a file from the given URL.
-func downloadModelFile(url string) ([]byte, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	return io.ReadAll(resp.Body)
-}
-
 // hashString computes a hex string of the SHA256 hash of data.
 func hashString(data []byte) string {
 	hash256 := sha256.Sum256(data)
 	return hex.EncodeToString(hash256[:])
 }
-
-// loadModelData loads model data from the given URL, using a local file-system
-// cache. wantHash is the hash (as returned by [hashString] expected on the
-// loaded data.
-//
-// Caching logic:
-//
-// Assuming $TEMP_DIR is the temporary directory used by the OS, this function
-// uses the file $TEMP_DIR/vertexai_tokenizer_model/$urlhash as a cache, where
-// $urlhash is hashString(url).
-//
-// If this cache file doesn't exist, or the data it contains doesn't match
-// wantHash, downloads data from the URL and writes it into the cache. If the
-// URL's data doesn't match the hash, an error is returned.
-func loadModelData(url string, wantHash string) ([]byte, error) {
-	urlhash := hashString([]byte(url))
-	cacheDir := filepath.Join(os.TempDir(), "vertexai_tokenizer_model")
-	cachePath := filepath.Join(cacheDir, urlhash)
-
-	cacheData, err := os.ReadFile(cachePath)
-	if err != nil || hashString(cacheData) != wantHash {
-		cacheData, err = downloadModelFile(url)
-		if err != nil {
-			return nil, fmt.Errorf("loading cache and downloading model: %w", err)
-		}
-
-		if hashString(cacheData) != wantHash {
-			return nil, fmt.Errorf("downloaded model hash mismatch")
-		}
-
-		err = os.MkdirAll(cacheDir, 0770)
-		if err != nil {
-			return nil, fmt.Errorf("creating cache dir: %w", err)
-		}
-		err = os.WriteFile(cachePath, cacheData, 0660)
-		if err != nil {
-			return nil, fmt.Errorf("writing cache file: %w", err)
-		}
-	}
-
-	return cacheData, nil
-}
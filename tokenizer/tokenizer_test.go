@@ -14,7 +14,9 @@
 package tokenizer
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -24,7 +26,7 @@ import (
 
 func TestDownload(t *testing.T) {
 	config := tokenizers["gemma2"]
-	b, err := downloadModelFile(config.modelURL)
+	b, err := downloadModelFile(context.Background(), http.DefaultClient, config.modelURL)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -49,19 +51,20 @@ func TestLoadModelData(t *testing.T) {
 		}
 	}
 
-	data, err := loadModelData(config.modelURL, config.modelHash)
+	ctx := context.Background()
+	data, err := newOptions(nil).loadModel(ctx, config)
 	checkDataAndErr(data, err)
 
 	// The cache should exist now and have the right data, try again.
-	data, err = loadModelData(config.modelURL, config.modelHash)
+	data, err = newOptions(nil).loadModel(ctx, config)
 	checkDataAndErr(data, err)
 
 	// Overwrite cache file with wrong data, and try again.
-	cacheDir := filepath.Join(os.TempDir(), "vertexai_tokenizer_model")
+	cacheDir := DefaultCacheDir()
 	cachePath := filepath.Join(cacheDir, hashString([]byte(config.modelURL)))
 	_ = os.MkdirAll(cacheDir, 0770)
 	_ = os.WriteFile(cachePath, []byte{0, 1, 2, 3}, 0660)
-	data, err = loadModelData(config.modelURL, config.modelHash)
+	data, err = newOptions(nil).loadModel(ctx, config)
 	checkDataAndErr(data, err)
 }
 
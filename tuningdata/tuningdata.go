@@ -0,0 +1,200 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tuningdata builds, validates and splits datasets for
+// [genai.Tunings.Tune].
+//
+// A [Dataset] holds conversations made of [genai.Content] values. It can be
+// written as the JSONL file Vertex AI reads from [genai.TuningDataset.GCSURI],
+// converted to the inline [genai.TuningExample] values accepted by the Gemini
+// Developer API, checked for structural problems before a job is paid for, and
+// split into training and validation sets.
+package tuningdata
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"google.golang.org/genai"
+)
+
+// Example is a supervised fine-tuning example: a conversation that alternates
+// between user and model turns and ends with the model turn to learn.
+type Example struct {
+	// Optional. System instruction applied to the conversation.
+	SystemInstruction *genai.Content `json:"systemInstruction,omitempty"`
+	// Required. The conversation.
+	Contents []*genai.Content `json:"contents,omitempty"`
+}
+
+// PreferenceExample is a preference tuning example: a prompt conversation that
+// ends with a user turn, and a preferred and a dispreferred model response.
+type PreferenceExample struct {
+	// Optional. System instruction applied to the conversation.
+	SystemInstruction *genai.Content `json:"systemInstruction,omitempty"`
+	// Required. The prompt conversation.
+	Contents []*genai.Content `json:"contents,omitempty"`
+	// Required. The preferred model response.
+	Chosen *genai.Content `json:"chosen,omitempty"`
+	// Required. The dispreferred model response.
+	Rejected *genai.Content `json:"rejected,omitempty"`
+}
+
+// Dataset is a tuning dataset. Examples are used for
+// [genai.TuningMethodSupervisedFineTuning] and Preferences for
+// [genai.TuningMethodPreferenceTuning]; a dataset holds one kind only.
+type Dataset struct {
+	Examples    []*Example
+	Preferences []*PreferenceExample
+}
+
+// Method returns the tuning method the dataset is meant for.
+func (d *Dataset) Method() genai.TuningMethod {
+	if len(d.Preferences) > 0 {
+		return genai.TuningMethodPreferenceTuning
+	}
+	return genai.TuningMethodSupervisedFineTuning
+}
+
+// Len returns the number of examples in the dataset.
+func (d *Dataset) Len() int {
+	return len(d.Examples) + len(d.Preferences)
+}
+
+// AddConversation appends a supervised example built from a conversation log.
+func (d *Dataset) AddConversation(systemInstruction *genai.Content, contents []*genai.Content) {
+	d.Examples = append(d.Examples, &Example{SystemInstruction: systemInstruction, Contents: contents})
+}
+
+// AddPreference appends a preference example.
+func (d *Dataset) AddPreference(systemInstruction *genai.Content, contents []*genai.Content, chosen, rejected *genai.Content) {
+	d.Preferences = append(d.Preferences, &PreferenceExample{
+		SystemInstruction: systemInstruction,
+		Contents:          contents,
+		Chosen:            chosen,
+		Rejected:          rejected,
+	})
+}
+
+type vertexCompletion struct {
+	Score      float64        `json:"score"`
+	Completion *genai.Content `json:"completion"`
+}
+
+type vertexLine struct {
+	SystemInstruction *genai.Content     `json:"systemInstruction,omitempty"`
+	Contents          []*genai.Content   `json:"contents"`
+	Completions       []vertexCompletion `json:"completions,omitempty"`
+}
+
+// WriteJSONL writes the dataset in the JSONL format Vertex AI expects for
+// [genai.TuningDataset.GCSURI] and [genai.TuningValidationDataset.GCSURI].
+// The Gemini Developer API does not read tuning files; use
+// [Dataset.TuningExamples] for it instead.
+func (d *Dataset) WriteJSONL(w io.Writer) error {
+	if len(d.Examples) > 0 && len(d.Preferences) > 0 {
+		return fmt.Errorf("dataset mixes supervised and preference examples")
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, e := range d.Examples {
+		if err := enc.Encode(vertexLine{SystemInstruction: e.SystemInstruction, Contents: e.Contents}); err != nil {
+			return err
+		}
+	}
+	for _, p := range d.Preferences {
+		line := vertexLine{
+			SystemInstruction: p.SystemInstruction,
+			Contents:          p.Contents,
+			Completions: []vertexCompletion{
+				{Score: 1, Completion: p.Chosen},
+				{Score: 0, Completion: p.Rejected},
+			},
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// TuningExamples converts the dataset to the inline examples accepted by the
+// Gemini Developer API in [genai.TuningDataset.Examples]. Only single-turn,
+// text-only supervised examples without a system instruction can be
+// represented.
+func (d *Dataset) TuningExamples() ([]*genai.TuningExample, error) {
+	if len(d.Preferences) > 0 {
+		return nil, fmt.Errorf("preference tuning is not supported in Gemini API")
+	}
+	examples := make([]*genai.TuningExample, 0, len(d.Examples))
+	for i, e := range d.Examples {
+		if e.SystemInstruction != nil {
+			return nil, fmt.Errorf("example %d: systemInstruction is not supported in Gemini API", i)
+		}
+		if len(e.Contents) != 2 {
+			return nil, fmt.Errorf("example %d: Gemini API examples must have exactly one user and one model turn, got %d turns", i, len(e.Contents))
+		}
+		input, err := textOf(e.Contents[0])
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		output, err := textOf(e.Contents[1])
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		examples = append(examples, &genai.TuningExample{TextInput: input, Output: output})
+	}
+	return examples, nil
+}
+
+func textOf(c *genai.Content) (string, error) {
+	var text string
+	for _, p := range c.Parts {
+		if p == nil {
+			continue
+		}
+		if p.Text == "" {
+			return "", fmt.Errorf("only text parts are supported in Gemini API examples")
+		}
+		text += p.Text
+	}
+	return text, nil
+}
+
+// Split shuffles the dataset deterministically using seed and splits it into a
+// training set and a validation set holding validationFraction of the examples,
+// rounded to the nearest whole example. The receiver is not modified.
+func (d *Dataset) Split(validationFraction float64, seed uint64) (train, validation *Dataset, err error) {
+	if validationFraction < 0 || validationFraction >= 1 {
+		return nil, nil, fmt.Errorf("validation fraction must be in [0, 1), got %v", validationFraction)
+	}
+	r := rand.New(rand.NewPCG(seed, seed))
+	train, validation = &Dataset{}, &Dataset{}
+
+	examples := slices.Clone(d.Examples)
+	r.Shuffle(len(examples), func(i, j int) { examples[i], examples[j] = examples[j], examples[i] })
+	n := int(math.Round(float64(len(examples)) * validationFraction))
+	validation.Examples, train.Examples = examples[:n], examples[n:]
+
+	preferences := slices.Clone(d.Preferences)
+	r.Shuffle(len(preferences), func(i, j int) { preferences[i], preferences[j] = preferences[j], preferences[i] })
+	n = int(math.Round(float64(len(preferences)) * validationFraction))
+	validation.Preferences, train.Preferences = preferences[:n], preferences[n:]
+	return train, validation, nil
+}
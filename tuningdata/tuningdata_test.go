@@ -0,0 +1,217 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuningdata
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genai"
+)
+
+// wordCounter counts one token per whitespace-separated word.
+type wordCounter struct{}
+
+func (wordCounter) CountTokens(contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResult, error) {
+	all := contents
+	if config != nil && config.SystemInstruction != nil {
+		all = append([]*genai.Content{config.SystemInstruction}, contents...)
+	}
+	n := 0
+	for _, c := range all {
+		for _, p := range c.Parts {
+			n += len(strings.Fields(p.Text))
+		}
+	}
+	return &genai.CountTokensResult{TotalTokens: int32(n)}, nil
+}
+
+func user(text string) *genai.Content  { return genai.NewContentFromText(text, genai.RoleUser) }
+func model(text string) *genai.Content { return genai.NewContentFromText(text, genai.RoleModel) }
+
+func TestWriteJSONL(t *testing.T) {
+	var d Dataset
+	d.AddConversation(&genai.Content{Parts: []*genai.Part{{Text: "be brief"}}}, []*genai.Content{user("hi"), model("hello")})
+	var sb strings.Builder
+	if err := d.WriteJSONL(&sb); err != nil {
+		t.Fatalf("WriteJSONL() unexpected error: %v", err)
+	}
+	want := `{"systemInstruction":{"parts":[{"text":"be brief"}]},"contents":[{"parts":[{"text":"hi"}],"role":"user"},{"parts":[{"text":"hello"}],"role":"model"}]}
+`
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("WriteJSONL() mismatch (-want +got):\n%s", diff)
+	}
+
+	var p Dataset
+	p.AddPreference(nil, []*genai.Content{user("q")}, model("good"), model("bad"))
+	sb.Reset()
+	if err := p.WriteJSONL(&sb); err != nil {
+		t.Fatalf("WriteJSONL() unexpected error: %v", err)
+	}
+	want = `{"contents":[{"parts":[{"text":"q"}],"role":"user"}],"completions":[{"score":1,"completion":{"parts":[{"text":"good"}],"role":"model"}},{"score":0,"completion":{"parts":[{"text":"bad"}],"role":"model"}}]}
+`
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("WriteJSONL() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTuningExamples(t *testing.T) {
+	var d Dataset
+	d.AddConversation(nil, []*genai.Content{user("2+2"), model("4")})
+	got, err := d.TuningExamples()
+	if err != nil {
+		t.Fatalf("TuningExamples() unexpected error: %v", err)
+	}
+	want := []*genai.TuningExample{{TextInput: "2+2", Output: "4"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("TuningExamples() mismatch (-want +got):\n%s", diff)
+	}
+
+	d.AddConversation(nil, []*genai.Content{user("a"), model("b"), user("c"), model("d")})
+	if _, err := d.TuningExamples(); err == nil {
+		t.Errorf("TuningExamples() with a multi-turn example: want error, got nil")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var d Dataset
+	d.AddConversation(nil, []*genai.Content{user("one two"), model("three")})
+	d.AddConversation(nil, []*genai.Content{model("x"), user("y")})
+	d.AddConversation(nil, []*genai.Content{user("a b c d"), model("e f")})
+	d.AddConversation(nil, nil)
+
+	got, err := d.Validate(&ValidateConfig{TokenCounter: wordCounter{}, MaxTokensPerExample: 5})
+	if err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	want := []Issue{
+		{Index: 1, Message: `turn 0 has role "model", want "user": turns must alternate`},
+		{Index: 1, Message: `turn 1 has role "user", want "model": turns must alternate`},
+		{Index: 1, Message: `last turn has role "user", want "model"`},
+		{Index: 2, Message: "example has 6 tokens, more than the limit of 5"},
+		{Index: 3, Message: "conversation is empty"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidatePreferences(t *testing.T) {
+	var d Dataset
+	d.AddPreference(nil, []*genai.Content{user("q")}, model("good"), model("bad"))
+	d.AddPreference(nil, []*genai.Content{user("q"), model("a")}, model("good"), nil)
+	d.AddPreference(nil, []*genai.Content{user("q")}, nil, user("bad"))
+
+	got, err := d.Validate(nil)
+	if err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	want := []Issue{
+		{Index: 1, Message: `last turn has role "model", want "user"`},
+		{Index: 1, Message: "rejected response is empty"},
+		{Index: 2, Message: "chosen response is empty"},
+		{Index: 2, Message: `rejected response has role "user", want "model"`},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var d Dataset
+	d.AddConversation(nil, []*genai.Content{user("a"), model("b")})
+	d.AddConversation(genai.NewContentFromText("s s", ""), []*genai.Content{user("a b c"), model("d")})
+	d.AddConversation(nil, []*genai.Content{user("a b c d e f g h i"), model("j")})
+
+	got, err := d.Stats(wordCounter{}, []int{2, 8})
+	if err != nil {
+		t.Fatalf("Stats() unexpected error: %v", err)
+	}
+	want := &Stats{
+		Examples:    3,
+		TotalTokens: 18,
+		MinTokens:   2,
+		MaxTokens:   10,
+		MeanTokens:  6,
+		Histogram: []HistogramBucket{
+			{UpperBound: 2, Count: 1},
+			{UpperBound: 8, Count: 1},
+			{UpperBound: math.MaxInt, Count: 1},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Stats() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStatsPreferences(t *testing.T) {
+	var d Dataset
+	d.AddPreference(nil, []*genai.Content{user("a b")}, model("c"), model("d e"))
+	d.AddPreference(nil, []*genai.Content{user("a b c")}, nil, model("d"))
+
+	if _, err := d.Stats(nil, nil); err == nil {
+		t.Errorf("Stats() with a nil TokenCounter: want error, got nil")
+	}
+	got, err := d.Stats(wordCounter{}, []int{2})
+	if err != nil {
+		t.Fatalf("Stats() unexpected error: %v", err)
+	}
+	if got.Examples != 2 || got.TotalTokens != 6 {
+		t.Errorf("Stats() = %d examples with %d tokens, want 2 with 6", got.Examples, got.TotalTokens)
+	}
+
+	issues, err := d.Validate(&ValidateConfig{TokenCounter: wordCounter{}, MaxTokensPerExample: 2})
+	if err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	want := []Issue{
+		{Index: 0, Message: "example has 3 tokens, more than the limit of 2"},
+		{Index: 1, Message: "chosen response is empty"},
+		{Index: 1, Message: "example has 3 tokens, more than the limit of 2"},
+	}
+	if diff := cmp.Diff(want, issues); diff != "" {
+		t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	var d Dataset
+	for i := 0; i < 10; i++ {
+		d.AddConversation(nil, []*genai.Content{user(string(rune('a' + i))), model("x")})
+	}
+	train, validation, err := d.Split(0.2, 1)
+	if err != nil {
+		t.Fatalf("Split() unexpected error: %v", err)
+	}
+	if train.Len() != 8 || validation.Len() != 2 {
+		t.Errorf("Split() sizes = %d/%d, want 8/2", train.Len(), validation.Len())
+	}
+	seen := map[*Example]bool{}
+	for _, e := range append(train.Examples, validation.Examples...) {
+		seen[e] = true
+	}
+	if len(seen) != 10 {
+		t.Errorf("Split() lost or duplicated examples: %d distinct, want 10", len(seen))
+	}
+	train2, _, _ := d.Split(0.2, 1)
+	if diff := cmp.Diff(train.Examples, train2.Examples); diff != "" {
+		t.Errorf("Split() is not deterministic (-first +second):\n%s", diff)
+	}
+	if _, _, err := d.Split(1, 1); err == nil {
+		t.Errorf("Split(1) want error, got nil")
+	}
+}
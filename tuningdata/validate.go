@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuningdata
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"google.golang.org/genai"
+)
+
+// TokenCounter counts the tokens of contents. [tokenizer.LocalTokenizer]
+// implements it, so datasets can be measured without calling the API.
+//
+// [tokenizer.LocalTokenizer]: https://pkg.go.dev/google.golang.org/genai/tokenizer#LocalTokenizer
+type TokenCounter interface {
+	CountTokens(contents []*genai.Content, config *genai.CountTokensConfig) (*genai.CountTokensResult, error)
+}
+
+// Issue is a problem found in an example by [Dataset.Validate].
+type Issue struct {
+	// Index of the example in Dataset.Examples or Dataset.Preferences.
+	Index int
+	// Message describes the problem.
+	Message string
+}
+
+// String returns a string representation of the Issue.
+func (i Issue) String() string {
+	return fmt.Sprintf("example %d: %s", i.Index, i.Message)
+}
+
+// ValidateConfig holds optional parameters for [Dataset.Validate].
+type ValidateConfig struct {
+	// Optional. Used to count the tokens of every example. Token limits are not
+	// checked if nil.
+	TokenCounter TokenCounter
+	// Optional. Maximum number of tokens of a single example, including its
+	// system instruction. Zero means no limit.
+	MaxTokensPerExample int
+}
+
+// Validate checks every example and returns the issues found. Supervised
+// examples must alternate user and model turns, starting with a user turn and
+// ending with a model turn. Preference examples must end with a user turn and
+// have non-empty model responses. An error is returned only if counting tokens
+// fails.
+func (d *Dataset) Validate(config *ValidateConfig) ([]Issue, error) {
+	if config == nil {
+		config = &ValidateConfig{}
+	}
+	var issues []Issue
+	if len(d.Examples) > 0 && len(d.Preferences) > 0 {
+		issues = append(issues, Issue{Index: -1, Message: "dataset mixes supervised and preference examples"})
+	}
+	for i, e := range d.Examples {
+		for _, msg := range validateTurns(e.Contents, genai.RoleModel) {
+			issues = append(issues, Issue{Index: i, Message: msg})
+		}
+		msgs, err := validateTokens(config, e.SystemInstruction, e.Contents)
+		if err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+		for _, msg := range msgs {
+			issues = append(issues, Issue{Index: i, Message: msg})
+		}
+	}
+	for i, p := range d.Preferences {
+		for _, msg := range validateTurns(p.Contents, genai.RoleUser) {
+			issues = append(issues, Issue{Index: i, Message: msg})
+		}
+		responses := []struct {
+			name    string
+			content *genai.Content
+		}{{"chosen", p.Chosen}, {"rejected", p.Rejected}}
+		for _, r := range responses {
+			name, c := r.name, r.content
+			if isEmpty(c) {
+				issues = append(issues, Issue{Index: i, Message: fmt.Sprintf("%s response is empty", name)})
+			} else if c.Role != "" && c.Role != genai.RoleModel {
+				issues = append(issues, Issue{Index: i, Message: fmt.Sprintf("%s response has role %q, want %q", name, c.Role, genai.RoleModel)})
+			}
+		}
+		contents := append(slices.Clone(p.Contents), p.Chosen)
+		msgs, err := validateTokens(config, p.SystemInstruction, contents)
+		if err != nil {
+			return nil, fmt.Errorf("preference %d: %w", i, err)
+		}
+		for _, msg := range msgs {
+			issues = append(issues, Issue{Index: i, Message: msg})
+		}
+	}
+	slices.SortStableFunc(issues, func(a, b Issue) int { return a.Index - b.Index })
+	return issues, nil
+}
+
+// validateTurns checks that contents alternate between user and model turns,
+// start with a user turn and end with a turn of lastRole.
+func validateTurns(contents []*genai.Content, lastRole genai.Role) []string {
+	if len(contents) == 0 {
+		return []string{"conversation is empty"}
+	}
+	var msgs []string
+	want := genai.Role(genai.RoleUser)
+	for j, c := range contents {
+		switch {
+		case c == nil:
+			msgs = append(msgs, fmt.Sprintf("turn %d is nil", j))
+		case c.Role != genai.RoleUser && c.Role != genai.RoleModel:
+			msgs = append(msgs, fmt.Sprintf("turn %d has role %q, want %q or %q", j, c.Role, genai.RoleUser, genai.RoleModel))
+		case genai.Role(c.Role) != want:
+			msgs = append(msgs, fmt.Sprintf("turn %d has role %q, want %q: turns must alternate", j, c.Role, want))
+		case isEmpty(c):
+			msgs = append(msgs, fmt.Sprintf("turn %d has no content", j))
+		}
+		if want == genai.RoleUser {
+			want = genai.RoleModel
+		} else {
+			want = genai.RoleUser
+		}
+	}
+	if last := contents[len(contents)-1]; last != nil && genai.Role(last.Role) != lastRole {
+		msgs = append(msgs, fmt.Sprintf("last turn has role %q, want %q", last.Role, lastRole))
+	}
+	return msgs
+}
+
+func isEmpty(c *genai.Content) bool {
+	if c == nil {
+		return true
+	}
+	for _, p := range c.Parts {
+		if p != nil && (p.Text != "" || p.InlineData != nil || p.FileData != nil || p.FunctionCall != nil || p.FunctionResponse != nil) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateTokens(config *ValidateConfig, systemInstruction *genai.Content, contents []*genai.Content) ([]string, error) {
+	if config.TokenCounter == nil || config.MaxTokensPerExample <= 0 {
+		return nil, nil
+	}
+	n, err := countTokens(config.TokenCounter, systemInstruction, contents)
+	if err != nil {
+		return nil, err
+	}
+	if n > config.MaxTokensPerExample {
+		return []string{fmt.Sprintf("example has %d tokens, more than the limit of %d", n, config.MaxTokensPerExample)}, nil
+	}
+	return nil, nil
+}
+
+// countTokens counts the tokens of contents with tc. Nil contents, such as a
+// missing chosen response, are skipped; Validate reports them separately.
+func countTokens(tc TokenCounter, systemInstruction *genai.Content, contents []*genai.Content) (int, error) {
+	contents = slices.DeleteFunc(slices.Clone(contents), func(c *genai.Content) bool { return c == nil })
+	var config *genai.CountTokensConfig
+	if systemInstruction != nil {
+		config = &genai.CountTokensConfig{SystemInstruction: systemInstruction}
+	}
+	result, err := tc.CountTokens(contents, config)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.TotalTokens), nil
+}
+
+// DefaultHistogramBounds are the upper bounds of the token histogram buckets
+// used by [Dataset.Stats] when none are given.
+var DefaultHistogramBounds = []int{128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768}
+
+// HistogramBucket counts the examples whose token count is at most
+// UpperBound and above the previous bucket's UpperBound. The last bucket has
+// an UpperBound of math.MaxInt.
+type HistogramBucket struct {
+	UpperBound int
+	Count      int
+}
+
+// Stats summarizes the size of a dataset.
+type Stats struct {
+	Examples    int
+	TotalTokens int
+	MinTokens   int
+	MaxTokens   int
+	MeanTokens  float64
+	Histogram   []HistogramBucket
+}
+
+// Stats counts the tokens of every example with tc and returns a summary.
+// bounds are the histogram bucket upper bounds in increasing order; if empty,
+// [DefaultHistogramBounds] is used. For preference examples the prompt and the
+// chosen response are counted. tc must not be nil.
+func (d *Dataset) Stats(tc TokenCounter, bounds []int) (*Stats, error) {
+	if tc == nil {
+		return nil, fmt.Errorf("token counter is nil")
+	}
+	if len(bounds) == 0 {
+		bounds = DefaultHistogramBounds
+	}
+	if !slices.IsSorted(bounds) {
+		return nil, fmt.Errorf("histogram bounds must be sorted")
+	}
+	stats := &Stats{Histogram: make([]HistogramBucket, len(bounds)+1)}
+	for i, b := range bounds {
+		stats.Histogram[i].UpperBound = b
+	}
+	stats.Histogram[len(bounds)].UpperBound = math.MaxInt
+
+	add := func(systemInstruction *genai.Content, contents []*genai.Content) error {
+		n, err := countTokens(tc, systemInstruction, contents)
+		if err != nil {
+			return err
+		}
+		if stats.Examples == 0 || n < stats.MinTokens {
+			stats.MinTokens = n
+		}
+		stats.MaxTokens = max(stats.MaxTokens, n)
+		stats.Examples++
+		stats.TotalTokens += n
+		idx, _ := slices.BinarySearch(bounds, n)
+		stats.Histogram[idx].Count++
+		return nil
+	}
+	for i, e := range d.Examples {
+		if err := add(e.SystemInstruction, e.Contents); err != nil {
+			return nil, fmt.Errorf("example %d: %w", i, err)
+		}
+	}
+	for i, p := range d.Preferences {
+		if err := add(p.SystemInstruction, append(slices.Clone(p.Contents), p.Chosen)); err != nil {
+			return nil, fmt.Errorf("preference %d: %w", i, err)
+		}
+	}
+	if stats.Examples > 0 {
+		stats.MeanTokens = float64(stats.TotalTokens) / float64(stats.Examples)
+	}
+	return stats, nil
+}
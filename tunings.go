@@ -1194,6 +1194,12 @@ func (m Tunings) All(ctx context.Context) iter.Seq2[*TuningJob, error] {
 var experimentalWarningTuningsCreateOperation sync.Once
 
 // Tune creates a tuning job resource.
+//
+// On the Gemini Developer API the job is created as a long-running operation;
+// Tune fetches the created tuned model and returns it as a fully populated
+// [TuningJob]. If fetching it fails, Tune returns the error along with a
+// TuningJob holding the name of the created job only. Use
+// [Tunings.TuneOperation] to get the operation instead.
 func (t Tunings) Tune(ctx context.Context, baseModel string, trainingDataset *TuningDataset, config *CreateTuningJobConfig) (*TuningJob, error) {
 	experimentalWarningTuningsCreateOperation.Do(func() {
 		log.Println("The SDK's tuning implementation is experimental, and may change in future versions.")
@@ -1209,32 +1215,24 @@ func (t Tunings) Tune(ctx context.Context, baseModel string, trainingDataset *Tu
 			return t.tune(ctx, &baseModel, nil, trainingDataset, config)
 		}
 	} else {
-		operation, err := t.tuneMldev(ctx, &baseModel, nil, trainingDataset, config)
+		operation, err := t.TuneOperation(ctx, baseModel, trainingDataset, config)
 		if err != nil {
 			return nil, err
 		}
-		if operation == nil {
-			return nil, fmt.Errorf("operation is nil")
+		name, err := tunedModelNameFromOperation(operation)
+		if err != nil {
+			return nil, err
 		}
-		if operation.Metadata != nil {
-			if tunedModel, ok := operation.Metadata["tunedModel"].(string); ok {
-				return &TuningJob{
-					Name:  tunedModel,
-					State: JobStateQueued,
-				}, nil
-			}
+		var getConfig *GetTuningJobConfig
+		if config != nil && config.HTTPOptions != nil {
+			getConfig = &GetTuningJobConfig{HTTPOptions: cloneHTTPOptions(config.HTTPOptions)}
 		}
-
-		if operation.Name == "" {
-			return nil, fmt.Errorf("operation name is required")
+		job, err := t.Get(ctx, name, getConfig)
+		if err != nil {
+			// The job is running already, so return its name with the error.
+			return &TuningJob{Name: name, State: JobStateQueued}, fmt.Errorf("tuning job %s was created, but getting it failed: %w", name, err)
 		}
-
-		parts := strings.Split(operation.Name, "/operations/")
-		tunedModelName := parts[0]
-		return &TuningJob{
-			Name:  tunedModelName,
-			State: JobStateQueued,
-		}, nil
+		return job, nil
 	}
 }
 
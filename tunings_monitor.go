@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"time"
+)
+
+// MonitorTuningJobConfig holds optional parameters for [Tunings.Monitor] and
+// [Tunings.Wait].
+type MonitorTuningJobConfig struct {
+	// Optional. Used to override HTTP request options of each poll.
+	HTTPOptions *HTTPOptions `json:"httpOptions,omitempty"`
+	// Optional. Initial delay between two polls. Defaults to 1 second.
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+	// Optional. Upper bound of the delay between two polls, which grows by half
+	// after every poll. Defaults to 30 seconds.
+	MaxPollInterval time.Duration `json:"maxPollInterval,omitempty"`
+	// Optional. If true, the tuning job is cancelled with [Tunings.Cancel] when
+	// the context is cancelled before the job reaches a terminal state.
+	CancelOnContextDone bool `json:"cancelOnContextDone,omitempty"`
+}
+
+// TuningSnapshot is a training metric reported by the Gemini Developer API
+// while a model is tuned.
+type TuningSnapshot struct {
+	// The tuning step.
+	Step int64 `json:"step,omitempty"`
+	// The epoch this step was part of.
+	Epoch int64 `json:"epoch,omitempty"`
+	// The mean training loss of the examples of this step.
+	MeanLoss float64 `json:"meanLoss,omitempty"`
+	// The time the metric was computed.
+	ComputeTime time.Time `json:"computeTime,omitempty"`
+}
+
+// TuningJobProgress is the state of a tuning job observed by
+// [Tunings.Monitor].
+type TuningJobProgress struct {
+	// Job is the latest snapshot of the tuning job.
+	Job *TuningJob
+	// Snapshots are all the training metrics reported so far. Only the Gemini
+	// Developer API includes them in the tuning job; Vertex AI reports metrics
+	// through the job's Experiment instead, so this is always empty there.
+	Snapshots []*TuningSnapshot
+	// NewSnapshots are the metrics reported since the previous progress.
+	NewSnapshots []*TuningSnapshot
+	// Checkpoints are the intermediate checkpoints of the tuned model, with the
+	// endpoints they are deployed to.
+	Checkpoints []*TunedModelCheckpoint
+	// TunedModel is the name to pass as the model to [Models.GenerateContent].
+	// It is set only once the job has succeeded.
+	TunedModel string
+}
+
+// Done reports whether the tuning job has reached a terminal state.
+func (p *TuningJobProgress) Done() bool {
+	return jobStateDone(p.Job.State)
+}
+
+// Monitor polls the named tuning job until it reaches a terminal state and
+// returns an iterator over its progress.
+//
+// A progress is yielded on the first poll, whenever the state, the training
+// metrics or the checkpoints change, and once more for the terminal state,
+// after which the iteration ends. Errors from polling are yielded and polling
+// continues for as long as the caller keeps iterating. Cancelling ctx ends the
+// iteration with the context error, after cancelling the job if
+// config.CancelOnContextDone is set.
+func (t Tunings) Monitor(ctx context.Context, name string, config *MonitorTuningJobConfig) iter.Seq2[*TuningJobProgress, error] {
+	if config == nil {
+		config = &MonitorTuningJobConfig{}
+	}
+	return func(yield func(*TuningJobProgress, error) bool) {
+		backoff := newPollBackoff(config.PollInterval, config.MaxPollInterval, 0)
+		var last *TuningJobProgress
+		for {
+			job, snapshots, err := t.getWithSnapshots(ctx, name, config.HTTPOptions)
+			if ctx.Err() != nil {
+				yield(nil, t.cancelOnContextDone(ctx, name, config))
+				return
+			}
+			if err != nil {
+				if !yield(nil, err) {
+					return
+				}
+			} else {
+				p := newTuningJobProgress(last, job, snapshots)
+				if last == nil || tuningProgressChanged(last, p) {
+					if !yield(p, nil) {
+						return
+					}
+				}
+				if p.Done() {
+					return
+				}
+				last = p
+			}
+			if err := sleepContext(ctx, backoff.next()); err != nil {
+				yield(nil, t.cancelOnContextDone(ctx, name, config))
+				return
+			}
+		}
+	}
+}
+
+// Wait polls the named tuning job until it reaches a terminal state and
+// returns its final progress. An error is returned if polling fails or the job
+// does not succeed; on success, the returned TunedModel is ready to be used with
+// [Models.GenerateContent].
+func (t Tunings) Wait(ctx context.Context, name string, config *MonitorTuningJobConfig) (*TuningJobProgress, error) {
+	for p, err := range t.Monitor(ctx, name, config) {
+		if err != nil {
+			return nil, err
+		}
+		if !p.Done() {
+			continue
+		}
+		if p.Job.State != JobStateSucceeded {
+			if p.Job.Error != nil {
+				return p, fmt.Errorf("tuning job %s ended in state %s: code %d, message: %s", name, p.Job.State, p.Job.Error.Code, p.Job.Error.Message)
+			}
+			return p, fmt.Errorf("tuning job %s ended in state %s", name, p.Job.State)
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("tuning job %s: monitoring ended before the job finished", name)
+}
+
+func (t Tunings) cancelOnContextDone(ctx context.Context, name string, config *MonitorTuningJobConfig) error {
+	if !config.CancelOnContextDone {
+		return ctx.Err()
+	}
+	var cancelConfig *CancelTuningJobConfig
+	if config.HTTPOptions != nil {
+		cancelConfig = &CancelTuningJobConfig{HTTPOptions: cloneHTTPOptions(config.HTTPOptions)}
+	}
+	if _, err := t.Cancel(context.WithoutCancel(ctx), name, cancelConfig); err != nil {
+		return errors.Join(ctx.Err(), fmt.Errorf("cancelling tuning job %s: %w", name, err))
+	}
+	return ctx.Err()
+}
+
+// getWithSnapshots fetches the tuning job. On the Gemini Developer API the raw
+// tuned model is read as well, since the training metrics it holds are not part
+// of [TuningJob].
+func (t Tunings) getWithSnapshots(ctx context.Context, name string, httpOptions *HTTPOptions) (*TuningJob, []*TuningSnapshot, error) {
+	// The requests fill in the headers, so the options of the caller are copied.
+	httpOptions = cloneHTTPOptions(httpOptions)
+	if t.apiClient.clientConfig.Backend == BackendVertexAI {
+		var config *GetTuningJobConfig
+		if httpOptions != nil {
+			config = &GetTuningJobConfig{HTTPOptions: httpOptions}
+		}
+		job, err := t.Get(ctx, name, config)
+		return job, nil, err
+	}
+	if httpOptions == nil {
+		httpOptions = &HTTPOptions{}
+	}
+	if httpOptions.Headers == nil {
+		httpOptions.Headers = http.Header{}
+	}
+	responseMap, err := sendRequest(ctx, t.apiClient, name, http.MethodGet, nil, httpOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	var task struct {
+		Snapshots []*TuningSnapshot `json:"snapshots"`
+	}
+	if raw, ok := getValueByPath(responseMap, []string{"tuningTask"}).(map[string]any); ok {
+		if err := mapToStruct(raw, &task); err != nil {
+			return nil, nil, err
+		}
+	}
+	jobMap, err := tuningJobFromMldev(responseMap, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	job := new(TuningJob)
+	if err := mapToStruct(jobMap, job); err != nil {
+		return nil, nil, err
+	}
+	return job, task.Snapshots, nil
+}
+
+// cloneHTTPOptions returns a copy of o that requests can fill in without
+// modifying o.
+func cloneHTTPOptions(o *HTTPOptions) *HTTPOptions {
+	if o == nil {
+		return nil
+	}
+	c := *o
+	c.Headers = c.Headers.Clone()
+	return &c
+}
+
+func newTuningJobProgress(last *TuningJobProgress, job *TuningJob, snapshots []*TuningSnapshot) *TuningJobProgress {
+	p := &TuningJobProgress{Job: job, Snapshots: snapshots}
+	seen := 0
+	if last != nil {
+		seen = len(last.Snapshots)
+	}
+	if len(snapshots) > seen {
+		p.NewSnapshots = snapshots[seen:]
+	}
+	if job.TunedModel != nil {
+		p.Checkpoints = job.TunedModel.Checkpoints
+		if job.State == JobStateSucceeded {
+			// Vertex AI serves tuned models from their endpoint. The Gemini
+			// Developer API reports the tuned model name as the endpoint.
+			p.TunedModel = job.TunedModel.Endpoint
+		}
+	}
+	return p
+}
+
+func tuningProgressChanged(last, p *TuningJobProgress) bool {
+	if last.Job.State != p.Job.State || len(p.NewSnapshots) > 0 || len(last.Checkpoints) != len(p.Checkpoints) {
+		return true
+	}
+	for i, c := range p.Checkpoints {
+		if c.Endpoint != last.Checkpoints[i].Endpoint {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTuningsMonitorGeminiAPI(t *testing.T) {
+	ctx := context.Background()
+	responses := []string{
+		`{"name":"tunedModels/m","state":"CREATING","tuningTask":{"snapshots":[{"step":1,"epoch":1,"meanLoss":2.5}]}}`,
+		`{"name":"tunedModels/m","state":"CREATING","tuningTask":{"snapshots":[{"step":1,"epoch":1,"meanLoss":2.5}]}}`,
+		`{"name":"tunedModels/m","state":"CREATING","tuningTask":{"snapshots":[{"step":1,"epoch":1,"meanLoss":2.5},{"step":2,"epoch":1,"meanLoss":1.5}]}}`,
+		`{"name":"tunedModels/m","state":"ACTIVE","tuningTask":{"snapshots":[{"step":1,"epoch":1,"meanLoss":2.5},{"step":2,"epoch":1,"meanLoss":1.5}]}}`,
+	}
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1beta/tunedModels/m" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(responses[min(polls, len(responses)-1)]))
+		polls++
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	config := &MonitorTuningJobConfig{HTTPOptions: &HTTPOptions{}, PollInterval: time.Millisecond, MaxPollInterval: time.Millisecond}
+	type step struct {
+		State        JobState
+		NewSnapshots []*TuningSnapshot
+		TunedModel   string
+	}
+	var got []step
+	for p, err := range client.Tunings.Monitor(ctx, "tunedModels/m", config) {
+		if err != nil {
+			t.Fatalf("Monitor() unexpected error: %v", err)
+		}
+		got = append(got, step{p.Job.State, p.NewSnapshots, p.TunedModel})
+	}
+	want := []step{
+		{State: JobStateRunning, NewSnapshots: []*TuningSnapshot{{Step: 1, Epoch: 1, MeanLoss: 2.5}}},
+		{State: JobStateRunning, NewSnapshots: []*TuningSnapshot{{Step: 2, Epoch: 1, MeanLoss: 1.5}}},
+		{State: JobStateSucceeded, TunedModel: "tunedModels/m"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Monitor() mismatch (-want +got):\n%s", diff)
+	}
+	if polls != len(responses) {
+		t.Errorf("polls = %d, want %d", polls, len(responses))
+	}
+	if config.HTTPOptions.Headers != nil {
+		t.Errorf("Monitor() modified the HTTPOptions of the config: %v", config.HTTPOptions)
+	}
+}
+
+func TestTuningsWaitFailed(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"projects/p/locations/l/tuningJobs/1","state":"JOB_STATE_FAILED","error":{"code":3,"message":"bad dataset"}}`))
+	}))
+	defer ts.Close()
+	client, err := NewClient(ctx, &ClientConfig{
+		Backend:     BackendVertexAI,
+		Project:     "test-project",
+		Location:    "us-central1",
+		HTTPOptions: HTTPOptions{BaseURL: ts.URL},
+		HTTPClient:  ts.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	p, err := client.Tunings.Wait(ctx, "projects/p/locations/l/tuningJobs/1", nil)
+	if err == nil {
+		t.Fatalf("Wait() want error, got nil")
+	}
+	if p == nil || p.Job.State != JobStateFailed || p.TunedModel != "" {
+		t.Errorf("Wait() progress = %+v, want failed job without tuned model", p)
+	}
+}
+
+func TestTuningsMonitorCancelOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelled := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta/tunedModels/m":
+			_, _ = w.Write([]byte(`{"name":"tunedModels/m","state":"CREATING"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1beta/tunedModels/m:cancel":
+			cancelled = true
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	config := &MonitorTuningJobConfig{PollInterval: time.Hour, CancelOnContextDone: true}
+	var gotErr error
+	for p, err := range client.Tunings.Monitor(ctx, "tunedModels/m", config) {
+		if err != nil {
+			gotErr = err
+			continue
+		}
+		if p.Done() {
+			t.Fatalf("Monitor() job unexpectedly done")
+		}
+		cancel()
+	}
+	if !errors.Is(gotErr, context.Canceled) {
+		t.Errorf("Monitor() error = %v, want %v", gotErr, context.Canceled)
+	}
+	if !cancelled {
+		t.Errorf("Monitor() did not cancel the tuning job")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// TuneOperation starts tuning a model and returns the long-running operation
+// that tracks it. Poll it with [Operations.GetTuningOperation]; its metadata
+// holds the tuned model name and the tuning progress.
+func (t Tunings) TuneOperation(ctx context.Context, baseModel string, trainingDataset *TuningDataset, config *CreateTuningJobConfig) (*TuningOperation, error) {
+	if t.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, fmt.Errorf("method TuneOperation is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
+	}
+	experimentalWarningTuningsCreateOperation.Do(func() {
+		log.Println("The SDK's tuning implementation is experimental, and may change in future versions.")
+	})
+	if err := validateCreateTuningJobConfigMldev(config); err != nil {
+		return nil, err
+	}
+	operation, err := t.tuneMldev(ctx, &baseModel, nil, trainingDataset, withLearningRateMultiplierMldev(config))
+	if err != nil {
+		return nil, err
+	}
+	if operation == nil {
+		return nil, fmt.Errorf("operation is nil")
+	}
+	return operation, nil
+}
+
+// validateCreateTuningJobConfigMldev rejects the settings the Gemini Developer
+// API cannot honor and that the request converter does not already reject.
+func validateCreateTuningJobConfigMldev(config *CreateTuningJobConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Method != "" && config.Method != TuningMethodSupervisedFineTuning {
+		return fmt.Errorf("method %s is not supported in Gemini API", config.Method)
+	}
+	if config.LearningRate != nil && config.LearningRateMultiplier != nil {
+		return fmt.Errorf("learningRate and learningRateMultiplier are mutually exclusive")
+	}
+	return nil
+}
+
+// withLearningRateMultiplierMldev returns a copy of config with its own
+// HTTPOptions, so the request does not change the caller's headers. The copy
+// sends LearningRateMultiplier in the extra body of the request, since the
+// request converter drops it. Fields set in the caller's ExtraBody take
+// precedence.
+func withLearningRateMultiplierMldev(config *CreateTuningJobConfig) *CreateTuningJobConfig {
+	if config == nil {
+		return nil
+	}
+	c := *config
+	c.HTTPOptions = cloneHTTPOptions(config.HTTPOptions)
+	if config.LearningRateMultiplier == nil {
+		return &c
+	}
+	if c.HTTPOptions == nil {
+		c.HTTPOptions = &HTTPOptions{}
+	}
+	extraBody := map[string]any{
+		"tuningTask": map[string]any{
+			"hyperparameters": map[string]any{
+				"learningRateMultiplier": *config.LearningRateMultiplier,
+			},
+		},
+	}
+	recursiveMapMerge(extraBody, c.HTTPOptions.ExtraBody)
+	c.HTTPOptions.ExtraBody = extraBody
+	return &c
+}
+
+// tunedModelNameFromOperation returns the name of the tuned model created by a
+// Gemini Developer API tuning operation.
+func tunedModelNameFromOperation(operation *TuningOperation) (string, error) {
+	if name, ok := operation.Metadata["tunedModel"].(string); ok && name != "" {
+		return name, nil
+	}
+	if operation.Name == "" {
+		return "", fmt.Errorf("operation name is required")
+	}
+	name, _, _ := strings.Cut(operation.Name, "/operations/")
+	return name, nil
+}
+
+// GetTuningOperation retrieves the status of a tuning operation returned by
+// [Tunings.TuneOperation].
+//
+// If the tuning is still in progress, the returned TuningOperation will have
+// Done set to false. Once it has completed, Done will be true, and the Error
+// field will be populated if tuning failed.
+func (m Operations) GetTuningOperation(ctx context.Context, operation *TuningOperation, config *GetOperationConfig) (*TuningOperation, error) {
+	if operation == nil || operation.Name == "" {
+		return nil, fmt.Errorf("Operation name is empty")
+	}
+	if m.apiClient.clientConfig.Backend == BackendVertexAI {
+		return nil, fmt.Errorf("method GetTuningOperation is only supported in the Gemini Developer client. You can choose to use Gemini Developer client by setting ClientConfig.Backend to BackendGeminiAPI.")
+	}
+	var httpOptions *HTTPOptions
+	if config == nil || config.HTTPOptions == nil {
+		httpOptions = &HTTPOptions{}
+	} else {
+		httpOptions = config.HTTPOptions
+	}
+	if httpOptions.Headers == nil {
+		httpOptions.Headers = http.Header{}
+	}
+	responseMap, err := sendRequest(ctx, m.apiClient, operation.Name, http.MethodGet, nil, httpOptions)
+	if err != nil {
+		return nil, err
+	}
+	response := new(TuningOperation)
+	if err := mapToStruct(responseMap, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
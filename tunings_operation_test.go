@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestTuningsTuneGeminiAPIHydratesJob(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1beta/tunedModels":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Failed to decode request: %v", err)
+			}
+			want := map[string]any{
+				"epochCount":             float64(3),
+				"batchSize":              float64(8),
+				"learningRateMultiplier": float64(0.5),
+			}
+			if diff := cmp.Diff(want, getValueByPath(body, []string{"tuningTask", "hyperparameters"})); diff != "" {
+				t.Errorf("hyperparameters mismatch (-want +got):\n%s", diff)
+			}
+			_, _ = w.Write([]byte(`{"name":"tunedModels/m/operations/op","metadata":{"tunedModel":"tunedModels/m"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta/tunedModels/m":
+			_, _ = w.Write([]byte(`{"name":"tunedModels/m","baseModel":"models/gemini-1.5-flash-001","state":"CREATING","description":"d"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	config := &CreateTuningJobConfig{
+		EpochCount:             Ptr[int32](3),
+		BatchSize:              Ptr[int32](8),
+		LearningRateMultiplier: Ptr[float32](0.5),
+	}
+	job, err := client.Tunings.Tune(ctx, "models/gemini-1.5-flash-001", &TuningDataset{
+		Examples: []*TuningExample{{TextInput: "a", Output: "b"}},
+	}, config)
+	if err != nil {
+		t.Fatalf("Tune() unexpected error: %v", err)
+	}
+	if config.HTTPOptions != nil {
+		t.Errorf("Tune() set the HTTPOptions of the config to %+v, want nil", config.HTTPOptions)
+	}
+	want := &TuningJob{
+		Name:        "tunedModels/m",
+		State:       JobStateRunning,
+		BaseModel:   "models/gemini-1.5-flash-001",
+		Description: "d",
+		TunedModel:  &TunedModel{Model: "tunedModels/m", Endpoint: "tunedModels/m"},
+	}
+	if diff := cmp.Diff(want, job, cmpopts.IgnoreFields(TuningJob{}, "SDKHTTPResponse")); diff != "" {
+		t.Errorf("Tune() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTuningsTuneGeminiAPIUnsupportedConfig(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	dataset := &TuningDataset{Examples: []*TuningExample{{TextInput: "a", Output: "b"}}}
+	for _, config := range []*CreateTuningJobConfig{
+		{Method: TuningMethodPreferenceTuning},
+		{LearningRate: Ptr[float32](0.1), LearningRateMultiplier: Ptr[float32](2)},
+		{AdapterSize: AdapterSizeFour},
+		{ValidationDataset: &TuningValidationDataset{GCSURI: "gs://b/v.jsonl"}},
+	} {
+		if _, err := client.Tunings.Tune(ctx, "models/gemini-1.5-flash-001", dataset, config); err == nil {
+			t.Errorf("Tune(%+v) want error, got nil", config)
+		}
+	}
+}
+
+func TestTuningsTuneGeminiAPIGetFails(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1beta/tunedModels":
+			_, _ = w.Write([]byte(`{"name":"tunedModels/m/operations/op","metadata":{"tunedModel":"tunedModels/m"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1beta/tunedModels/m":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"code":404,"message":"not found","status":"NOT_FOUND"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	config := &CreateTuningJobConfig{HTTPOptions: &HTTPOptions{}}
+	job, err := client.Tunings.Tune(ctx, "models/gemini-1.5-flash-001", &TuningDataset{
+		Examples: []*TuningExample{{TextInput: "a", Output: "b"}},
+	}, config)
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		t.Errorf("Tune() error = %v, want a 404 APIError", err)
+	}
+	if diff := cmp.Diff(&TuningJob{Name: "tunedModels/m", State: JobStateQueued}, job); diff != "" {
+		t.Errorf("Tune() mismatch (-want +got):\n%s", diff)
+	}
+	if config.HTTPOptions.Headers != nil {
+		t.Errorf("Tune() set the headers of the config to %v, want nil", config.HTTPOptions.Headers)
+	}
+}
+
+func TestOperationsGetTuningOperation(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1beta/tunedModels/m/operations/op" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"name":"tunedModels/m/operations/op","done":true,"metadata":{"completedPercent":100}}`))
+	}))
+	defer ts.Close()
+	client := newTestBatchesClient(t, ts)
+
+	got, err := client.Operations.GetTuningOperation(ctx, &TuningOperation{Name: "tunedModels/m/operations/op"}, nil)
+	if err != nil {
+		t.Fatalf("GetTuningOperation() unexpected error: %v", err)
+	}
+	want := &TuningOperation{Name: "tunedModels/m/operations/op", Done: true, Metadata: map[string]any{"completedPercent": float64(100)}}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(TuningOperation{}, "SDKHTTPResponse")); diff != "" {
+		t.Errorf("GetTuningOperation() mismatch (-want +got):\n%s", diff)
+	}
+}